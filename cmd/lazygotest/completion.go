@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/primary/headless"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/config"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// subcommands are offered as the first argument
+var subcommands = []string{"run", "list", "completion"}
+
+// completionScripts are sourced by the shells. They pass the words typed so
+// far to `lazygotest __complete`, which prints one candidate per line.
+// Candidates ending in "/" or "." are partial (a directory or a "pkg."
+// stem), so no space is added after them.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for lazygotest
+_lazygotest() {
+    local IFS=$'\n'
+    COMPREPLY=($(lazygotest __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == *[/.] ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _lazygotest lazygotest
+`,
+	"zsh": `#compdef lazygotest
+# zsh completion for lazygotest
+_lazygotest() {
+    local -a candidates spaced unspaced
+    local c
+    candidates=("${(@f)$(lazygotest __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    for c in $candidates; do
+        [[ -n $c ]] || continue
+        if [[ $c == *[/.] ]]; then
+            unspaced+=$c
+        else
+            spaced+=$c
+        fi
+    done
+    compadd -Q -S '' -a unspaced
+    compadd -Q -a spaced
+}
+if [[ $funcstack[1] == _lazygotest ]]; then
+    _lazygotest "$@"
+else
+    compdef _lazygotest lazygotest
+fi
+`,
+	"fish": `# fish completion for lazygotest
+function __lazygotest_complete
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    lazygotest __complete $tokens (commandline -ct) 2>/dev/null
+end
+complete -c lazygotest -f -a '(__lazygotest_complete)'
+`,
+}
+
+// runCompletion implements `lazygotest completion <shell>`: it prints the
+// completion script for the shell
+func runCompletion(args []string) int {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		fmt.Fprintln(os.Stderr, "Usage: lazygotest completion bash|zsh|fish")
+		return 2
+	}
+	fmt.Print(completionScripts[args[0]])
+	return 0
+}
+
+// runComplete implements the hidden `lazygotest __complete` command used by
+// the completion scripts. args are the words after the program name; the
+// last one is the word being completed.
+func runComplete(args []string) int {
+	current := ""
+	if len(args) > 0 {
+		current = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	candidates, err := completeWords(args, current)
+	if err != nil {
+		// The completion scripts discard stderr
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		return 1
+	}
+	for _, candidate := range candidates {
+		fmt.Println(candidate)
+	}
+	return 0
+}
+
+// completeWords returns the candidates for current given the preceding words
+func completeWords(words []string, current string) ([]string, error) {
+	command := ""
+	if len(words) > 0 && isSubcommand(words[0]) {
+		command, words = words[0], words[1:]
+	}
+	if command == "completion" {
+		return matching(sortedShells(), current), nil
+	}
+
+	var extra []func(*flag.FlagSet, *cliOptions)
+	if command == "list" {
+		extra = append(extra, listFlags)
+	}
+	opts := &cliOptions{set: make(map[string]bool)}
+	fs := newFlagSet("lazygotest", opts, extra...)
+	fs.SetOutput(io.Discard)
+	// Parse what has been typed so far for -tags and -replay; errors in
+	// unfinished input are expected
+	_ = fs.Parse(words)
+
+	// The value of a flag given as a separate word
+	if len(words) > 0 {
+		if f := lookupFlag(fs, words[len(words)-1]); f != nil && !isBoolFlag(f) {
+			return completeFlagValue(opts, f.Name, current)
+		}
+	}
+
+	if strings.HasPrefix(current, "-") && current != "-" {
+		if name, value, ok := strings.Cut(current, "="); ok {
+			f := lookupFlag(fs, name)
+			if f == nil {
+				return nil, nil
+			}
+			values, err := completeFlagValue(opts, f.Name, value)
+			for i := range values {
+				values[i] = name + "=" + values[i]
+			}
+			return values, err
+		}
+		return completeFlagNames(fs, current), nil
+	}
+
+	candidates, err := headless.CompletePackages(context.Background(), completeConfig(opts), current)
+	if err != nil {
+		return nil, err
+	}
+	if command == "" && len(words) == 0 {
+		candidates = append(matching(subcommands, current), candidates...)
+	}
+	return candidates, nil
+}
+
+// completeFlagValue returns the candidates for the value of the named flag
+func completeFlagValue(opts *cliOptions, name, prefix string) ([]string, error) {
+	switch name {
+	case "focus":
+		return headless.CompleteTests(context.Background(), completeConfig(opts), prefix)
+	case "fuzz":
+		candidates, err := headless.CompleteTests(context.Background(), completeConfig(opts), prefix)
+		// Keep the package stems and the fuzz targets
+		var targets []string
+		for _, candidate := range candidates {
+			id, ok := domain.ParseTestID(candidate)
+			if strings.HasSuffix(candidate, ".") || ok && domain.KindOf(id.Name) == domain.KindFuzz {
+				targets = append(targets, candidate)
+			}
+		}
+		return targets, err
+	case "replay":
+		return completeFiles(prefix, false), nil
+	case "C":
+		return completeFiles(prefix, true), nil
+	case "profile":
+		cfg, err := config.Load(opts.configDir())
+		if err != nil {
+			return nil, err
+		}
+		return matching(cfg.ProfileNames(), prefix), nil
+	}
+	return nil, nil
+}
+
+// completeConfig builds the completion sources from the flags typed so far
+// and the configuration files
+func completeConfig(opts *cliOptions) headless.CompleteConfig {
+	cfg, err := config.Load(opts.configDir())
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	if err := opts.applyConfig(cfg); err != nil {
+		// An unknown -profile: complete with the plain configuration
+		opts.profile = ""
+		_ = opts.applyConfig(cfg)
+	}
+	// Never wait on stdin while completing
+	opts.patterns = nil
+
+	initLogger(opts)
+	src := newSources(opts, cfg)
+
+	return headless.CompleteConfig{
+		RunOptions:  opts.runOptions(),
+		Runner:      src.runner,
+		PackageRepo: src.pkgRepo,
+	}
+}
+
+// completeFlagNames lists the flags starting with prefix, keeping its
+// single or double dash
+func completeFlagNames(fs *flag.FlagSet, prefix string) []string {
+	dashes := "-"
+	if strings.HasPrefix(prefix, "--") {
+		dashes = "--"
+	}
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, dashes+f.Name)
+	})
+	return matching(names, prefix)
+}
+
+// completeFiles lists the files and directories starting with prefix, or
+// only the directories
+func completeFiles(prefix string, dirsOnly bool) []string {
+	matches, _ := filepath.Glob(prefix + "*")
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			match += string(filepath.Separator)
+		} else if dirsOnly {
+			continue
+		}
+		files = append(files, match)
+	}
+	return files
+}
+
+// lookupFlag returns the flag named by a "-name" or "--name" word
+func lookupFlag(fs *flag.FlagSet, word string) *flag.Flag {
+	if !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return nil
+	}
+	return fs.Lookup(strings.TrimLeft(word, "-"))
+}
+
+// isBoolFlag reports whether the flag takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func isSubcommand(word string) bool {
+	for _, command := range subcommands {
+		if word == command {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedShells() []string {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+// matching returns the words starting with prefix
+func matching(words []string, prefix string) []string {
+	var matches []string
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			matches = append(matches, word)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/config"
+)
+
+// fakeLazygotest is put on PATH for the completion scripts: it records its
+// arguments, one per line, and prints the candidates of $CANDIDATES
+const fakeLazygotest = `#!/bin/sh
+printf '%s\n' "$@" > "$ARGS_FILE"
+printf '%s' "$CANDIDATES"
+`
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "bin", "lazygotest"), fakeLazygotest)
+	if err := os.Chmod(filepath.Join(dir, "bin", "lazygotest"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "lazygotest.bash")
+	writeFile(t, script, completionScripts["bash"])
+
+	tests := []struct {
+		name       string
+		words      []string
+		cword      int
+		candidates string
+		// args are the words passed to lazygotest
+		args    []string
+		reply   []string
+		nospace bool
+	}{
+		{
+			name:       "test names",
+			words:      []string{"lazygotest", "-focus", "example.com/a."},
+			cword:      2,
+			candidates: "example.com/a.TestOne\nexample.com/a.TestTwo\n",
+			args:       []string{"__complete", "-focus", "example.com/a."},
+			reply:      []string{"example.com/a.TestOne", "example.com/a.TestTwo"},
+		},
+		{
+			// The words after the cursor are left out
+			name:       "middle word",
+			words:      []string{"lazygotest", "run", "./", "-race"},
+			cword:      2,
+			candidates: "./...\n./a\n",
+			args:       []string{"__complete", "run", "./"},
+			reply:      []string{"./...", "./a"},
+		},
+		{
+			name:       "package stem",
+			words:      []string{"lazygotest", "-focus", "ex"},
+			cword:      2,
+			candidates: "example.com/a.\n",
+			args:       []string{"__complete", "-focus", "ex"},
+			reply:      []string{"example.com/a."},
+			nospace:    true,
+		},
+		{
+			name:       "directory",
+			words:      []string{"lazygotest", "-C", "sub"},
+			cword:      2,
+			candidates: "sub/\n",
+			args:       []string{"__complete", "-C", "sub"},
+			reply:      []string{"sub/"},
+			nospace:    true,
+		},
+		{
+			// Candidates are split on newlines only
+			name:       "spaces",
+			words:      []string{"lazygotest", "-replay", "my "},
+			cword:      2,
+			candidates: "my run.json\n",
+			args:       []string{"__complete", "-replay", "my "},
+			reply:      []string{"my run.json"},
+		},
+		{
+			name:  "no candidates",
+			words: []string{"lazygotest", "-profile", "x"},
+			cword: 2,
+			args:  []string{"__complete", "-profile", "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argsFile := filepath.Join(t.TempDir(), "args")
+			// compopt only works in a completion started by readline
+			driver := `source "$1"
+compopt() { printf 'compopt'; printf ' %s' "$@"; echo; }
+COMP_WORDS=(` + shellWords(tt.words) + `)
+COMP_CWORD=` + strconv.Itoa(tt.cword) + `
+_lazygotest
+printf 'reply %s\n' "${COMPREPLY[@]}"
+`
+			cmd := exec.Command(bash, "--norc", "-c", driver, "bash", script)
+			cmd.Env = append(os.Environ(),
+				"PATH="+filepath.Join(dir, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"),
+				"ARGS_FILE="+argsFile,
+				"CANDIDATES="+tt.candidates,
+			)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%v: %s", err, out)
+			}
+
+			args, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Split(strings.TrimSuffix(string(args), "\n"), "\n"); !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("__complete got %q, want %q", got, tt.args)
+			}
+
+			var reply []string
+			nospace := false
+			for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+				switch {
+				case line == "compopt -o nospace":
+					nospace = true
+				case strings.HasPrefix(line, "reply ") && line != "reply ":
+					reply = append(reply, strings.TrimPrefix(line, "reply "))
+				}
+			}
+			if !reflect.DeepEqual(reply, tt.reply) {
+				t.Errorf("COMPREPLY = %q, want %q", reply, tt.reply)
+			}
+			if nospace != tt.nospace {
+				t.Errorf("nospace = %v, want %v", nospace, tt.nospace)
+			}
+		})
+	}
+}
+
+// shellWords quotes words for a shell array
+func shellWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// TestCompletionScriptSyntax checks every script parses in its shell, when
+// the shell is installed
+func TestCompletionScriptSyntax(t *testing.T) {
+	for _, shell := range sortedShells() {
+		t.Run(shell, func(t *testing.T) {
+			path, err := exec.LookPath(shell)
+			if err != nil {
+				t.Skipf("%s is not installed", shell)
+			}
+			script := filepath.Join(t.TempDir(), "lazygotest."+shell)
+			writeFile(t, script, completionScripts[shell])
+			if out, err := exec.Command(path, "-n", script).CombinedOutput(); err != nil {
+				t.Errorf("%s -n: %v: %s", shell, err, out)
+			}
+			if !strings.Contains(completionScripts[shell], "lazygotest __complete") {
+				t.Errorf("%s script does not call lazygotest __complete", shell)
+			}
+		})
+	}
+}
+
+// completeModule writes a module with a test, a fuzz target and two
+// profiles, and returns its directory
+func completeModule(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/cw\n\ngo 1.23\n")
+	writeFile(t, filepath.Join(dir, "a", "a_test.go"), "package a\n\nimport \"testing\"\n\n"+
+		"func TestOne(t *testing.T) {}\n\nfunc FuzzParse(f *testing.F) {}\n")
+	writeFile(t, filepath.Join(dir, "a", "b", "b_test.go"), "package b\n\nimport \"testing\"\n\n"+
+		"func TestTwo(t *testing.T) {}\n")
+	writeFile(t, filepath.Join(dir, config.ProjectFileName), "profiles:\n  ci:\n    race: true\n  cover:\n    cover: true\n")
+	return dir
+}
+
+func TestCompleteWords(t *testing.T) {
+	dir := completeModule(t)
+
+	tests := []struct {
+		name    string
+		words   []string
+		current string
+		want    []string
+		// lists tests, building the packages
+		lists bool
+	}{
+		{
+			name:  "packages",
+			words: []string{"-C", dir},
+			want: []string{
+				"example.com/...", "example.com/cw/...", "example.com/cw/a", "example.com/cw/a/...",
+				"example.com/cw/a/b", "example.com/cw/a/b/...",
+			},
+		},
+		{
+			name:    "relative packages",
+			words:   []string{"run", "-C", dir},
+			current: "./a",
+			want:    []string{"./a", "./a/...", "./a/b", "./a/b/..."},
+		},
+		{
+			name:    "subcommands first",
+			current: "r",
+			want:    []string{"run"},
+		},
+		{
+			name:  "shells",
+			words: []string{"completion"},
+			want:  []string{"bash", "fish", "zsh"},
+		},
+		{
+			name:    "flag names",
+			current: "--pro",
+			want:    []string{"--profile"},
+		},
+		{
+			name:    "profiles",
+			words:   []string{"-C", dir, "-profile"},
+			current: "c",
+			want:    []string{"ci", "cover"},
+		},
+		{
+			name:    "profiles after =",
+			words:   []string{"-C", dir},
+			current: "-profile=ci",
+			want:    []string{"-profile=ci"},
+		},
+		{
+			// Tests are only listed once the prefix names a package
+			name:    "package stems",
+			words:   []string{"-C", dir, "-focus"},
+			current: "example.com/cw/a",
+			want:    []string{"example.com/cw/a.", "example.com/cw/a/b."},
+		},
+		{
+			name:    "test names",
+			words:   []string{"-C", dir, "-focus"},
+			current: "example.com/cw/a.",
+			want:    []string{"example.com/cw/a.FuzzParse", "example.com/cw/a.TestOne"},
+			lists:   true,
+		},
+		{
+			name:    "fuzz targets",
+			words:   []string{"-C", dir},
+			current: "-fuzz=./a.",
+			want:    []string{"-fuzz=./a.FuzzParse"},
+			lists:   true,
+		},
+		{
+			name:    "directories",
+			words:   []string{"-C"},
+			current: filepath.Join(dir, "a"),
+			want:    []string{filepath.Join(dir, "a") + string(filepath.Separator)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.lists && testing.Short() {
+				t.Skip("builds the test packages")
+			}
+			got, err := completeWords(tt.words, tt.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completeWords(%q, %q) = %q, want %q", tt.words, tt.current, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/config"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/pkgrepo"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+const (
+	defaultTimeout        = 2 * time.Minute
+	defaultStallThreshold = 30 * time.Second
+	defaultEditor         = "nvim"
+)
+
+// cliOptions holds the parsed command line flags
+type cliOptions struct {
+	version      bool
+	watch        bool
+	cover        bool
+	race         bool
+	short        bool
+	debug        bool
+	timeout      time.Duration
+	tags         string
+	editor       string
+	parallel     int
+	testParallel int
+	bench        string
+	benchtime    string
+	benchmem     bool
+	count        int
+	fuzz         string
+	fuzzID       domain.TestID
+	fuzztime     string
+	benchSave    string
+	benchBase    string
+	diffBase     string
+	stall        time.Duration
+	dir          string
+	profile      string
+	envFlags     stringList
+	env          []string
+	unsetEnv     []string
+	// args are the package patterns given on the command line; patterns
+	// falls back to the configured ones when there are none
+	args        []string
+	patterns    []string
+	replay      stringList
+	replaySpeed float64
+	jsonOutput  bool
+	focus       stringList
+	focusIDs    []domain.TestID
+
+	// set records the flags given explicitly on the command line
+	set map[string]bool
+}
+
+// parseFlags parses the command line arguments of the named command. extra
+// registers command-specific flags. Call applyConfig and validate before
+// using the result.
+func parseFlags(name string, args []string, output io.Writer, extra ...func(*flag.FlagSet, *cliOptions)) (*cliOptions, error) {
+	opts := &cliOptions{set: make(map[string]bool)}
+
+	fs := newFlagSet(name, opts, extra...)
+	fs.SetOutput(output)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	opts.args = fs.Args()
+	opts.patterns = opts.args
+	fs.Visit(func(f *flag.Flag) {
+		opts.set[f.Name] = true
+	})
+
+	return opts, nil
+}
+
+// newFlagSet registers the common flags and the command-specific extra
+// flags of the named command into opts
+func newFlagSet(name string, opts *cliOptions, extra ...func(*flag.FlagSet, *cliOptions)) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.BoolVar(&opts.version, "version", false, "Print version information")
+	fs.StringVar(&opts.dir, "C", "", "Run go list and go test in `dir` (the module root)")
+	fs.BoolVar(&opts.watch, "watch", false, "Enable file watch mode")
+	fs.BoolVar(&opts.cover, "cover", false, "Enable coverage reporting")
+	fs.BoolVar(&opts.race, "race", false, "Enable race detector")
+	fs.BoolVar(&opts.short, "short", false, "Run short tests only")
+	fs.DurationVar(&opts.timeout, "timeout", defaultTimeout, "Test timeout")
+	fs.StringVar(&opts.tags, "tags", "", "Build tags (comma separated)")
+	fs.StringVar(&opts.editor, "editor", "", "Editor command (default $EDITOR or nvim)")
+	fs.IntVar(&opts.parallel, "p", 0, "Package-level parallelism (default GOMAXPROCS)")
+	fs.IntVar(&opts.testParallel, "parallel", 0, "Maximum parallel tests per package (default GOMAXPROCS)")
+	fs.StringVar(&opts.bench, "bench", "", "Run the benchmarks matching `regexp`")
+	fs.StringVar(&opts.benchtime, "benchtime", "", "Run each benchmark for a duration or `N`x iterations")
+	fs.BoolVar(&opts.benchmem, "benchmem", false, "Report benchmark memory allocations")
+	fs.IntVar(&opts.count, "count", 0, "Run each test and benchmark `n` times")
+	fs.StringVar(&opts.fuzz, "fuzz", "", "Fuzz the fuzz target pkg.`FuzzName` instead of running the tests")
+	fs.StringVar(&opts.fuzztime, "fuzztime", "", "Fuzz for a duration or `N`x iterations (default: until interrupted)")
+	fs.StringVar(&opts.benchSave, "bench-save", "", "Write the benchmark results of the run to `file`")
+	fs.StringVar(&opts.benchBase, "bench-base", "", "Compare benchmark results with the baseline in `file`")
+	fs.StringVar(&opts.diffBase, "diff-base", "", "Report the coverage of the lines changed since git `ref` (implies -cover)")
+	fs.DurationVar(&opts.stall, "stall-threshold", defaultStallThreshold, "Flag running tests that print nothing for this long (0 disables)")
+	fs.BoolVar(&opts.debug, "debug", false, "Enable debug logging to debug.log")
+	fs.Var(&opts.replay, "replay", "Replay a recorded go test -json file instead of running go (repeatable)")
+	fs.Float64Var(&opts.replaySpeed, "replay-speed", 0, "Replay pacing: 0 instant, 1 real time, 2 twice as fast")
+	fs.Var(&opts.focus, "focus", "Run only the test pkg.TestName (repeatable)")
+	fs.StringVar(&opts.profile, "profile", "", "Use the named run profile from the configuration")
+	fs.Var(&opts.envFlags, "env", "Set NAME=VALUE in the test environment (repeatable)")
+	for _, register := range extra {
+		register(fs, opts)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] [packages]\n\nFlags:\n", name)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// applyConfig resolves every option not given on the command line from the
+// configuration files and the selected profile. It only depends on the
+// explicitly set flags, so a copy can be resolved again for another profile.
+func (o *cliOptions) applyConfig(cfg *config.Config) error {
+	if o.profile != "" {
+		applied, err := cfg.ApplyProfile(o.profile)
+		if err != nil {
+			return err
+		}
+		cfg = applied
+	}
+
+	if !o.set["tags"] {
+		o.tags = cfg.Run.Tags
+	}
+	if !o.set["timeout"] {
+		o.timeout = defaultTimeout
+		if cfg.Run.Timeout != "" {
+			o.timeout = cfg.TimeoutDuration()
+		}
+	}
+	if !o.set["race"] {
+		o.race = cfg.Run.Race != nil && *cfg.Run.Race
+	}
+	if !o.set["cover"] {
+		o.cover = cfg.Run.Cover != nil && *cfg.Run.Cover
+	}
+	if !o.set["short"] {
+		o.short = cfg.Run.Short != nil && *cfg.Run.Short
+	}
+	if !o.set["parallel"] {
+		o.testParallel = intValue(cfg.Run.Parallel)
+	}
+	if !o.set["p"] {
+		o.parallel = intValue(cfg.Run.PackageParallel)
+	}
+	if !o.set["bench"] {
+		o.bench = cfg.Run.Bench
+	}
+	if !o.set["benchtime"] {
+		o.benchtime = cfg.Run.Benchtime
+	}
+	if !o.set["benchmem"] {
+		o.benchmem = cfg.Run.Benchmem != nil && *cfg.Run.Benchmem
+	}
+	if !o.set["count"] {
+		o.count = intValue(cfg.Run.Count)
+	}
+	if !o.set["fuzztime"] {
+		o.fuzztime = cfg.Run.Fuzztime
+	}
+	if !o.set["editor"] {
+		o.editor = cfg.Editor
+	}
+	if !o.set["diff-base"] {
+		o.diffBase = cfg.DiffBase
+	}
+	if !o.set["stall-threshold"] {
+		o.stall = defaultStallThreshold
+		if cfg.StallThreshold != "" {
+			o.stall = cfg.StallThresholdDuration()
+		}
+	}
+
+	// -env entries override the configured environment by name
+	env := make(map[string]*string, len(cfg.Run.Env)+len(o.envFlags))
+	for name, value := range cfg.Run.Env {
+		env[name] = value
+	}
+	for _, pair := range o.envFlags {
+		name, value, _ := strings.Cut(pair, "=")
+		env[name] = &value
+	}
+	o.env, o.unsetEnv = config.EnvLists(env)
+
+	o.patterns = o.args
+	if len(o.patterns) == 0 {
+		o.patterns = cfg.Packages.Include
+	}
+
+	return nil
+}
+
+// intValue returns a configured number, 0 (go test's default) when unset
+func intValue(n *int) int {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
+// withProfile returns a copy of the options resolved for the named profile;
+// "" resolves the configuration without a profile
+func (o *cliOptions) withProfile(cfg *config.Config, name string) (*cliOptions, error) {
+	resolved := *o
+	resolved.profile = name
+	if err := resolved.applyConfig(cfg); err != nil {
+		return nil, err
+	}
+	if err := resolved.validate(); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// validate checks flag values and normalizes them in place
+func (o *cliOptions) validate() error {
+	if o.timeout < 0 {
+		return errors.Invalid("timeout", "must not be negative")
+	}
+
+	if o.parallel < 0 {
+		return errors.Invalid("p", "must not be negative")
+	}
+
+	if o.testParallel < 0 {
+		return errors.Invalid("parallel", "must not be negative")
+	}
+
+	if o.bench != "" {
+		if _, err := regexp.Compile(o.bench); err != nil {
+			return errors.Invalid("bench", "invalid regexp: "+err.Error())
+		}
+	}
+
+	if o.benchtime != "" && !config.ValidBenchtime(o.benchtime) {
+		return errors.Invalid("benchtime", "expected a duration such as 2s or a count such as 100x")
+	}
+
+	if o.count < 0 {
+		return errors.Invalid("count", "must not be negative")
+	}
+
+	if o.fuzztime != "" && !config.ValidBenchtime(o.fuzztime) {
+		return errors.Invalid("fuzztime", "expected a duration such as 30s or a count such as 1000x")
+	}
+
+	if o.stall < 0 {
+		return errors.Invalid("stall-threshold", "must not be negative")
+	}
+
+	if strings.HasPrefix(o.diffBase, "-") {
+		return errors.Invalid("diff-base", "expected a git ref, got "+o.diffBase)
+	}
+
+	if o.replaySpeed < 0 {
+		return errors.Invalid("replay-speed", "must not be negative")
+	}
+
+	if o.editor == "" {
+		o.editor = defaultEditorCommand()
+	}
+
+	if o.dir != "" {
+		dir, err := filepath.Abs(o.dir)
+		if err != nil || !pkgrepo.IsDir(dir) {
+			return errors.Invalid("C", o.dir+" is not a directory")
+		}
+		o.dir = dir
+	}
+
+	if o.tags != "" {
+		tags := strings.Split(o.tags, ",")
+		for i, tag := range tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || strings.ContainsAny(tag, " \t") {
+				return errors.Invalid("tags", "expected a comma separated list of build tags")
+			}
+			tags[i] = tag
+		}
+		o.tags = strings.Join(tags, ",")
+	}
+
+	for _, pair := range o.envFlags {
+		name, _, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return errors.Invalid("env", "expected NAME=VALUE, got "+pair)
+		}
+	}
+
+	o.focusIDs = nil
+	for _, focus := range o.focus {
+		id, ok := domain.ParseTestID(focus)
+		if !ok {
+			return errors.Invalid("focus", "expected pkg.TestName, got "+focus)
+		}
+		o.focusIDs = append(o.focusIDs, id)
+	}
+
+	o.fuzzID = domain.TestID{}
+	if o.fuzz != "" {
+		id, ok := domain.ParseTestID(o.fuzz)
+		if !ok || domain.KindOf(id.Name) != domain.KindFuzz || strings.Contains(id.Name, "/") {
+			return errors.Invalid("fuzz", "expected pkg.FuzzName, got "+o.fuzz)
+		}
+		if len(o.focus) > 0 {
+			return errors.Invalid("fuzz", "cannot be used with -focus")
+		}
+		if len(o.replay) > 0 {
+			return errors.Invalid("fuzz", "cannot be used with -replay")
+		}
+		o.fuzzID = id
+	}
+
+	if o.readStdin() {
+		if len(o.focus) > 0 {
+			return errors.Invalid("focus", "cannot be used when reading test output from stdin")
+		}
+		if o.fuzz != "" {
+			return errors.Invalid("fuzz", "cannot be used when reading test output from stdin")
+		}
+		if len(o.patterns) > 1 || len(o.replay) > 0 {
+			return errors.Invalid("packages", "- reads test2json from stdin and must be the only input")
+		}
+		return nil
+	}
+
+	for _, pattern := range o.patterns {
+		if strings.HasPrefix(pattern, "-") {
+			return errors.Invalid("packages", "flag "+pattern+" must come before package patterns")
+		}
+	}
+
+	return nil
+}
+
+// configDir returns the directory the project configuration is looked up from
+func (o *cliOptions) configDir() string {
+	if o.dir != "" {
+		return o.dir
+	}
+	return "."
+}
+
+// readStdin reports whether test2json input is read from stdin ("-")
+func (o *cliOptions) readStdin() bool {
+	for _, pattern := range o.patterns {
+		if pattern == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// runOptions converts the flags into the defaults used for every run
+func (o *cliOptions) runOptions() runner.RunOptions {
+	patterns := o.patterns
+	if o.readStdin() {
+		patterns = nil
+	}
+
+	opts := runner.RunOptions{
+		Packages:        patterns,
+		Tags:            o.tags,
+		Race:            o.race,
+		Cover:           o.cover || o.diffBase != "",
+		Short:           o.short,
+		Verbose:         true,
+		Parallel:        o.testParallel,
+		PackageParallel: o.parallel,
+		Bench:           o.bench,
+		Benchtime:       o.benchtime,
+		Benchmem:        o.benchmem,
+		Count:           o.count,
+		Fuzztime:        o.fuzztime,
+		Env:             o.env,
+		Unsetenv:        o.unsetEnv,
+		Dir:             o.dir,
+	}
+
+	if o.timeout > 0 {
+		opts.Timeout = o.timeout.String()
+	}
+
+	return opts
+}
+
+// defaultEditorCommand returns $EDITOR, falling back to nvim
+func defaultEditorCommand() string {
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	return defaultEditor
+}
+
+// stringList is a flag.Value collecting repeated string flags
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// listFlags registers the flags of `lazygotest list`
+func listFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.BoolVar(&opts.jsonOutput, "json", false, "Print the inventory as JSON")
+}
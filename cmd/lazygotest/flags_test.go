@@ -0,0 +1,324 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/config"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+const userConfig = `editor: vim
+stall_threshold: 1m
+run:
+  tags: unit
+  timeout: 5m
+  race: true
+  cover: true
+  count: 2
+  env:
+    A: "1"
+    B: "2"
+packages:
+  include: [./...]
+profiles:
+  ci:
+    timeout: 20m
+    benchmem: true
+`
+
+const projectConfig = `run:
+  timeout: 10m
+  race: false
+  env:
+    B: null
+profiles:
+  ci:
+    timeout: 15m
+    count: 3
+    packages: [./cmd/...]
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// loadConfig loads the given user and project configuration files, each
+// skipped when empty
+func loadConfig(t *testing.T, user, project string) *config.Config {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	if user != "" {
+		writeFile(t, config.UserConfigPath(), user)
+	}
+	if project != "" {
+		writeFile(t, filepath.Join(dir, config.ProjectFileName), project)
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// resolve parses args and resolves them against cfg the way loadOptions does
+func resolve(t *testing.T, cfg *config.Config, args ...string) (*cliOptions, error) {
+	t.Helper()
+	opts, err := parseFlags("lazygotest", args, io.Discard, listFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.applyConfig(cfg); err != nil {
+		return nil, err
+	}
+	return opts, opts.validate()
+}
+
+// resolved are the options that depend on the configuration
+type resolved struct {
+	timeout  time.Duration
+	tags     string
+	race     bool
+	cover    bool
+	benchmem bool
+	count    int
+	stall    time.Duration
+	editor   string
+	env      []string
+	unsetEnv []string
+	patterns []string
+}
+
+func resolvedOf(o *cliOptions) resolved {
+	return resolved{
+		timeout:  o.timeout,
+		tags:     o.tags,
+		race:     o.race,
+		cover:    o.cover,
+		benchmem: o.benchmem,
+		count:    o.count,
+		stall:    o.stall,
+		editor:   o.editor,
+		env:      o.env,
+		unsetEnv: o.unsetEnv,
+		patterns: o.patterns,
+	}
+}
+
+// TestApplyConfig checks the precedence of the option sources: flags over
+// the profile over the project file over the user file
+func TestApplyConfig(t *testing.T) {
+	t.Setenv("EDITOR", "")
+	tests := []struct {
+		name          string
+		user, project string
+		args          []string
+		want          resolved
+	}{
+		{
+			name: "defaults",
+			want: resolved{timeout: defaultTimeout, stall: defaultStallThreshold, editor: defaultEditor},
+		},
+		{
+			name: "user file",
+			user: userConfig,
+			want: resolved{
+				timeout: 5 * time.Minute, tags: "unit", race: true, cover: true, count: 2,
+				stall: time.Minute, editor: "vim", env: []string{"A=1", "B=2"}, patterns: []string{"./..."},
+			},
+		},
+		{
+			name:    "project file",
+			user:    userConfig,
+			project: projectConfig,
+			want: resolved{
+				timeout: 10 * time.Minute, tags: "unit", race: false, cover: true, count: 2,
+				stall: time.Minute, editor: "vim", env: []string{"A=1"}, unsetEnv: []string{"B"},
+				patterns: []string{"./..."},
+			},
+		},
+		{
+			// The project's ci profile replaces the user's, whose benchmem is lost
+			name:    "profile",
+			user:    userConfig,
+			project: projectConfig,
+			args:    []string{"-profile", "ci"},
+			want: resolved{
+				timeout: 15 * time.Minute, tags: "unit", race: false, cover: true, count: 3,
+				stall: time.Minute, editor: "vim", env: []string{"A=1"}, unsetEnv: []string{"B"},
+				patterns: []string{"./cmd/..."},
+			},
+		},
+		{
+			// An explicit 0 leaves the count to go test
+			name:    "count reset",
+			user:    userConfig,
+			project: "run:\n  count: 0\n",
+			want: resolved{
+				timeout: 5 * time.Minute, tags: "unit", race: true, cover: true,
+				stall: time.Minute, editor: "vim", env: []string{"A=1", "B=2"}, patterns: []string{"./..."},
+			},
+		},
+		{
+			name: "user profile",
+			user: userConfig,
+			args: []string{"-profile", "ci"},
+			want: resolved{
+				timeout: 20 * time.Minute, tags: "unit", race: true, cover: true, benchmem: true, count: 2,
+				stall: time.Minute, editor: "vim", env: []string{"A=1", "B=2"}, patterns: []string{"./..."},
+			},
+		},
+		{
+			name:    "flags",
+			user:    userConfig,
+			project: projectConfig,
+			args: []string{
+				"-profile", "ci", "-timeout", "1m", "-count", "5", "-tags", "e2e", "-race", "-cover=false",
+				"-stall-threshold", "0", "-editor", "code", "-env", "B=3", "./pkg",
+			},
+			want: resolved{
+				timeout: time.Minute, tags: "e2e", race: true, cover: false, count: 5,
+				editor: "code", env: []string{"A=1", "B=3"}, patterns: []string{"./pkg"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadConfig(t, tt.user, tt.project)
+			opts, err := resolve(t, cfg, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resolvedOf(opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolved\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyConfigUnknownProfile(t *testing.T) {
+	cfg := loadConfig(t, userConfig, "")
+	if _, err := resolve(t, cfg, "-profile", "nightly"); !errors.IsValidation(err) {
+		t.Errorf("applyConfig = %v, want an invalid profile", err)
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	cfg := loadConfig(t, userConfig, projectConfig)
+	opts, err := resolve(t, cfg, "-profile", "ci", "-count", "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Switching back drops the profile but keeps the flags
+	plain, err := opts.withProfile(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.profile != "" || plain.timeout != 10*time.Minute || plain.count != 7 ||
+		!reflect.DeepEqual(plain.patterns, []string{"./..."}) {
+		t.Errorf("withProfile(\"\") = profile %q timeout %v count %d patterns %v, want no profile, 10m, 7, ./...",
+			plain.profile, plain.timeout, plain.count, plain.patterns)
+	}
+	if opts.profile != "ci" || opts.timeout != 15*time.Minute {
+		t.Errorf("withProfile changed the options to profile %q timeout %v", opts.profile, opts.timeout)
+	}
+
+	if _, err := opts.withProfile(cfg, "nightly"); !errors.IsValidation(err) {
+		t.Errorf("withProfile(nightly) = %v, want an invalid profile", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("EDITOR", "")
+	const fuzz = "example.com/fs/fz.FuzzFind"
+	tests := []struct {
+		args []string
+		// field is the invalid flag, empty for valid arguments
+		field string
+	}{
+		{args: nil},
+		{args: []string{"-timeout", "-1s"}, field: "timeout"},
+		{args: []string{"-p", "-1"}, field: "p"},
+		{args: []string{"-parallel", "-1"}, field: "parallel"},
+		{args: []string{"-bench", "Join("}, field: "bench"},
+		{args: []string{"-benchtime", "0x"}, field: "benchtime"},
+		{args: []string{"-count", "-1"}, field: "count"},
+		{args: []string{"-fuzztime", "forever"}, field: "fuzztime"},
+		{args: []string{"-stall-threshold", "-1s"}, field: "stall-threshold"},
+		{args: []string{"-diff-base", "-x"}, field: "diff-base"},
+		{args: []string{"-replay-speed", "-1"}, field: "replay-speed"},
+		{args: []string{"-C", filepath.Join(t.TempDir(), "missing")}, field: "C"},
+		{args: []string{"-tags", "a,,b"}, field: "tags"},
+		{args: []string{"-tags", "a b"}, field: "tags"},
+		{args: []string{"-env", "NOVALUE"}, field: "env"},
+		{args: []string{"-env", "=1"}, field: "env"},
+		{args: []string{"-focus", "TestJoin"}, field: "focus"},
+		{args: []string{"-fuzz", "example.com/fs/fz.TestFind"}, field: "fuzz"},
+		{args: []string{"-fuzz", fuzz + "/seed"}, field: "fuzz"},
+		{args: []string{"-fuzz", fuzz, "-focus", "example.com/fs/fz.TestFind"}, field: "fuzz"},
+		{args: []string{"-fuzz", fuzz, "-replay", "run.json"}, field: "fuzz"},
+		{args: []string{"-fuzz", fuzz, "-"}, field: "fuzz"},
+		{args: []string{"-focus", "example.com/fs/fz.TestFind", "-"}, field: "focus"},
+		{args: []string{"-", "./..."}, field: "packages"},
+		{args: []string{"-replay", "run.json", "-"}, field: "packages"},
+		{args: []string{"./...", "-race"}, field: "packages"},
+		{args: []string{"-"}},
+		{args: []string{"-fuzz", fuzz, "-fuzztime", "100x"}},
+		{args: []string{"-bench", ".", "-benchtime", "2s", "-count", "3", "-stall-threshold", "0"}},
+	}
+	for _, tt := range tests {
+		_, err := resolve(t, &config.Config{}, tt.args...)
+		var invalid *errors.ValidationError
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("validate(%q) = %v, want nil", tt.args, err)
+		case tt.field != "" && (!errors.As(err, &invalid) || invalid.Field != tt.field):
+			t.Errorf("validate(%q) = %v, want an invalid %s", tt.args, err, tt.field)
+		}
+	}
+}
+
+func TestValidateNormalizes(t *testing.T) {
+	t.Setenv("EDITOR", "")
+	dir := t.TempDir()
+	opts, err := resolve(t, &config.Config{},
+		"-C", dir, "-tags", " integration , e2e", "-fuzz", "example.com/fs/fz.FuzzFind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.dir != dir || opts.tags != "integration,e2e" || opts.editor != defaultEditor {
+		t.Errorf("validate = dir %q tags %q editor %q, want %q, integration,e2e, %s",
+			opts.dir, opts.tags, opts.editor, dir, defaultEditor)
+	}
+	if want := (domain.TestID{Pkg: "example.com/fs/fz", Name: "FuzzFind"}); opts.fuzzID != want {
+		t.Errorf("fuzzID = %v, want %v", opts.fuzzID, want)
+	}
+
+	opts, err = resolve(t, &config.Config{},
+		"-focus", "example.com/fs/fz.TestFind", "-focus", "example.com/fs.TestA/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.TestID{{Pkg: "example.com/fs/fz", Name: "TestFind"}, {Pkg: "example.com/fs", Name: "TestA/sub"}}
+	if !reflect.DeepEqual(opts.focusIDs, want) {
+		t.Errorf("focusIDs = %v, want %v", opts.focusIDs, want)
+	}
+	// Validating again does not repeat them
+	if err := opts.validate(); err != nil || !reflect.DeepEqual(opts.focusIDs, want) {
+		t.Errorf("validate again = %v with focusIDs %v, want %v", err, opts.focusIDs, want)
+	}
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/primary/headless"
 	"github.com/YuminosukeSato/lazygotest/internal/adapter/primary/tui"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/benchstore"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/config"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/pkgrepo"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/internal/usecase"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
 	"github.com/YuminosukeSato/lazygotest/pkg/logger"
 )
 
@@ -19,37 +29,314 @@ var (
 )
 
 func main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "run":
+			os.Exit(runHeadless(args[1:]))
+		case "list":
+			os.Exit(runList(args[1:]))
+		case "completion":
+			os.Exit(runCompletion(args[1:]))
+		case "__complete":
+			os.Exit(runComplete(args[1:]))
+		}
+	}
+
+	opts, cfg := loadOptions("lazygotest", args)
+
+	keys := tui.DefaultKeyMap()
+	if err := keys.Apply(keyBindings(cfg)); err != nil {
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		os.Exit(2)
+	}
+
+	initLogger(opts)
+	defer closeLogger()
+	setGOROOT(opts)
+
+	logger.Debug("Starting lazygotest application", "patterns", opts.patterns)
+
+	src := newSources(opts, cfg)
+	runOpts := opts.runOptions()
+
+	var profiles []tui.Profile
+	if !src.streamed {
+		var err error
+		if profiles, err = tuiProfiles(opts, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "lazygotest:", err)
+			os.Exit(2)
+		}
+	}
+
+	// Create and run the TUI application
+	app := tui.New(tui.Config{
+		Patterns:       runOpts.Packages,
+		RunOptions:     runOpts,
+		Watch:          opts.watch,
+		Editor:         opts.editor,
+		Runner:         src.runner,
+		PackageRepo:    src.pkgRepo,
+		Source:         src.label,
+		AutoRun:        src.replayed,
+		Focus:          opts.focusIDs,
+		Fuzz:           opts.fuzzID,
+		Profiles:       profiles,
+		Profile:        opts.profile,
+		Dir:            opts.dir,
+		Baseline:       loadBaseline(opts),
+		SaveBenchmarks: opts.benchSave,
+		DiffBase:       opts.diffBase,
+		StallThreshold: opts.stall,
+		SingleRun:      !src.canRerun,
+		Keys:           &keys,
+		Theme:          cfg.Theme,
+	})
+
+	programOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if src.streamed {
+		// stdin carries the test output, so read keys from the terminal
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(app, programOpts...)
+	app.SetProgram(p)
+
+	_, err := p.Run()
+	// Runs cancelled on quit still have their processes to stop
+	app.Wait()
+	if err != nil {
+		logger.Error("Error running program", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runHeadless implements `lazygotest run`: it runs the tests without the
+// TUI and returns the process exit code
+func runHeadless(args []string) int {
+	opts, cfg := loadOptions("lazygotest run", args)
+
+	initLogger(opts)
+	defer closeLogger()
+	setGOROOT(opts)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	src := newSources(opts, cfg)
+
+	app := headless.New(headless.Config{
+		RunOptions:     opts.runOptions(),
+		Runner:         src.runner,
+		PackageRepo:    src.pkgRepo,
+		Streamed:       src.streamed,
+		Focus:          opts.focusIDs,
+		Fuzz:           opts.fuzzID,
+		Baseline:       loadBaseline(opts),
+		SaveBenchmarks: opts.benchSave,
+		DiffBase:       opts.diffBase,
+		StallThreshold: opts.stall,
+		Output:         os.Stdout,
+	})
+
+	code, err := app.Run(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+	}
+	return code
+}
+
+// runList implements `lazygotest list`: it prints the packages and their
+// test functions and returns the process exit code
+func runList(args []string) int {
+	opts, cfg := loadOptions("lazygotest list", args, listFlags)
+
+	initLogger(opts)
+	defer closeLogger()
+
+	src := newSources(opts, cfg)
+
+	err := headless.List(context.Background(), headless.ListConfig{
+		RunOptions:  opts.runOptions(),
+		Runner:      src.runner,
+		PackageRepo: src.pkgRepo,
+		JSON:        opts.jsonOutput,
+		Output:      os.Stdout,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		return 1
+	}
+	return 0
+}
+
+// loadOptions parses the flags of the named command, merges them with the
+// configuration files and exits on invalid input
+func loadOptions(name string, args []string, extra ...func(*flag.FlagSet, *cliOptions)) (*cliOptions, *config.Config) {
 	// Parse command line flags
-	versionFlag := flag.Bool("version", false, "Print version information")
-	flag.Parse()
+	opts, err := parseFlags(name, args, os.Stderr, extra...)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		os.Exit(2)
+	}
 
 	// Handle --version flag
-	if *versionFlag {
+	if opts.version {
 		fmt.Printf("lazygotest version %s\n", version)
 		fmt.Printf("  commit: %s\n", commit)
 		fmt.Printf("  built:  %s\n", date)
 		os.Exit(0)
 	}
 
-	// Initialize debug logger
-	if err := logger.Init("debug.log"); err != nil {
-		logger.Error("Failed to initialize logger", "error", err)
-		os.Exit(1)
+	// Load configuration files; command line flags take precedence
+	cfg, err := config.Load(opts.configDir())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		os.Exit(2)
+	}
+	if err := opts.applyConfig(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		os.Exit(2)
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		os.Exit(2)
 	}
-	defer func() {
-		if err := logger.Close(); err != nil {
-			logger.Error("Failed to close logger", "error", err)
+
+	return opts, cfg
+}
+
+// sources are the test runner and package repository a command works with
+type sources struct {
+	runner  usecase.TestRunner
+	pkgRepo usecase.PackageRepository
+	// label names a non-toolchain source in the TUI header
+	label string
+	// replayed sources are run once at startup instead of waiting for the user
+	replayed bool
+	// streamed sources cannot be re-read; packages come from the stream itself
+	streamed bool
+	canRerun bool
+}
+
+// newSources builds the test runner and package repository: stdin with "-",
+// recorded test2json files with -replay, otherwise the go toolchain
+func newSources(opts *cliOptions, cfg *config.Config) sources {
+	if opts.readStdin() {
+		var rerun *runner.CommandRunner
+		if cfg.RerunCommand != "" {
+			rerun = runner.NewCommandRunner(cfg.RerunCommand)
 		}
-	}()
+		stream := runner.NewStreamRunner(os.Stdin, rerun)
+		return sources{
+			runner:   stream,
+			pkgRepo:  pkgrepo.NewStaticPackageRepo(nil),
+			label:    "stdin",
+			replayed: true,
+			streamed: true,
+			canRerun: stream.CanRerun(),
+		}
+	}
 
-	logger.Debug("Starting lazygotest application")
+	if len(opts.replay) > 0 {
+		replay, err := runner.NewReplayRunner(opts.replay, opts.replaySpeed)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "lazygotest:", err)
+			os.Exit(2)
+		}
+		return sources{
+			runner:   replay,
+			pkgRepo:  pkgrepo.NewStaticPackageRepo(replay.Packages()),
+			label:    "replay",
+			replayed: true,
+			canRerun: true,
+		}
+	}
 
-	// Create and run the TUI application
-	app := tui.New()
-	p := tea.NewProgram(app, tea.WithAltScreen())
+	pkgRepo := pkgrepo.NewGoPackageRepo()
+	pkgRepo.SetExcludes(cfg.Packages.Exclude)
+	pkgRepo.SetDir(opts.dir)
+	return sources{
+		runner:   runner.NewTestRunner(),
+		pkgRepo:  pkgRepo,
+		canRerun: true,
+	}
+}
 
-	if _, err := p.Run(); err != nil {
-		logger.Error("Error running program", "error", err)
+// loadBaseline reads the -bench-base file, exiting when it cannot be used
+func loadBaseline(opts *cliOptions) *domain.BenchmarkRun {
+	if opts.benchBase == "" {
+		return nil
+	}
+	run, err := benchstore.Load(opts.benchBase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lazygotest:", err)
+		os.Exit(2)
+	}
+	return &run
+}
+
+// tuiProfiles resolves every configured profile for the TUI picker, after
+// an unnamed entry for the configuration without a profile. Flags given on
+// the command line apply to all of them.
+func tuiProfiles(opts *cliOptions, cfg *config.Config) ([]tui.Profile, error) {
+	if len(cfg.Profiles) == 0 {
+		return nil, nil
+	}
+
+	names := append([]string{""}, cfg.ProfileNames()...)
+	profiles := make([]tui.Profile, 0, len(names))
+	for _, name := range names {
+		resolved, err := opts.withProfile(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		runOpts := resolved.runOptions()
+		profiles = append(profiles, tui.Profile{
+			Name:       name,
+			Patterns:   runOpts.Packages,
+			RunOptions: runOpts,
+		})
+	}
+	return profiles, nil
+}
+
+// initLogger initializes the logger; debug output is only kept with -debug
+func initLogger(opts *cliOptions) {
+	logPath := os.DevNull
+	if opts.debug {
+		logPath = "debug.log"
+	}
+	if err := logger.Init(logPath); err != nil {
+		logger.Error("Failed to initialize logger", "error", err)
 		os.Exit(1)
 	}
 }
+
+// setGOROOT tells the stack frames of the standard library apart by the
+// GOROOT of the go command the tests run with
+func setGOROOT(opts *cliOptions) {
+	root, err := runner.GOROOT(context.Background(), opts.dir)
+	if err != nil {
+		logger.Warn("Telling standard library frames apart by their paths", "error", err)
+		return
+	}
+	domain.SetGOROOT(root)
+}
+
+func closeLogger() {
+	if err := logger.Close(); err != nil {
+		logger.Error("Failed to close logger", "error", err)
+	}
+}
+
+// keyBindings converts the configured keybindings for the TUI key map
+func keyBindings(cfg *config.Config) map[string][]string {
+	bindings := make(map[string][]string, len(cfg.Keybindings))
+	for action, keys := range cfg.Keybindings {
+		bindings[action] = keys
+	}
+	return bindings
+}
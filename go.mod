@@ -7,6 +7,7 @@ require (
 	github.com/charmbracelet/bubbletea v0.24.2
 	github.com/charmbracelet/lipgloss v0.10.0
 	github.com/cockroachdb/errors v1.12.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
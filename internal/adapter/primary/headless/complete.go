@@ -0,0 +1,142 @@
+package headless
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/internal/usecase"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// CompleteConfig holds the dependencies for shell completion candidates
+type CompleteConfig struct {
+	// RunOptions supplies the build tags used to list tests
+	RunOptions runner.RunOptions
+	// Runner lists the test functions
+	Runner usecase.TestRunner
+	// PackageRepo lists the packages of the module
+	PackageRepo usecase.PackageRepository
+}
+
+// CompletePackages returns package patterns starting with prefix: import
+// paths, or ./relative directories when the prefix is relative, each with
+// its "/..." wildcard forms
+func CompletePackages(ctx context.Context, cfg CompleteConfig, prefix string) ([]string, error) {
+	packages, err := cfg.PackageRepo.ListPackages(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	relative := strings.HasPrefix(prefix, ".")
+	seen := make(map[string]bool)
+	if relative {
+		addCandidate(seen, prefix, "./...")
+	}
+	for _, pkg := range packages {
+		name := string(pkg.ID)
+		if relative {
+			name = relativePackage(cfg.RunOptions.Dir, pkg)
+			if name == "" || name == "." {
+				continue
+			}
+		}
+
+		addCandidate(seen, prefix, name)
+		// Every ancestor can be used as a wildcard pattern
+		for dir := name; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+			addCandidate(seen, prefix, dir+"/...")
+		}
+	}
+
+	return sortedKeys(seen), nil
+}
+
+// CompleteTests returns "pkg.TestName" candidates starting with prefix.
+// Until the prefix names a package, only "pkg." stems are offered so that
+// tests are listed for a single package instead of compiling the module.
+func CompleteTests(ctx context.Context, cfg CompleteConfig, prefix string) ([]string, error) {
+	packages, err := cfg.PackageRepo.ListPackages(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	relative := strings.HasPrefix(prefix, ".")
+	seen := make(map[string]bool)
+	for _, pkg := range packages {
+		name := string(pkg.ID)
+		if relative {
+			name = relativePackage(cfg.RunOptions.Dir, pkg)
+		}
+		if name == "" {
+			continue
+		}
+
+		stem := name + "."
+		if !strings.HasPrefix(prefix, stem) {
+			addCandidate(seen, prefix, stem)
+			continue
+		}
+
+		opts := cfg.RunOptions
+		opts.Packages = []string{string(pkg.ID)}
+		// A package that does not build has no candidates
+		testIDs, err := cfg.Runner.ListTests(ctx, opts)
+		var listErr *runner.ListError
+		if err != nil && !errors.As(err, &listErr) {
+			return nil, err
+		}
+		for _, id := range testIDs {
+			addCandidate(seen, prefix, domain.TestID{Pkg: name, Name: id.Name}.String())
+		}
+	}
+
+	return sortedKeys(seen), nil
+}
+
+// relativePackage returns the ./relative directory of a package from base
+// (the working directory when empty), or "" when it lies outside base
+func relativePackage(base string, pkg *domain.Package) string {
+	if pkg.Path == "" {
+		return ""
+	}
+	if base == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return ""
+		}
+		base = wd
+	}
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(base, pkg.Path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if rel == "." {
+		return "."
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+func addCandidate(seen map[string]bool, prefix, candidate string) {
+	if strings.HasPrefix(candidate, prefix) {
+		seen[candidate] = true
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,659 @@
+package headless
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/benchstore"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/gitdiff"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/internal/shared/eventbus"
+	"github.com/YuminosukeSato/lazygotest/internal/usecase"
+	"github.com/YuminosukeSato/lazygotest/pkg/logger"
+)
+
+// Exit codes returned by App.Run
+const (
+	ExitOK     = 0
+	ExitFailed = 1
+)
+
+// Config holds the settings for a headless run
+type Config struct {
+	// RunOptions are the options for the run; Packages are the patterns to test
+	RunOptions runner.RunOptions
+	// Runner executes the tests
+	Runner usecase.TestRunner
+	// PackageRepo resolves the package patterns to the packages to test
+	PackageRepo usecase.PackageRepository
+	// Streamed runs the runner's input as-is instead of resolving packages
+	Streamed bool
+	// Focus limits the run to these tests instead of the package patterns
+	Focus []domain.TestID
+	// Fuzz is a fuzz target to fuzz instead of running the tests
+	Fuzz domain.TestID
+	// Baseline is compared with the benchmark results of the run when set
+	Baseline *domain.BenchmarkRun
+	// SaveBenchmarks is a file the benchmark results are written to
+	SaveBenchmarks string
+	// DiffBase is a git ref; the coverage of the lines changed since it is
+	// reported after a run with -cover
+	DiffBase string
+	// StallThreshold is how long a running test may print nothing before
+	// it is reported stalled; zero disables the watch
+	StallThreshold time.Duration
+	// Output receives progress and the final summary
+	Output io.Writer
+}
+
+// App runs tests without the TUI and reports progress as plain text
+type App struct {
+	out        io.Writer
+	opts       runner.RunOptions
+	streamed   bool
+	focus      []domain.TestID
+	fuzz       domain.TestID
+	baseline   *domain.BenchmarkRun
+	saveBench  string
+	diffBase   string
+	diff       *domain.DiffCoverage
+	eventBus   *eventbus.EventBus
+	listPkgsUC *usecase.ListPackagesUseCase
+	runTestsUC *usecase.RunTestsUseCase
+
+	mu       sync.Mutex
+	results  map[domain.TestID]*domain.TestCase
+	order    []domain.TestID
+	pkgLogs  map[string][]string
+	modules  map[string]string
+	buildOut []string
+	summary  *domain.TestSummary
+	runErrs  []error
+	finished chan struct{}
+}
+
+// New creates a headless application
+func New(cfg Config) *App {
+	bus := eventbus.New(1000)
+
+	app := &App{
+		out:        cfg.Output,
+		opts:       cfg.RunOptions,
+		streamed:   cfg.Streamed,
+		focus:      cfg.Focus,
+		fuzz:       cfg.Fuzz,
+		baseline:   cfg.Baseline,
+		saveBench:  cfg.SaveBenchmarks,
+		diffBase:   cfg.DiffBase,
+		eventBus:   bus,
+		listPkgsUC: usecase.NewListPackagesUseCase(cfg.PackageRepo, bus),
+		runTestsUC: usecase.NewRunTestsUseCase(cfg.Runner, bus),
+		results:    make(map[domain.TestID]*domain.TestCase),
+		pkgLogs:    make(map[string][]string),
+		modules:    make(map[string]string),
+		finished:   make(chan struct{}),
+	}
+	app.runTestsUC.SetDefaults(cfg.RunOptions)
+	app.runTestsUC.SetStallThreshold(cfg.StallThreshold)
+	app.subscribeToEvents()
+
+	return app
+}
+
+// Run executes the tests, prints progress and a summary, and returns the
+// process exit code
+func (a *App) Run(ctx context.Context) (int, error) {
+	if a.fuzz.Name != "" {
+		if err := a.runTestsUC.ExecuteFuzz(ctx, a.fuzz); err != nil {
+			return ExitFailed, err
+		}
+		return a.wait(ctx)
+	}
+
+	if len(a.focus) > 0 {
+		if err := a.runTestsUC.ExecuteMultipleTests(ctx, a.focus); err != nil {
+			return ExitFailed, err
+		}
+		return a.wait(ctx)
+	}
+
+	if !a.streamed {
+		if err := a.resolvePackages(ctx); err != nil {
+			return ExitFailed, err
+		}
+		if len(a.runTestsUC.Defaults().Packages) == 0 {
+			fmt.Fprintln(a.out, "no packages to test")
+			return ExitOK, nil
+		}
+	}
+
+	if err := a.runTestsUC.ExecuteAll(ctx); err != nil {
+		return ExitFailed, err
+	}
+	return a.wait(ctx)
+}
+
+// wait blocks until the run completes, prints the report and returns the
+// process exit code
+func (a *App) wait(ctx context.Context) (int, error) {
+	select {
+	case <-a.finished:
+	case <-ctx.Done():
+		// Cancelling ctx cancels the run; report it once its processes exit
+		a.mu.Lock()
+		fmt.Fprintf(a.out, "cancelling: signalled the test processes, killing them after %s\n", runner.QuitGrace)
+		a.mu.Unlock()
+		<-a.finished
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.printFailures()
+	a.printCancelled()
+	a.printRaces()
+	a.printCrashers()
+	a.printBenchmarks()
+	a.printComparison()
+	a.printDiffCoverage(ctx)
+	a.printSummary()
+
+	if a.saveBench != "" && len(a.summary.Benchmarks) > 0 {
+		if err := benchstore.Save(a.saveBench, a.benchmarkRun()); err != nil {
+			return ExitFailed, err
+		}
+	}
+
+	if a.summary.HasFailures() || a.summary.Cancelled || len(a.runErrs) > 0 {
+		return ExitFailed, nil
+	}
+	return ExitOK, nil
+}
+
+// resolvePackages resolves the patterns through the package repository so
+// the selection (including configured excludes) matches what the TUI shows
+func (a *App) resolvePackages(ctx context.Context) error {
+	packages, err := a.listPkgsUC.Execute(ctx, a.opts.Packages)
+	if err != nil {
+		return err
+	}
+
+	opts := a.opts
+	opts.Packages = make([]string, len(packages))
+	for i, pkg := range packages {
+		opts.Packages[i] = string(pkg.ID)
+		a.modules[string(pkg.ID)] = pkg.Module
+	}
+	a.runTestsUC.SetDefaults(opts)
+	return nil
+}
+
+// subscribeToEvents sets up event handlers
+func (a *App) subscribeToEvents() {
+	a.eventBus.Subscribe(eventbus.TopicTestEvent, func(ctx context.Context, event interface{}) {
+		if e, ok := event.(domain.TestEvent); ok {
+			a.handleTestEvent(e)
+		}
+	})
+
+	a.eventBus.Subscribe(eventbus.TopicTestCompleted, func(ctx context.Context, event interface{}) {
+		if summary, ok := event.(*domain.TestSummary); ok {
+			a.mu.Lock()
+			a.summary = summary
+			for _, id := range summary.Unfinished {
+				if test, ok := a.results[id]; ok {
+					test.Cancel()
+				}
+			}
+			a.mu.Unlock()
+			close(a.finished)
+		}
+	})
+
+	a.eventBus.Subscribe(eventbus.TopicTestStalled, func(ctx context.Context, event interface{}) {
+		if stall, ok := event.(domain.TestStall); ok {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			fmt.Fprintf(a.out, "STALL  %s  %s: no output for %s\n", stall.ID.Pkg, stall.ID.Name, stall.Idle(time.Now()).Round(time.Second))
+		}
+	})
+
+	a.eventBus.Subscribe(eventbus.TopicFuzzProgress, func(ctx context.Context, event interface{}) {
+		if progress, ok := event.(domain.FuzzProgress); ok {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			fmt.Fprintf(a.out, "fuzz  %s  %s\n", progress.ID.Name, progress)
+		}
+	})
+
+	a.eventBus.Subscribe(eventbus.TopicError, func(ctx context.Context, event interface{}) {
+		if err, ok := event.(error); ok {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.runErrs = append(a.runErrs, err)
+			fmt.Fprintln(a.out, "error:", err)
+		}
+	})
+}
+
+// handleTestEvent records the event and prints package progress
+func (a *App) handleTestEvent(event domain.TestEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if event.Test == "" {
+		switch event.Action {
+		case "pass", "fail", "skip":
+			a.printPackageResult(event)
+		case "build-output":
+			a.buildOut = append(a.buildOut, event.Output)
+		default:
+			if event.Output != "" {
+				a.pkgLogs[event.Package] = append(a.pkgLogs[event.Package], event.Output)
+			}
+		}
+		return
+	}
+
+	testID := domain.TestID{Pkg: event.Package, Name: event.Test}
+	test, exists := a.results[testID]
+	if !exists {
+		test = &domain.TestCase{ID: testID, Package: event.Package, Name: event.Test}
+		a.results[testID] = test
+		a.order = append(a.order, testID)
+	}
+	test.Apply(event)
+}
+
+// printPackageResult prints a go test style line when a package finishes
+func (a *App) printPackageResult(event domain.TestEvent) {
+	status := "ok  "
+	switch event.Action {
+	case "fail":
+		status = "FAIL"
+	case "skip":
+		status = "?   "
+	}
+
+	pkg := event.Package
+	if pkg == "" {
+		pkg = "(unknown package)"
+	}
+	fmt.Fprintf(a.out, "%s  %s  %s\n", status, pkg, formatDuration(event.Elapsed))
+}
+
+// printFailures prints the logs of every failed test, and the output of
+// packages that failed without a failing test (build errors, panics in init)
+func (a *App) printFailures() {
+	var failed []*domain.TestCase
+	failedPkgs := make(map[string]bool)
+	for _, id := range a.order {
+		test := a.results[id]
+		if test.Status.Failed() {
+			failed = append(failed, test)
+			failedPkgs[id.Pkg] = true
+		}
+	}
+
+	// Packages of a cancelled run fail with their unfinished tests, which
+	// printCancelled reports
+	for _, id := range a.summary.Unfinished {
+		failedPkgs[id.Pkg] = true
+	}
+
+	var brokenPkgs []string
+	for pkg, logs := range a.pkgLogs {
+		if pkg != "" && !failedPkgs[pkg] && hasFailOutput(logs) {
+			brokenPkgs = append(brokenPkgs, pkg)
+		}
+	}
+	sort.Strings(brokenPkgs)
+
+	if len(failed) == 0 && len(brokenPkgs) == 0 {
+		return
+	}
+
+	a.printBuildErrors()
+
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Failed")
+	for _, test := range failed {
+		fmt.Fprintf(a.out, "--- FAIL: %s %s (%s)\n", test.ID.Pkg, test.ID.Name, formatDuration(test.Duration.Seconds()))
+		a.printPanicOrigin(test)
+		if test.LastFail != nil {
+			for _, line := range strings.Split(strings.TrimRight(test.LastFail.FullLog, "\n"), "\n") {
+				fmt.Fprintln(a.out, "    "+strings.TrimRight(line, "\n"))
+			}
+		}
+	}
+	for _, pkg := range brokenPkgs {
+		fmt.Fprintf(a.out, "--- FAIL: %s\n", pkg)
+		for _, line := range a.pkgLogs[pkg] {
+			fmt.Fprint(a.out, "    "+line)
+		}
+	}
+}
+
+// printPanicOrigin prints where a test that panicked panicked from, which
+// is otherwise buried in the stack trace of its log
+func (a *App) printPanicOrigin(test *domain.TestCase) {
+	if test.LastFail == nil || test.LastFail.Panic == nil {
+		return
+	}
+	panicked := test.LastFail.Panic
+	if i := panicked.Origin(a.modules[test.ID.Pkg]); i >= 0 {
+		frame := panicked.Goroutine.Frames[i]
+		fmt.Fprintf(a.out, "    %s\n        at %s (%s:%d)\n", panicked.Summary(), frame.Func, frame.File, frame.Line)
+	}
+}
+
+// printBuildErrors prints the compiler and vet errors of packages that
+// failed to build, by package, or the raw build output when it has no
+// errors with a location, such as a linker failure
+func (a *App) printBuildErrors() {
+	if len(a.summary.BuildErrors) == 0 {
+		if len(a.buildOut) > 0 {
+			fmt.Fprintln(a.out)
+			fmt.Fprintln(a.out, "=== Build output")
+			for _, line := range a.buildOut {
+				fmt.Fprint(a.out, line)
+			}
+		}
+		return
+	}
+
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Build errors")
+	pkg := ""
+	for i, buildErr := range a.summary.BuildErrors {
+		if i == 0 || buildErr.Pkg != pkg {
+			pkg = buildErr.Pkg
+			fmt.Fprintf(a.out, "# %s\n", pkg)
+		}
+		message := strings.ReplaceAll(buildErr.Message, "\n", "\n\t")
+		fmt.Fprintf(a.out, "%s: %s\n", buildErr.Location(), message)
+	}
+}
+
+// printCancelled prints the tests a cancelled run left unfinished with
+// their output, which ends with the goroutine dump of their test binary
+func (a *App) printCancelled() {
+	if !a.summary.Cancelled || len(a.summary.Unfinished) == 0 {
+		return
+	}
+
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Cancelled")
+	for _, id := range a.summary.Unfinished {
+		fmt.Fprintf(a.out, "--- CANCELLED: %s %s\n", id.Pkg, id.Name)
+		if test, ok := a.results[id]; ok {
+			for _, line := range test.Logs {
+				fmt.Fprint(a.out, "    "+line)
+			}
+		}
+	}
+}
+
+// printRaces prints the unique data races the run reported with the sites
+// of both accesses, where their goroutines were started and the tests that
+// reported them; the full stacks are in the logs of those tests
+func (a *App) printRaces() {
+	if len(a.summary.Races) == 0 {
+		return
+	}
+
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Data races")
+	for _, race := range a.summary.Races {
+		fmt.Fprintln(a.out, race.Summary())
+		for i, access := range []domain.RaceAccess{race.Access, race.Previous} {
+			prefix := ""
+			if i == 1 {
+				prefix = "previous "
+			}
+			by := "main goroutine"
+			if access.Goroutine != 0 {
+				by = "goroutine " + strconv.Itoa(access.Goroutine)
+			}
+			fmt.Fprintf(a.out, "    %s%s at %s by %s\n", prefix, access.Op, frameSite(access.Site()), by)
+			if len(access.Created) > 0 {
+				fmt.Fprintf(a.out, "        created at %s\n", frameSite(access.CreatedAt()))
+			}
+		}
+		if race.Location != "" {
+			fmt.Fprintln(a.out, "    location: "+race.Location)
+		}
+		tests := make([]string, len(race.Tests))
+		for i, id := range race.Tests {
+			tests[i] = id.Pkg + " " + id.Name
+			if id.Name == "" {
+				tests[i] = id.Pkg + " (outside tests)"
+			}
+		}
+		fmt.Fprintln(a.out, "    reported by: "+strings.Join(tests, ", "))
+	}
+}
+
+// frameSite formats a frame as "file:line (func)"
+func frameSite(frame domain.StackFrame, ok bool) string {
+	if !ok {
+		return "(unknown)"
+	}
+	return fmt.Sprintf("%s:%d (%s)", frame.File, frame.Line, frame.Func)
+}
+
+// printCrashers prints the failing inputs fuzzing wrote, with the command
+// that reruns each of them as a regular test
+func (a *App) printCrashers() {
+	if len(a.summary.Crashers) == 0 {
+		return
+	}
+
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Failing fuzz inputs")
+	for _, crasher := range a.summary.Crashers {
+		id := crasher.TestID()
+		fmt.Fprintf(a.out, "%s %s\n", crasher.ID.Pkg, crasher.ID.Name)
+		fmt.Fprintln(a.out, "    "+crasher.Path)
+		fmt.Fprintf(a.out, "    rerun: lazygotest run -focus %s.%s\n", id.Pkg, id.Name)
+	}
+}
+
+// printDiffCoverage prints the changed lines since the -diff-base ref that
+// the run did not cover, per file
+func (a *App) printDiffCoverage(ctx context.Context) {
+	if a.diffBase == "" || a.summary.Coverage == nil {
+		return
+	}
+	changed, err := gitdiff.ChangedLines(ctx, a.opts.Dir, a.diffBase)
+	if err != nil {
+		fmt.Fprintln(a.out)
+		fmt.Fprintln(a.out, "=== Changed lines: "+err.Error())
+		a.runErrs = append(a.runErrs, err)
+		return
+	}
+	a.diff = domain.NewDiffCoverage(a.diffBase, changed, a.summary.Coverage)
+
+	var rows [][]string
+	for _, file := range a.diff.Files {
+		if len(file.Uncovered) > 0 {
+			rows = append(rows, []string{file.Path, file.UncoveredRanges()})
+		}
+	}
+	if len(rows) == 0 && len(a.diff.Unmeasured) == 0 {
+		return
+	}
+
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Uncovered changed lines since "+a.diffBase)
+	for _, line := range alignRows(rows, 1) {
+		fmt.Fprintln(a.out, "    "+line)
+	}
+	for _, name := range a.diff.Unmeasured {
+		fmt.Fprintln(a.out, "    "+name+"  (package not covered by the run)")
+	}
+}
+
+// printBenchmarks prints the benchmark results grouped by package, one row
+// per result with a column for each metric unit
+func (a *App) printBenchmarks() {
+	results := a.summary.Benchmarks
+	if len(results) == 0 {
+		return
+	}
+	units := domain.BenchmarkUnits(results)
+
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		row := []string{result.ID.Name, strconv.FormatInt(result.Iterations, 10)}
+		for _, unit := range units {
+			cell := ""
+			if value, ok := result.Metric(unit); ok {
+				cell = formatMetric(domain.BenchmarkMetric{Value: value, Unit: unit})
+			}
+			row = append(row, cell)
+		}
+		rows[i] = row
+	}
+
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Benchmarks")
+	pkg := ""
+	for i, line := range alignRows(rows, 1) {
+		if results[i].ID.Pkg != pkg {
+			pkg = results[i].ID.Pkg
+			fmt.Fprintln(a.out, pkg)
+		}
+		fmt.Fprintln(a.out, "    "+line)
+	}
+}
+
+// printComparison prints the benchstat-style comparison of the run's
+// benchmark results with the baseline, one section per unit
+func (a *App) printComparison() {
+	if a.baseline == nil || len(a.summary.Benchmarks) == 0 {
+		return
+	}
+
+	deltas := domain.CompareBenchmarks(*a.baseline, a.benchmarkRun(), domain.DefaultAlpha)
+	fmt.Fprintln(a.out)
+	fmt.Fprintln(a.out, "=== Compared with "+a.baseline.Label)
+	if len(deltas) == 0 {
+		fmt.Fprintln(a.out, "no benchmarks in common with the baseline")
+		return
+	}
+
+	rows := [][]string{{"", a.baseline.Label, "this run", "delta", ""}}
+	for _, delta := range deltas {
+		rows = append(rows, []string{
+			delta.ID.Name,
+			delta.Baseline.String(),
+			delta.Candidate.String(),
+			delta.Change(),
+			fmt.Sprintf("(p=%.3f n=%d+%d)", delta.P, delta.Baseline.N, delta.Candidate.N),
+		})
+	}
+
+	lines := alignRows(rows, 1)
+	fmt.Fprintln(a.out, "    "+lines[0])
+	unit := ""
+	for i, delta := range deltas {
+		if delta.Unit != unit {
+			unit = delta.Unit
+			fmt.Fprintln(a.out, unit)
+		}
+		fmt.Fprintln(a.out, "    "+lines[i+1])
+	}
+}
+
+// benchmarkRun returns the benchmark results of the run
+func (a *App) benchmarkRun() domain.BenchmarkRun {
+	return domain.BenchmarkRun{
+		StartedAt: a.summary.StartedAt,
+		Results:   a.summary.Benchmarks,
+	}
+}
+
+// alignRows pads the cells of every row to their column width. The first
+// left columns are left aligned, the others right aligned.
+func alignRows(rows [][]string, left int) []string {
+	var widths []int
+	for _, row := range rows {
+		for j, cell := range row {
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			if j < left {
+				cells[j] = fmt.Sprintf("%-*s", widths[j], cell)
+			} else {
+				cells[j] = fmt.Sprintf("%*s", widths[j], cell)
+			}
+		}
+		lines[i] = strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+	return lines
+}
+
+// printSummary prints the final counts
+func (a *App) printSummary() {
+	s := a.summary
+	fmt.Fprintln(a.out)
+	if len(s.Env) > 0 {
+		fmt.Fprintf(a.out, "ENV  %s\n", strings.Join(s.Env, " "))
+	}
+	if s.Coverage != nil {
+		total := s.Coverage.Total()
+		fmt.Fprintf(a.out, "COVER %.1f%% of statements (%d/%d)\n", total.Percent(), total.Covered, total.Statements)
+	}
+	if a.diff != nil {
+		total := a.diff.Total()
+		if total.Statements == 0 {
+			fmt.Fprintf(a.out, "DIFF  no changed statements since %s\n", a.diffBase)
+		} else {
+			fmt.Fprintf(a.out, "DIFF  %.1f%% of changed lines since %s (%d/%d)\n", total.Percent(), a.diffBase, total.Covered, total.Statements)
+		}
+	}
+	if s.Cancelled {
+		fmt.Fprintf(a.out, "CANCELLED with %d tests unfinished\n", len(s.Unfinished))
+	}
+	fmt.Fprintf(a.out, "DONE %d tests, %d failed, %d skipped, %d packages (%d failed) in %s\n",
+		s.TotalTests, s.Failed, s.Skipped, s.TotalPackages, s.FailedPackages,
+		s.Duration.Round(10*time.Millisecond))
+	logger.Info("Headless run completed", "summary", s)
+}
+
+// hasFailOutput reports whether package output contains a failure marker
+func hasFailOutput(logs []string) bool {
+	for _, line := range logs {
+		if strings.HasPrefix(line, "FAIL") || strings.Contains(line, "[build failed]") {
+			return true
+		}
+	}
+	return false
+}
+
+// formatMetric formats a benchmark metric like go test does
+func formatMetric(metric domain.BenchmarkMetric) string {
+	return strconv.FormatFloat(metric.Value, 'f', -1, 64) + " " + metric.Unit
+}
+
+// formatDuration formats elapsed seconds like go test does
+func formatDuration(seconds float64) string {
+	return fmt.Sprintf("%.3fs", seconds)
+}
@@ -0,0 +1,234 @@
+package headless
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// scriptRunner replays its events as a run and fails it with err. When the
+// run is cancelled after the events, it prints quit before ending, as a test
+// binary sent SIGQUIT dumps its goroutines.
+type scriptRunner struct {
+	events []domain.TestEvent
+	err    error
+	quit   []domain.TestEvent
+	// blocked is closed once the events were sent and the run waits for
+	// the cancellation
+	blocked chan struct{}
+}
+
+func (r *scriptRunner) Run(ctx context.Context, opts runner.RunOptions) (<-chan domain.TestEvent, <-chan error) {
+	events := make(chan domain.TestEvent)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		for _, event := range r.events {
+			events <- event
+		}
+		if r.err != nil {
+			errs <- r.err
+		}
+		if r.quit != nil {
+			close(r.blocked)
+			<-ctx.Done()
+			for _, event := range r.quit {
+				events <- event
+			}
+		}
+	}()
+	return events, errs
+}
+
+func (r *scriptRunner) ListTests(ctx context.Context, opts runner.RunOptions) ([]domain.TestID, error) {
+	return nil, nil
+}
+
+// output returns an output event of a test, or of the package when test is
+// empty
+func output(pkg, test, line string) domain.TestEvent {
+	return domain.TestEvent{Action: "output", Package: pkg, Test: test, Output: line}
+}
+
+// action returns an event without output of a test, or of the package when
+// test is empty
+func action(name, pkg, test string) domain.TestEvent {
+	return domain.TestEvent{Action: name, Package: pkg, Test: test}
+}
+
+// runHeadless runs the script through an App and returns its exit code,
+// output and error
+func runHeadless(t *testing.T, ctx context.Context, r *scriptRunner) (int, string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	app := New(Config{
+		RunOptions: runner.RunOptions{Packages: []string{"./..."}},
+		Runner:     r,
+		Streamed:   true,
+		Output:     &out,
+	})
+	code, err := app.Run(ctx)
+	return code, out.String(), err
+}
+
+// summaryLine returns the DONE line of out without its duration
+func summaryLine(t *testing.T, out string) string {
+	t.Helper()
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "DONE ") {
+			done, _, _ := strings.Cut(line, " in ")
+			return done
+		}
+	}
+	t.Fatalf("no summary line in\n%s", out)
+	return ""
+}
+
+func TestRun(t *testing.T) {
+	passed := []domain.TestEvent{
+		action("start", "example.com/hs/ok", ""),
+		action("run", "example.com/hs/ok", "TestA"),
+		action("pass", "example.com/hs/ok", "TestA"),
+		action("run", "example.com/hs/ok", "TestB"),
+		action("skip", "example.com/hs/ok", "TestB"),
+		action("pass", "example.com/hs/ok", ""),
+	}
+	failed := append(passed[:len(passed):len(passed)],
+		action("start", "example.com/hs/bad", ""),
+		action("run", "example.com/hs/bad", "TestC"),
+		output("example.com/hs/bad", "TestC", "=== RUN   TestC\n"),
+		output("example.com/hs/bad", "TestC", "    bad_test.go:9: got 1, want 2\n"),
+		output("example.com/hs/bad", "TestC", "--- FAIL: TestC (0.00s)\n"),
+		action("fail", "example.com/hs/bad", "TestC"),
+		output("example.com/hs/bad", "", "FAIL\n"),
+		action("fail", "example.com/hs/bad", ""),
+		// Fails without a failing test
+		action("start", "example.com/hs/initp", ""),
+		output("example.com/hs/initp", "", "panic: no config\n"),
+		output("example.com/hs/initp", "", "FAIL\texample.com/hs/initp\t0.003s\n"),
+		action("fail", "example.com/hs/initp", ""),
+	)
+
+	tests := []struct {
+		name    string
+		runner  *scriptRunner
+		code    int
+		summary string
+		// lines are in the output, in order
+		lines []string
+	}{
+		{
+			name:    "pass",
+			runner:  &scriptRunner{events: passed},
+			code:    ExitOK,
+			summary: "DONE 2 tests, 0 failed, 1 skipped, 1 packages (0 failed)",
+			lines:   []string{"ok    example.com/hs/ok  0.000s"},
+		},
+		{
+			name:    "fail",
+			runner:  &scriptRunner{events: failed},
+			code:    ExitFailed,
+			summary: "DONE 3 tests, 1 failed, 1 skipped, 3 packages (2 failed)",
+			lines: []string{
+				"FAIL  example.com/hs/bad  0.000s",
+				"FAIL  example.com/hs/initp  0.000s",
+				"=== Failed",
+				"--- FAIL: example.com/hs/bad TestC (0.000s)",
+				"        bad_test.go:9: got 1, want 2",
+				"--- FAIL: example.com/hs/initp",
+				"    panic: no config",
+			},
+		},
+		{
+			name:    "run error",
+			runner:  &scriptRunner{events: passed, err: errors.New("go: cannot find main module")},
+			code:    ExitFailed,
+			summary: "DONE 2 tests, 0 failed, 1 skipped, 1 packages (0 failed)",
+			lines:   []string{"error: go: cannot find main module"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, out, err := runHeadless(t, context.Background(), tt.runner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.code {
+				t.Errorf("exit code = %d, want %d\n%s", code, tt.code, out)
+			}
+			if got := summaryLine(t, out); got != tt.summary {
+				t.Errorf("summary = %q, want %q", got, tt.summary)
+			}
+			rest := out
+			for _, line := range tt.lines {
+				i := strings.Index(rest, line+"\n")
+				if i < 0 {
+					t.Fatalf("output lacks %q after the lines before it:\n%s", line, out)
+				}
+				rest = rest[i+len(line):]
+			}
+		})
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	r := &scriptRunner{
+		events: []domain.TestEvent{
+			action("start", "example.com/hs/stuck", ""),
+			action("run", "example.com/hs/stuck", "TestDone"),
+			action("pass", "example.com/hs/stuck", "TestDone"),
+			action("run", "example.com/hs/stuck", "TestWait"),
+			output("example.com/hs/stuck", "TestWait", "=== RUN   TestWait\n"),
+		},
+		quit: []domain.TestEvent{
+			output("example.com/hs/stuck", "TestWait", "SIGQUIT: quit\n"),
+			output("example.com/hs/stuck", "TestWait", "goroutine 7 [chan receive]:\n"),
+			output("example.com/hs/stuck", "", "FAIL\texample.com/hs/stuck\t0.512s\n"),
+			action("fail", "example.com/hs/stuck", ""),
+		},
+		blocked: make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.blocked:
+			cancel()
+		case <-time.After(5 * time.Second):
+		}
+	}()
+
+	code, out, err := runHeadless(t, ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != ExitFailed {
+		t.Errorf("exit code = %d, want %d\n%s", code, ExitFailed, out)
+	}
+	if got, want := summaryLine(t, out), "DONE 1 tests, 0 failed, 0 skipped, 1 packages (1 failed)"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	for _, line := range []string{
+		"cancelling: signalled the test processes, killing them after " + runner.QuitGrace.String(),
+		"=== Cancelled",
+		"--- CANCELLED: example.com/hs/stuck TestWait",
+		"    goroutine 7 [chan receive]:",
+		"CANCELLED with 1 tests unfinished",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("output lacks %q:\n%s", line, out)
+		}
+	}
+	// The package fails with its unfinished test, which is not a failure
+	if strings.Contains(out, "=== Failed") {
+		t.Errorf("cancelled test reported failed:\n%s", out)
+	}
+}
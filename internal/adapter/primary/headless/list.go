@@ -0,0 +1,94 @@
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/internal/usecase"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// ListConfig holds the settings for printing the test inventory
+type ListConfig struct {
+	// RunOptions supplies the package patterns and build tags
+	RunOptions runner.RunOptions
+	// Runner lists the test functions
+	Runner usecase.TestRunner
+	// PackageRepo resolves the package patterns
+	PackageRepo usecase.PackageRepository
+	// JSON selects machine-readable output
+	JSON bool
+	// Output receives the inventory
+	Output io.Writer
+}
+
+// listedPackage is the JSON form of a package in the inventory
+type listedPackage struct {
+	ImportPath string       `json:"importPath"`
+	Name       string       `json:"name"`
+	Dir        string       `json:"dir,omitempty"`
+	Tests      []listedTest `json:"tests"`
+	// Error is why the tests could not be listed, such as a build error
+	Error string `json:"error,omitempty"`
+}
+
+// listedTest is the JSON form of a test function in the inventory
+type listedTest struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// List prints the packages matching the patterns with their test
+// functions. The packages whose tests could not be listed are printed
+// without tests, and reported by the returned *runner.ListError.
+func List(ctx context.Context, cfg ListConfig) error {
+	discoverUC := usecase.NewDiscoverTestsUseCase(cfg.PackageRepo, cfg.Runner)
+
+	packages, err := discoverUC.Execute(ctx, cfg.RunOptions)
+	listErr := &runner.ListError{}
+	if err != nil && !errors.As(err, &listErr) {
+		return err
+	}
+
+	if cfg.JSON {
+		if writeErr := writeListJSON(cfg.Output, packages, listErr); writeErr != nil {
+			return writeErr
+		}
+		return err
+	}
+
+	for _, pkg := range packages {
+		fmt.Fprintln(cfg.Output, pkg.ID)
+		for _, test := range pkg.Tests {
+			fmt.Fprintf(cfg.Output, "  %-9s %s\n", test.Kind, test.Name)
+		}
+	}
+	return err
+}
+
+// writeListJSON writes the inventory as an indented JSON array, with the
+// errors of the packages whose tests could not be listed
+func writeListJSON(w io.Writer, packages []*domain.Package, listErr *runner.ListError) error {
+	listed := make([]listedPackage, len(packages))
+	for i, pkg := range packages {
+		tests := make([]listedTest, len(pkg.Tests))
+		for j, test := range pkg.Tests {
+			tests[j] = listedTest{Name: test.Name, Kind: test.Kind.String()}
+		}
+		listed[i] = listedPackage{
+			ImportPath: string(pkg.ID),
+			Name:       pkg.Name,
+			Dir:        pkg.Path,
+			Tests:      tests,
+			Error:      listErr.Reason(string(pkg.ID)),
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(listed)
+}
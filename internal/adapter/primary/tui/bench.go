@@ -0,0 +1,292 @@
+package tui
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/benchstore"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// addBenchmarkResult records a result for the benchmarks view. The first
+// result of a run replaces those of the previous run.
+func (m *Model) addBenchmarkResult(result domain.BenchmarkResult) {
+	if m.benchStale {
+		m.benchmarks = nil
+		m.benchCursor = 0
+		m.benchStale = false
+	}
+	m.benchmarks = append(m.benchmarks, result)
+
+	// test2json never reports benchmarks as passed; a result means the
+	// benchmark and its top-level parent completed
+	topLevel, _, _ := strings.Cut(result.ID.Name, "/")
+	for _, name := range []string{result.ID.Name, topLevel} {
+		id := domain.TestID{Pkg: result.ID.Pkg, Name: name}
+		if test, ok := m.testResults[id]; ok && test.Status == domain.StatusRunning {
+			test.Status = domain.StatusPassed
+		}
+	}
+	m.updateTestList()
+}
+
+// recordBenchmarkRun keeps the benchmark results of a completed run for
+// comparisons and writes them to the -bench-save file
+func (m *Model) recordBenchmarkRun(summary *domain.TestSummary) {
+	if len(summary.Benchmarks) == 0 {
+		return
+	}
+
+	run := domain.BenchmarkRun{
+		Label:     "run " + summary.StartedAt.Format("15:04:05"),
+		StartedAt: summary.StartedAt,
+		Results:   summary.Benchmarks,
+	}
+	m.benchRuns = append(m.benchRuns, run)
+
+	if m.saveBench != "" {
+		if err := benchstore.Save(m.saveBench, run); err != nil {
+			m.appendDetail("Error: " + err.Error())
+		}
+	}
+}
+
+// comparison returns the baseline and the latest run to compare, if there
+// is a baseline and a later run
+func (m *Model) comparison() (baseline, candidate domain.BenchmarkRun, ok bool) {
+	latest := len(m.benchRuns) - 1
+	if m.benchBaseline < 0 || latest <= m.benchBaseline {
+		return baseline, candidate, false
+	}
+	return m.benchRuns[m.benchBaseline], m.benchRuns[latest], true
+}
+
+// benchmarkDeltas compares the latest run with the baseline
+func (m *Model) benchmarkDeltas() []domain.BenchmarkDelta {
+	baseline, candidate, ok := m.comparison()
+	if !ok {
+		return nil
+	}
+	return domain.CompareBenchmarks(baseline, candidate, domain.DefaultAlpha)
+}
+
+// handleBenchmarksKey handles keys while the benchmarks view is open
+func (m *Model) handleBenchmarksKey(msg tea.KeyMsg) tea.Cmd {
+	rows := len(m.benchmarks)
+	var deltas []domain.BenchmarkDelta
+	if m.comparing {
+		deltas = m.benchmarkDeltas()
+		rows = len(deltas)
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.benchCursor < rows-1 {
+			m.benchCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.benchCursor > 0 {
+			m.benchCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		if m.benchCursor >= rows {
+			return nil
+		}
+		if m.comparing {
+			return m.runTest(deltas[m.benchCursor].ID)
+		}
+		return m.runTest(m.benchmarks[m.benchCursor].ID)
+	case key.Matches(msg, m.keys.RunAll):
+		return m.runAllBenchmarks()
+	case msg.String() == "b":
+		// The latest run becomes the baseline the next runs are compared with
+		if len(m.benchRuns) > 0 {
+			m.benchBaseline = len(m.benchRuns) - 1
+		}
+	case msg.String() == "c":
+		m.comparing = !m.comparing
+		m.benchCursor = 0
+	case key.Matches(msg, m.keys.Benchmarks), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showBenchmarks = false
+	}
+	return nil
+}
+
+// runAllBenchmarks runs the benchmarks of every package without the tests
+func (m *Model) runAllBenchmarks() tea.Cmd {
+	if !m.startRun() {
+		return nil
+	}
+
+	m.detailsContent = []string{"Running benchmarks..."}
+
+	return func() tea.Msg {
+		err := m.runTestsUC.ExecuteBenchmarks(m.ctx)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return nil
+	}
+}
+
+// renderBenchmarks renders the benchmarks view: the results of the latest
+// run, or their comparison with the baseline
+func (m *Model) renderBenchmarks(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+
+	// styles are by row index after the header; left is the number of
+	// left aligned text columns
+	var title, empty string
+	var rows [][]string
+	var styles map[int]lipgloss.Style
+	left := 3
+	if m.comparing {
+		title, rows, styles = m.comparisonRows()
+		empty = "Mark a run as baseline with b, then run the benchmarks again to compare."
+		if m.benchBaseline >= 0 {
+			empty = "Run the benchmarks again to compare them with the baseline."
+		}
+		left = 2
+	} else {
+		title, rows = "Benchmarks", m.resultRows()
+		empty = "No benchmark results yet."
+	}
+
+	// Keep the cursor row visible below the title, header and hint lines
+	visible := max(height-7, 1)
+	offset := 0
+	if m.benchCursor >= visible {
+		offset = m.benchCursor - visible + 1
+	}
+
+	lines := []string{titleStyle.Render(title), ""}
+	for i, line := range alignTable(rows, left) {
+		if i == 0 {
+			lines = append(lines, muted.Render("  "+line))
+			continue
+		}
+		if i-1 < offset || i-1 >= offset+visible {
+			continue
+		}
+		cursor := "  "
+		if i-1 == m.benchCursor {
+			cursor = "▶ "
+		}
+		if style, ok := styles[i-1]; ok {
+			line = style.Render(line)
+		}
+		lines = append(lines, cursor+line)
+	}
+	if len(rows) == 1 {
+		lines = append(lines, muted.Render(empty))
+	}
+
+	hints := []string{hint(m.keys.Enter, "Rerun"), hint(m.keys.RunAll, "Run all benchmarks"), "b:Baseline"}
+	if m.comparing {
+		hints = append(hints, "c:Results")
+	} else {
+		hints = append(hints, "c:Compare")
+	}
+	lines = append(lines, "", muted.Render(strings.Join(append(hints, "esc:Close"), " | ")))
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// resultRows lays out the latest results with a column per metric unit
+func (m *Model) resultRows() [][]string {
+	units := domain.BenchmarkUnits(m.benchmarks)
+
+	rows := [][]string{append([]string{"Package", "Benchmark", "", "Iterations"}, units...)}
+	for _, result := range m.benchmarks {
+		procs := ""
+		if result.Procs > 0 {
+			procs = "-" + strconv.Itoa(result.Procs)
+		}
+		row := []string{path.Base(result.ID.Pkg), result.ID.Name, procs, strconv.FormatInt(result.Iterations, 10)}
+		for _, unit := range units {
+			value := ""
+			if v, ok := result.Metric(unit); ok {
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// comparisonRows lays out the comparison of the latest run with the
+// baseline. Significant changes are styled by whether they are improvements.
+func (m *Model) comparisonRows() (string, [][]string, map[int]lipgloss.Style) {
+	baseline, candidate, ok := m.comparison()
+	if !ok {
+		title := "Compare benchmarks"
+		if m.benchBaseline >= 0 {
+			title += ": baseline " + m.benchRuns[m.benchBaseline].Label
+		}
+		return title, [][]string{{"Benchmark", "Unit"}}, nil
+	}
+
+	rows := [][]string{{"Benchmark", "Unit", baseline.Label, candidate.Label, "Delta", "p"}}
+	styles := make(map[int]lipgloss.Style)
+	for i, delta := range domain.CompareBenchmarks(baseline, candidate, domain.DefaultAlpha) {
+		rows = append(rows, []string{
+			delta.ID.Name,
+			delta.Unit,
+			delta.Baseline.String(),
+			delta.Candidate.String(),
+			delta.Change(),
+			strconv.FormatFloat(delta.P, 'f', 3, 64) + " n=" + strconv.Itoa(delta.Baseline.N) + "+" + strconv.Itoa(delta.Candidate.N),
+		})
+		if delta.Significant {
+			// Throughput units (MB/s, ops/s) improve as they grow
+			higherIsBetter := strings.HasSuffix(delta.Unit, "/s")
+			if (delta.Percent > 0) == higherIsBetter {
+				styles[i] = statusPassStyle
+			} else {
+				styles[i] = statusFailStyle
+			}
+		}
+	}
+	return baseline.Label + " vs " + candidate.Label, rows, styles
+}
+
+// alignTable pads the cells of every row to their column width. The first
+// left columns are left aligned, the others right aligned.
+func alignTable(rows [][]string, left int) []string {
+	var widths []int
+	for _, row := range rows {
+		for j, cell := range row {
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], lipgloss.Width(cell))
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			pad := strings.Repeat(" ", widths[j]-lipgloss.Width(cell))
+			if j < left {
+				cells[j] = cell + pad
+			} else {
+				cells[j] = pad + cell
+			}
+		}
+		lines[i] = strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+	return lines
+}
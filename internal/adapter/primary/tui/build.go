@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// addBuildErrors keeps the build errors of a completed run for the build
+// errors view
+func (m *Model) addBuildErrors(summary *domain.TestSummary) {
+	m.buildErrors = summary.BuildErrors
+	m.buildDir = m.currentDir()
+	m.buildCursor = 0
+	if len(summary.BuildFailed) > 0 {
+		m.appendDetail("Failed to build: " + strings.Join(summary.BuildFailed, ", ") + "; " +
+			m.keys.BuildErrors.Help().Key + " lists the errors")
+	}
+}
+
+// buildFailedCount counts the packages whose latest run failed to build
+func (m *Model) buildFailedCount() int {
+	n := 0
+	for _, pkg := range m.packages {
+		if pkg.Status == domain.PackageStatusBuildFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// handleBuildErrorsKey handles keys while the build errors view is open
+func (m *Model) handleBuildErrorsKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.buildCursor < len(m.buildErrors)-1 {
+			m.buildCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.buildCursor > 0 {
+			m.buildCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		if m.buildCursor < len(m.buildErrors) {
+			buildErr := m.buildErrors[m.buildCursor]
+			file := buildErr.File
+			// Paths are relative to the directory the run went in
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(m.buildDir, file)
+			}
+			return m.openInEditor(file, buildErr.Line)
+		}
+	case key.Matches(msg, m.keys.BuildErrors), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showBuild = false
+	}
+	return nil
+}
+
+// renderBuildErrors renders the build errors view: the compiler and vet
+// errors of the latest run by package, with the full message of the one
+// under the cursor
+func (m *Model) renderBuildErrors(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	title := titleStyle.Render("Build errors")
+
+	if len(m.buildErrors) == 0 {
+		message := "The latest run built every package."
+		if m.buildFailedCount() > 0 {
+			message = "The packages that failed to build reported no errors with a location; their output is in the details pane."
+		}
+		return placeBox(width, height, []string{title, "", muted.Render(message), "", muted.Render("esc:Close")})
+	}
+
+	inner := max(width-4, 20)
+	count := strconv.Itoa(len(m.buildErrors)) + " errors"
+	if len(m.buildErrors) == 1 {
+		count = "1 error"
+	}
+	lines := []string{title + muted.Render(count), ""}
+
+	// Each error takes a row, and the first of a package a header above it
+	type row struct {
+		text  string
+		index int
+	}
+	var rows []row
+	for i, buildErr := range m.buildErrors {
+		if i == 0 || buildErr.Pkg != m.buildErrors[i-1].Pkg {
+			rows = append(rows, row{text: muted.Render("# " + buildErr.Pkg), index: -1})
+		}
+		message, _, _ := strings.Cut(buildErr.Message, "\n")
+		rows = append(rows, row{text: buildErr.Location() + "  " + message, index: i})
+	}
+
+	// Messages over several lines, such as the types a call has and wants,
+	// are shown in full below for the error under the cursor
+	var message []string
+	if full := m.buildErrors[m.buildCursor].Message; strings.Contains(full, "\n") {
+		message = append([]string{""}, strings.Split(full, "\n")...)
+	}
+	visible := max(height-2-5-len(message), 1)
+	cursorRow := 0
+	for i, r := range rows {
+		if r.index == m.buildCursor {
+			cursorRow = i
+		}
+	}
+	start := max(cursorRow-visible+1, 0)
+	for i, r := range rows {
+		if i < start || i >= start+visible {
+			continue
+		}
+		if r.index < 0 {
+			lines = append(lines, truncate(r.text, inner))
+			continue
+		}
+		cursor := "  "
+		if r.index == m.buildCursor {
+			cursor = "▶ "
+		}
+		lines = append(lines, statusFailStyle.Render(truncate(cursor+r.text, inner)))
+	}
+
+	for _, line := range message {
+		lines = append(lines, truncate("  "+line, inner))
+	}
+
+	lines = append(lines, "", muted.Render("j/k:Move | enter:Open in editor | esc:Close"))
+	return placeBox(width, height, lines)
+}
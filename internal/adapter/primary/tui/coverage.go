@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// addCoverage records the coverage of a run. Packages the run did not
+// cover keep their previous coverage.
+func (m *Model) addCoverage(coverage *domain.Coverage) {
+	for _, cov := range coverage.Packages {
+		m.coverage[cov.Pkg] = cov
+	}
+	m.updatePackageList()
+	m.updateDiffCoverage()
+	m.refreshSourceCoverage()
+}
+
+// totalCoverage sums the latest coverage of every package
+func (m *Model) totalCoverage() (domain.CoverageCounts, bool) {
+	var total domain.CoverageCounts
+	for _, cov := range m.coverage {
+		total.Statements += cov.Statements
+		total.Covered += cov.Covered
+	}
+	return total, len(m.coverage) > 0
+}
+
+// formatPercent formats a coverage percentage such as "82.4%"
+func formatPercent(counts domain.CoverageCounts) string {
+	return strconv.FormatFloat(counts.Percent(), 'f', 1, 64) + "%"
+}
+
+// renderFileCoverage renders the per-file coverage of the package under
+// the cursor in the packages pane, in at most maxLines lines
+func (m *Model) renderFileCoverage(width, maxLines int) []string {
+	item, ok := m.packageList.SelectedItem().(packageItem)
+	if !ok || item.coverage == nil || len(item.coverage.Files) == 0 || maxLines < 2 {
+		return nil
+	}
+
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	lines := []string{muted.Render("Coverage by file")}
+
+	rows := make([][]string, 0, len(item.coverage.Files))
+	for _, file := range item.coverage.Files {
+		counts := strconv.Itoa(file.Covered) + "/" + strconv.Itoa(file.Statements)
+		rows = append(rows, []string{file.Name(), formatPercent(file.CoverageCounts), counts})
+	}
+	for i, line := range alignTable(rows, 1) {
+		if len(lines) == maxLines-1 && i < len(rows)-1 {
+			lines = append(lines, muted.Render("… "+strconv.Itoa(len(rows)-i)+" more files"))
+			break
+		}
+		lines = append(lines, truncate(" "+line, width))
+	}
+	return lines
+}
+
+// truncate cuts s to width cells
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
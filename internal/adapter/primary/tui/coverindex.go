@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// addCoverageIndex records the lines the tests of an indexing run execute
+func (m *Model) addCoverageIndex(index *domain.CoverageIndex) {
+	// The index is merged into one of the model's own; the bus shares
+	// events among its subscribers
+	if m.coverageIndex == nil {
+		m.coverageIndex = domain.NewCoverageIndex()
+	}
+	m.coverageIndex.Merge(index)
+}
+
+// indexTests returns the tests of pkg to index: the selected ones, or all
+// of its top-level tests, fuzz targets and examples
+func (m *Model) indexTests(pkg domain.PkgID) []domain.TestID {
+	var selected, all []domain.TestID
+	for id := range m.testResults {
+		if domain.PkgID(id.Pkg) != pkg || domain.KindOf(id.Name) == domain.KindBenchmark {
+			continue
+		}
+		if m.selectedTests[id] {
+			selected = append(selected, id)
+		}
+		if !strings.Contains(id.Name, "/") {
+			all = append(all, id)
+		}
+	}
+
+	ids := all
+	if len(selected) > 0 {
+		ids = selected
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Name < ids[j].Name })
+	return ids
+}
+
+// runCoverageIndex runs each test on its own with coverage to learn the
+// lines it executes
+func (m *Model) runCoverageIndex(ids []domain.TestID) tea.Cmd {
+	if len(ids) == 0 {
+		m.detailsContent = []string{
+			"No tests to index yet.",
+			"Run the package once so its tests are known.",
+		}
+		return nil
+	}
+	if !m.startRun() {
+		return nil
+	}
+
+	m.indexing = true
+	m.detailsContent = []string{"Indexing the coverage of " + strconv.Itoa(len(ids)) + " tests..."}
+
+	return func() tea.Msg {
+		err := m.runTestsUC.ExecuteCoverageIndex(m.ctx, ids)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return nil
+	}
+}
+
+// coveringTests returns the indexed tests executing the line under the
+// source viewer's cursor
+func (m *Model) coveringTests() []domain.TestID {
+	if m.coverageIndex == nil || len(m.sourceFiles) == 0 {
+		return nil
+	}
+	file := string(m.sourcePkg.ID) + "/" + m.sourceFiles[m.sourceIndex].name
+	return m.coverageIndex.TestsAt(file, m.sourceCursor+1)
+}
+
+// packageIndexed reports whether any test of pkg is in the coverage index
+func (m *Model) packageIndexed(pkg domain.PkgID) bool {
+	if m.coverageIndex == nil {
+		return false
+	}
+	for _, id := range m.coverageIndex.Tests {
+		if domain.PkgID(id.Pkg) == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// runCoveringTests runs exactly the tests executing the line under the
+// source viewer's cursor
+func (m *Model) runCoveringTests() tea.Cmd {
+	ids := m.coveringTests()
+	if len(ids) == 0 || !m.startRun() {
+		return nil
+	}
+
+	m.showSource = false
+	m.detailsContent = []string{
+		"Running " + strconv.Itoa(len(ids)) + " tests covering " +
+			m.sourceFiles[m.sourceIndex].name + ":" + strconv.Itoa(m.sourceCursor+1) + "...",
+	}
+
+	return func() tea.Msg {
+		err := m.runTestsUC.ExecuteMultipleTests(m.ctx, ids)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/gitdiff"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// updateDiffCoverage checks the lines changed since the diff base against
+// the latest coverage of every package
+func (m *Model) updateDiffCoverage() {
+	if m.diffBase == "" || len(m.coverage) == 0 {
+		return
+	}
+
+	changed, err := gitdiff.ChangedLines(m.ctx, m.dir, m.diffBase)
+	if err != nil {
+		m.diffErr = err
+		return
+	}
+	m.diffErr = nil
+
+	coverage := &domain.Coverage{}
+	for _, cov := range m.coverage {
+		coverage.Packages = append(coverage.Packages, cov)
+	}
+	sort.Slice(coverage.Packages, func(i, j int) bool { return coverage.Packages[i].Pkg < coverage.Packages[j].Pkg })
+
+	m.diffCoverage = domain.NewDiffCoverage(m.diffBase, changed, coverage)
+	m.diffCursor = min(m.diffCursor, max(len(m.diffCoverage.Files)-1, 0))
+}
+
+// handleDiffKey handles keys while the changed lines view is open
+func (m *Model) handleDiffKey(msg tea.KeyMsg) tea.Cmd {
+	var files []domain.FileDiffCoverage
+	if m.diffCoverage != nil {
+		files = m.diffCoverage.Files
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.diffCursor < len(files)-1 {
+			m.diffCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.diffCursor > 0 {
+			m.diffCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		// Show the file's first uncovered change in the source viewer
+		if m.diffCursor < len(files) {
+			file := files[m.diffCursor]
+			line := 1
+			if len(file.Uncovered) > 0 {
+				line = file.Uncovered[0]
+			}
+			if pkg := m.findPackage(domain.PkgID(path.Dir(file.File))); pkg != nil {
+				m.showDiff = false
+				m.openSourceAt(pkg, path.Base(file.File), line)
+			}
+		}
+	case key.Matches(msg, m.keys.DiffCover), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showDiff = false
+	}
+	return nil
+}
+
+// findPackage returns the discovered package id, nil if there is none
+func (m *Model) findPackage(id domain.PkgID) *domain.Package {
+	for _, pkg := range m.packages {
+		if pkg.ID == id {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// renderDiff renders the changed lines view: per changed file, how many of
+// its changed statements are covered and the lines that are not
+func (m *Model) renderDiff(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	title := titleStyle.Render("Changed lines")
+
+	var message string
+	switch {
+	case m.diffBase == "":
+		message = "Start lazygotest with -diff-base ref (or diff_base in the configuration) to check the lines changed since ref."
+	case m.diffErr != nil:
+		message = "Cannot diff against " + m.diffBase + ": " + m.diffErr.Error()
+	case m.diffCoverage == nil:
+		message = "Run tests with coverage (" + m.keys.ToggleCover.Help().Key + ") to check the lines changed since " + m.diffBase + "."
+	case len(m.diffCoverage.Files) == 0 && len(m.diffCoverage.Unmeasured) == 0:
+		message = "No statements changed since " + m.diffBase + "."
+	}
+	if message != "" {
+		return placeBox(width, height, []string{title, "", muted.Render(message), "", muted.Render("esc:Close")})
+	}
+
+	total := m.diffCoverage.Total()
+	lines := []string{
+		title + muted.Render("since "+m.diffBase+": "+formatPercent(total)+" of "+
+			strconv.Itoa(total.Statements)+" changed statement lines covered"),
+		"",
+	}
+
+	rows := make([][]string, len(m.diffCoverage.Files))
+	for i, file := range m.diffCoverage.Files {
+		counts := strconv.Itoa(file.Covered) + "/" + strconv.Itoa(file.Statements)
+		rows[i] = []string{file.Path, formatPercent(file.CoverageCounts), counts, file.UncoveredRanges()}
+	}
+
+	// Title, blank lines, unmeasured files and hints
+	visible := max(height-2-6-len(m.diffCoverage.Unmeasured), 1)
+	start := max(m.diffCursor-visible+1, 0)
+	inner := max(width-4, 20)
+	for i, row := range alignTable(rows, 2) {
+		if i < start || i >= start+visible {
+			continue
+		}
+		cursor := "  "
+		if i == m.diffCursor {
+			cursor = "▶ "
+		}
+		row = truncate(cursor+row, inner)
+		if len(m.diffCoverage.Files[i].Uncovered) > 0 {
+			row = statusFailStyle.Render(row)
+		} else {
+			row = statusPassStyle.Render(row)
+		}
+		lines = append(lines, row)
+	}
+
+	if len(m.diffCoverage.Unmeasured) > 0 {
+		lines = append(lines, "")
+		for _, name := range m.diffCoverage.Unmeasured {
+			lines = append(lines, truncate(muted.Render("  "+name+"  (package not covered yet)"), inner))
+		}
+	}
+
+	lines = append(lines, "", muted.Render("j/k:Move | enter:Show in source | esc:Close"))
+	return placeBox(width, height, lines)
+}
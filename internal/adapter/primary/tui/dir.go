@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/pkgrepo"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// currentDir returns the directory tests run in
+func (m *Model) currentDir() string {
+	if m.dir != "" {
+		return m.dir
+	}
+	return m.rootDir
+}
+
+// dirLabel shows dir relative to the directory lazygotest was started in
+func (m *Model) dirLabel(dir string) string {
+	rel, err := filepath.Rel(m.rootDir, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return dir
+	}
+	if rel == "." {
+		return "."
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// openDirSwitcher lists the modules below the start directory
+func (m *Model) openDirSwitcher() {
+	if m.source != "" {
+		m.detailsContent = []string{"Switching directories is not available for " + m.source + " input."}
+		return
+	}
+
+	modules, err := pkgrepo.FindModules(m.rootDir)
+	if err != nil {
+		m.detailsContent = []string{"Error: " + err.Error()}
+		return
+	}
+
+	current := m.currentDir()
+	found := false
+	for _, module := range modules {
+		found = found || module == current
+	}
+	if !found {
+		modules = append([]string{current}, modules...)
+	}
+
+	m.dirModules = modules
+	m.dirCursor = 0
+	for i, module := range modules {
+		if module == current {
+			m.dirCursor = i
+		}
+	}
+	m.dirMessage = ""
+	m.dirInput.Blur()
+	m.pickingDir = true
+}
+
+// handleDirSwitcherKey handles keys while the directory switcher is open
+func (m *Model) handleDirSwitcherKey(msg tea.KeyMsg) tea.Cmd {
+	if m.dirInput.Focused() {
+		switch msg.String() {
+		case "enter":
+			return m.switchDir(m.dirInput.Value())
+		case "esc":
+			m.dirInput.Blur()
+			return nil
+		}
+		var cmd tea.Cmd
+		m.dirInput, cmd = m.dirInput.Update(msg)
+		return cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.dirCursor < len(m.dirModules)-1 {
+			m.dirCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.dirCursor > 0 {
+			m.dirCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		if m.dirCursor < len(m.dirModules) {
+			return m.switchDir(m.dirModules[m.dirCursor])
+		}
+	case msg.String() == "n":
+		m.dirMessage = ""
+		m.dirInput.SetValue("")
+		m.dirInput.Focus()
+	case key.Matches(msg, m.keys.SwitchDir), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.pickingDir = false
+	}
+	return nil
+}
+
+// switchDir makes dir (absolute or relative to the start directory) the
+// module root, clears the results of the previous one and reloads the packages
+func (m *Model) switchDir(dir string) tea.Cmd {
+	if m.isRunning {
+		m.dirMessage = "cannot switch directories while tests are running"
+		return nil
+	}
+
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		m.dirMessage = "enter a directory"
+		return nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(m.rootDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	if !pkgrepo.IsDir(dir) {
+		m.dirMessage = dir + " is not a directory"
+		return nil
+	}
+	if !m.listPkgsUC.SetDir(dir) {
+		m.dirMessage = "the package source cannot change directories"
+		return nil
+	}
+
+	m.pickingDir = false
+	m.dirInput.Blur()
+	m.dir = dir
+	m.syncRunOptions()
+
+	m.packages = nil
+	m.selectedPackage = nil
+	m.selectedTest = nil
+	m.summary = nil
+	m.testResults = make(map[domain.TestID]*domain.TestCase)
+	m.selectedTests = make(map[domain.TestID]bool)
+	m.updatePackageList()
+	m.updateTestList()
+
+	m.detailsContent = []string{"Switched to " + m.dirLabel(dir)}
+	return m.loadPackages()
+}
+
+// newDirInput creates the text input of the directory switcher
+func newDirInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "path/to/module"
+	input.Cursor.SetMode(cursor.CursorStatic)
+	return input
+}
+
+// renderDirSwitcher renders the directory switcher centered in the content area
+func (m *Model) renderDirSwitcher(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	current := m.currentDir()
+
+	lines := []string{titleStyle.Render("Module root"), ""}
+	for i, module := range m.dirModules {
+		cursor := "  "
+		if i == m.dirCursor && !m.dirInput.Focused() {
+			cursor = "▶ "
+		}
+		line := cursor + m.dirLabel(module)
+		if module == current {
+			line += muted.Render(" (current)")
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "")
+	if m.dirInput.Focused() {
+		lines = append(lines, m.dirInput.View())
+	}
+	if m.dirMessage != "" {
+		lines = append(lines, statusFailStyle.Render(m.dirMessage))
+	}
+	if m.dirInput.Focused() {
+		lines = append(lines, muted.Render("enter:Switch | esc:Cancel"))
+	} else {
+		lines = append(lines, muted.Render(hint(m.keys.Enter, "Switch")+" | n:Other path | esc:Close"))
+	}
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// startDir returns the working directory lazygotest was started in
+func startDir() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	return wd
+}
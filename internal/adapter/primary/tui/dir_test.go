@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/pkgrepo"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// dirRepo serves the same packages in every directory and records the
+// directory it was moved to
+type dirRepo struct {
+	*pkgrepo.StaticPackageRepo
+	dir string
+}
+
+func (r *dirRepo) SetDir(dir string) {
+	r.dir = dir
+}
+
+// writeModules creates a go.mod in each directory below root
+func writeModules(t *testing.T, root string, dirs ...string) {
+	t.Helper()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDirSwitcher(t *testing.T) {
+	root := t.TempDir()
+	writeModules(t, root, ".", "svc")
+	repo := &dirRepo{StaticPackageRepo: pkgrepo.NewStaticPackageRepo([]domain.PkgID{"example.com/svc"})}
+	m := newModel(t, Config{PackageRepo: repo})
+	m.rootDir = root
+	svc := filepath.Join(root, "svc")
+
+	press(m, "D")
+	if !m.pickingDir || !reflect.DeepEqual(m.dirModules, []string{root, svc}) || m.dirCursor != 0 {
+		t.Fatalf("switcher open %v on %d of %q, want open on the root of [root svc]", m.pickingDir, m.dirCursor, m.dirModules)
+	}
+	if view := m.renderDirSwitcher(80, 20); !strings.Contains(view, ". (current)") || !strings.Contains(view, "./svc") {
+		t.Errorf("switcher lacks the modules:\n%s", view)
+	}
+
+	// Switching clears the results of the previous module
+	id := domain.TestID{Pkg: "example.com", Name: "TestA"}
+	m.testResults[id] = &domain.TestCase{ID: id}
+	m.summary = &domain.TestSummary{TotalTests: 1}
+	cmd := press(m, "j", "enter")
+	if m.pickingDir || m.dir != svc || repo.dir != svc || m.runTestsUC.Defaults().Dir != svc {
+		t.Fatalf("switched to %q, repo in %q, runs in %q; want all in %s",
+			m.dir, repo.dir, m.runTestsUC.Defaults().Dir, svc)
+	}
+	if len(m.testResults) != 0 || m.summary != nil {
+		t.Error("results of the previous module kept")
+	}
+	if got := strings.Join(m.detailsContent, "\n"); got != "Switched to ./svc" {
+		t.Errorf("details = %q, want Switched to ./svc", got)
+	}
+	if cmd == nil {
+		t.Fatal("switching reloaded no packages")
+	}
+	m.Update(cmd())
+	if len(m.packages) != 1 {
+		t.Errorf("packages = %v, want the reloaded one", m.packages)
+	}
+
+	// Another path, relative to the start directory
+	press(m, "D")
+	if m.dirCursor != 1 {
+		t.Errorf("switcher reopened on %d, want the current module 1", m.dirCursor)
+	}
+	press(m, "n", "missing", "enter")
+	if !m.pickingDir || m.dirMessage != filepath.Join(root, "missing")+" is not a directory" {
+		t.Errorf("switched to a missing directory: open %v, message %q", m.pickingDir, m.dirMessage)
+	}
+	press(m, "esc")
+	press(m, "n", ".", "enter")
+	if m.pickingDir || m.dir != root {
+		t.Errorf("switcher open %v in %q, want closed in the root", m.pickingDir, m.dir)
+	}
+
+	// A current directory outside the modules is listed first
+	m.dir = t.TempDir()
+	press(m, "D")
+	if len(m.dirModules) != 3 || m.dirModules[0] != m.dir || m.dirCursor != 0 {
+		t.Errorf("switcher on %d of %q, want the current directory first", m.dirCursor, m.dirModules)
+	}
+	press(m, "esc")
+
+	// Not while tests run
+	m.isRunning = true
+	press(m, "D", "enter")
+	if !m.pickingDir || m.dirMessage != "cannot switch directories while tests are running" {
+		t.Errorf("switched while running: open %v, message %q", m.pickingDir, m.dirMessage)
+	}
+}
+
+func TestDirSwitcherUnavailable(t *testing.T) {
+	root := t.TempDir()
+	writeModules(t, root, ".", "svc")
+
+	// The package repository cannot change directories
+	m := newModel(t, Config{})
+	m.rootDir = root
+	press(m, "D", "j", "enter")
+	if !m.pickingDir || m.dir != "" || m.dirMessage != "the package source cannot change directories" {
+		t.Errorf("switched to %q: open %v, message %q", m.dir, m.pickingDir, m.dirMessage)
+	}
+
+	// Recorded input has no directories
+	m = newModel(t, Config{Source: "replay"})
+	m.rootDir = root
+	press(m, "D")
+	if m.pickingDir || len(m.detailsContent) == 0 || !strings.HasPrefix(m.detailsContent[0], "Switching directories is not available") {
+		t.Errorf("switcher open %v with details %q, want it unavailable", m.pickingDir, m.detailsContent)
+	}
+}
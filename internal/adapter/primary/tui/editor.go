@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openInEditor opens an absolute file at line in the configured editor
+func (m *Model) openInEditor(file string, line int) tea.Cmd {
+	fields := strings.Fields(m.editor)
+	if len(fields) == 0 || !filepath.IsAbs(file) {
+		return nil
+	}
+	if _, err := os.Stat(file); err != nil {
+		m.appendDetail("Cannot open " + file + ": " + err.Error())
+		return nil
+	}
+
+	args := append(fields[1:], editorArgs(fields[0], file, line)...)
+	cmd := exec.Command(fields[0], args...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return nil
+	})
+}
+
+// editorArgs returns the arguments opening file at line: "+line file" as
+// vi, emacs and nano take them, or file:line for editors that expect it
+func editorArgs(editor, file string, line int) []string {
+	location := file + ":" + strconv.Itoa(line)
+	switch filepath.Base(editor) {
+	case "code", "code-insiders", "codium", "cursor":
+		return []string{"-g", location}
+	case "subl", "zed", "hx":
+		return []string{location}
+	default:
+		return []string{"+" + strconv.Itoa(line), file}
+	}
+}
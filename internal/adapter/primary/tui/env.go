@@ -0,0 +1,281 @@
+package tui
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// commonEnvVars are always listed in the env editor so their inherited
+// values are visible before they are overridden
+var commonEnvVars = []string{"CGO_ENABLED", "GOEXPERIMENT", "GOFLAGS"}
+
+// envRow is a variable shown in the env editor
+type envRow struct {
+	name  string
+	value string
+	unset bool
+	// origin is where the effective value comes from: override, profile,
+	// config or inherited
+	origin string
+}
+
+// envOverrides returns the overrides of the active profile. A nil value
+// unsets the variable.
+func (m *Model) envOverrides() map[string]*string {
+	overrides, ok := m.envByProfile[m.profile]
+	if !ok {
+		overrides = make(map[string]*string)
+		m.envByProfile[m.profile] = overrides
+	}
+	return overrides
+}
+
+// effectiveEnv merges the profile environment with the overrides into the
+// variables to set and the names to unset
+func (m *Model) effectiveEnv() (set, unset []string) {
+	env := make(map[string]*string)
+	for _, pair := range m.baseRunOpts.Env {
+		name, value, _ := strings.Cut(pair, "=")
+		env[name] = &value
+	}
+	for _, name := range m.baseRunOpts.Unsetenv {
+		env[name] = nil
+	}
+	for name, value := range m.envOverrides() {
+		env[name] = value
+	}
+
+	for name, value := range env {
+		if value == nil {
+			unset = append(unset, name)
+			continue
+		}
+		set = append(set, name+"="+*value)
+	}
+	sort.Strings(set)
+	sort.Strings(unset)
+	return set, unset
+}
+
+// envRows lists the variables for the env editor
+func (m *Model) envRows() []envRow {
+	baseOrigin := "config"
+	if m.profile != "" {
+		baseOrigin = "profile"
+	}
+
+	rows := make(map[string]envRow)
+	for _, name := range commonEnvVars {
+		value, ok := os.LookupEnv(name)
+		rows[name] = envRow{name: name, value: value, unset: !ok, origin: "inherited"}
+	}
+	for _, pair := range m.baseRunOpts.Env {
+		name, value, _ := strings.Cut(pair, "=")
+		rows[name] = envRow{name: name, value: value, origin: baseOrigin}
+	}
+	for _, name := range m.baseRunOpts.Unsetenv {
+		rows[name] = envRow{name: name, unset: true, origin: baseOrigin}
+	}
+	for name, value := range m.envOverrides() {
+		row := envRow{name: name, unset: value == nil, origin: "override"}
+		if value != nil {
+			row.value = *value
+		}
+		rows[name] = row
+	}
+
+	sorted := make([]envRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+// openEnvEditor shows the env editor
+func (m *Model) openEnvEditor() {
+	m.editingEnv = true
+	m.envMessage = ""
+	m.envInput.Blur()
+	if rows := m.envRows(); m.envCursor >= len(rows) {
+		m.envCursor = 0
+	}
+}
+
+// handleEnvEditorKey handles keys while the env editor is open
+func (m *Model) handleEnvEditorKey(msg tea.KeyMsg) tea.Cmd {
+	if m.envInput.Focused() {
+		switch msg.String() {
+		case "enter":
+			m.applyEnvInput(m.envInput.Value())
+			return nil
+		case "esc":
+			m.envInput.Blur()
+			return nil
+		}
+		var cmd tea.Cmd
+		m.envInput, cmd = m.envInput.Update(msg)
+		return cmd
+	}
+
+	rows := m.envRows()
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.envCursor < len(rows)-1 {
+			m.envCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.envCursor > 0 {
+			m.envCursor--
+		}
+	case key.Matches(msg, m.keys.Enter), msg.String() == "e":
+		if m.envCursor < len(rows) {
+			row := rows[m.envCursor]
+			m.startEnvInput(row.name + "=" + row.value)
+		}
+	case msg.String() == "n":
+		m.startEnvInput("")
+	case msg.String() == "d":
+		if m.envCursor < len(rows) {
+			m.setEnvOverride(rows[m.envCursor].name, nil)
+		}
+	case msg.String() == "x":
+		if m.envCursor < len(rows) {
+			delete(m.envOverrides(), rows[m.envCursor].name)
+			m.syncRunOptions()
+		}
+	case key.Matches(msg, m.keys.EditEnv), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.editingEnv = false
+	}
+	return nil
+}
+
+// startEnvInput focuses the input with the given text
+func (m *Model) startEnvInput(text string) {
+	m.envMessage = ""
+	m.envInput.SetValue(text)
+	m.envInput.CursorEnd()
+	m.envInput.Focus()
+}
+
+// applyEnvInput parses NAME=VALUE (set) or -NAME (unset) into an override
+func (m *Model) applyEnvInput(text string) {
+	text = strings.TrimSpace(text)
+
+	if name, ok := strings.CutPrefix(text, "-"); ok {
+		if !validEnvName(name) {
+			m.envMessage = "invalid variable name " + name
+			return
+		}
+		m.setEnvOverride(name, nil)
+		m.envInput.Blur()
+		return
+	}
+
+	name, value, ok := strings.Cut(text, "=")
+	if !ok || !validEnvName(name) {
+		m.envMessage = "expected NAME=VALUE to set or -NAME to unset"
+		return
+	}
+	m.setEnvOverride(name, &value)
+	m.envInput.Blur()
+}
+
+// setEnvOverride overrides a variable for the active profile and moves the
+// cursor to it
+func (m *Model) setEnvOverride(name string, value *string) {
+	m.envOverrides()[name] = value
+	m.syncRunOptions()
+
+	for i, row := range m.envRows() {
+		if row.name == name {
+			m.envCursor = i
+		}
+	}
+}
+
+func validEnvName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "= \t")
+}
+
+// newEnvInput creates the text input of the env editor
+func newEnvInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "NAME=VALUE or -NAME"
+	// A static cursor needs no blink messages routed to the input
+	input.Cursor.SetMode(cursor.CursorStatic)
+	return input
+}
+
+// renderEnvEditor renders the env editor centered in the content area
+func (m *Model) renderEnvEditor(width, height int) string {
+	title := "Environment"
+	if m.profile != "" {
+		title += " (profile " + m.profile + ")"
+	}
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+
+	lines := []string{titleStyle.Render(title), ""}
+	for i, row := range m.envRows() {
+		cursor := "  "
+		if i == m.envCursor && !m.envInput.Focused() {
+			cursor = "▶ "
+		}
+		value := row.name + "=" + row.value
+		if row.unset {
+			value = row.name + " (unset)"
+		}
+		line := cursor + value + "  " + muted.Render(row.origin)
+		if row.origin == "override" {
+			line = cursor + statusRunningStyle.Render(value) + "  " + muted.Render(row.origin)
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "")
+	if m.envInput.Focused() {
+		lines = append(lines, m.envInput.View())
+	}
+	if m.envMessage != "" {
+		lines = append(lines, statusFailStyle.Render(m.envMessage))
+	}
+	if m.envInput.Focused() {
+		lines = append(lines, muted.Render("enter:Apply | esc:Cancel"))
+	} else {
+		lines = append(lines, muted.Render(
+			hint(m.keys.Enter, "Edit")+" | n:New | d:Unset | x:Reset | esc:Close"))
+	}
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// envHeader summarizes the effective overrides for the header, shortening
+// long values
+func (m *Model) envHeader() string {
+	const maxValue = 24
+
+	opts := m.runTestsUC.Defaults()
+	overrides := opts.EnvOverrides()
+	for i, pair := range overrides {
+		if name, value, ok := strings.Cut(pair, "="); ok && len([]rune(value)) > maxValue {
+			overrides[i] = name + "=" + string([]rune(value)[:maxValue-1]) + "…"
+		}
+	}
+	return strings.Join(overrides, " ")
+}
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+)
+
+// checkEnv checks the variables the next run sets and unsets
+func checkEnv(t *testing.T, m *Model, set, unset []string) {
+	t.Helper()
+	opts := m.runTestsUC.Defaults()
+	if !reflect.DeepEqual(opts.Env, set) || !reflect.DeepEqual(opts.Unsetenv, unset) {
+		t.Errorf("run sets %q and unsets %q, want %q and %q", opts.Env, opts.Unsetenv, set, unset)
+	}
+}
+
+func TestEnvEditor(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=mod")
+	base := runner.RunOptions{Env: []string{"A=1", "B=2"}, Unsetenv: []string{"C"}}
+	m := newModel(t, Config{
+		RunOptions: base,
+		Profiles:   []Profile{{RunOptions: base}, {Name: "ci"}},
+	})
+
+	press(m, "E")
+	if !m.editingEnv {
+		t.Fatal("env editor not open")
+	}
+
+	press(m, "n", "FOO=bar", "enter")
+	checkEnv(t, m, []string{"A=1", "B=2", "FOO=bar"}, []string{"C"})
+	if row := m.envRows()[m.envCursor]; row.name != "FOO" || row.origin != "override" {
+		t.Errorf("cursor on %+v, want the FOO override", row)
+	}
+
+	// -NAME unsets a variable of the configuration
+	press(m, "n", "-A", "enter")
+	checkEnv(t, m, []string{"B=2", "FOO=bar"}, []string{"A", "C"})
+
+	// Editing starts from the current value
+	press(m, "j", "enter")
+	if got := m.envInput.Value(); got != "B=2" {
+		t.Errorf("editing B starts from %q, want B=2", got)
+	}
+	press(m, "0", "enter")
+	checkEnv(t, m, []string{"B=20", "FOO=bar"}, []string{"A", "C"})
+
+	// Invalid input keeps the input open with a message
+	press(m, "n", "bogus", "enter")
+	if !m.envInput.Focused() || m.envMessage == "" {
+		t.Errorf("invalid input accepted: focused %v, message %q", m.envInput.Focused(), m.envMessage)
+	}
+	press(m, "esc")
+	if m.envInput.Focused() || !m.editingEnv {
+		t.Error("esc did not leave the input for the list")
+	}
+	checkEnv(t, m, []string{"B=20", "FOO=bar"}, []string{"A", "C"})
+
+	// d unsets the variable under the cursor, x drops its override
+	press(m, "d")
+	checkEnv(t, m, []string{"FOO=bar"}, []string{"A", "B", "C"})
+	press(m, "x")
+	checkEnv(t, m, []string{"B=2", "FOO=bar"}, []string{"A", "C"})
+
+	rows := make(map[string]envRow)
+	for _, row := range m.envRows() {
+		rows[row.name] = row
+	}
+	want := map[string]envRow{
+		"B":       {name: "B", value: "2", origin: "config"},
+		"C":       {name: "C", unset: true, origin: "config"},
+		"GOFLAGS": {name: "GOFLAGS", value: "-mod=mod", origin: "inherited"},
+		"FOO":     {name: "FOO", value: "bar", origin: "override"},
+	}
+	for name, row := range want {
+		if rows[name] != row {
+			t.Errorf("row %s = %+v, want %+v", name, rows[name], row)
+		}
+	}
+
+	press(m, "esc")
+	if m.editingEnv {
+		t.Fatal("esc did not close the env editor")
+	}
+	if got, want := m.envHeader(), "B=2 FOO=bar -A -C"; got != want {
+		t.Errorf("header shows %q, want %q", got, want)
+	}
+
+	// Overrides are kept per profile
+	m.applyProfile(m.profiles[1])
+	checkEnv(t, m, nil, nil)
+	m.applyProfile(m.profiles[0])
+	checkEnv(t, m, []string{"B=2", "FOO=bar"}, []string{"A", "C"})
+}
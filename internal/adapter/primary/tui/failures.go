@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// openFailures opens the failures view on the test under the cursor in the
+// tests pane. For a test that panicked it shows the panic's stack with the
+// cursor on the frame the panic came from.
+func (m *Model) openFailures() {
+	test := m.cursorTest()
+	if test == nil || test.LastFail == nil {
+		m.appendDetail("Select a failed test in the tests pane to list its failures.")
+		return
+	}
+	m.failuresTest = test
+	m.failureCursor = 0
+	if panicked := test.LastFail.Panic; panicked != nil {
+		m.failureCursor = max(panicked.Origin(m.testModule(test.ID)), 0)
+	}
+	m.showFailures = true
+}
+
+// testModule returns the module of a test's package, empty when unknown
+func (m *Model) testModule(id domain.TestID) string {
+	if pkg := m.findPackage(domain.PkgID(id.Pkg)); pkg != nil {
+		return pkg.Module
+	}
+	return ""
+}
+
+// addPanic reports a test that panicked in the details pane, where the
+// stack trace it printed has just scrolled by
+func (m *Model) addPanic(test *domain.TestCase) {
+	panicked := test.LastFail.Panic
+	message := "Panicked: " + test.ID.Name
+	if i := panicked.Origin(m.testModule(test.ID)); i >= 0 {
+		frame := panicked.Goroutine.Frames[i]
+		message += " in " + frame.Func + " at " + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+	}
+	m.appendDetail(message + ": " + panicked.Summary() + "; " + m.keys.Failures.Help().Key + " shows the stack")
+}
+
+// panickedCount counts the tests whose latest run panicked
+func (m *Model) panickedCount() int {
+	n := 0
+	for _, test := range m.testResults {
+		if test.Status == domain.StatusPanicked {
+			n++
+		}
+	}
+	return n
+}
+
+// handleFailuresKey handles keys while the failures view is open
+func (m *Model) handleFailuresKey(msg tea.KeyMsg) tea.Cmd {
+	failures := m.failuresTest.LastFail.Failures
+	count := len(failures)
+	if panicked := m.failuresTest.LastFail.Panic; panicked != nil {
+		count = len(panicked.Goroutine.Frames)
+	}
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.failureCursor < count-1 {
+			m.failureCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.failureCursor > 0 {
+			m.failureCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		if panicked := m.failuresTest.LastFail.Panic; panicked != nil {
+			if m.failureCursor < count {
+				frame := panicked.Goroutine.Frames[m.failureCursor]
+				return m.openInEditor(frame.File, frame.Line)
+			}
+			return nil
+		}
+		if m.failureCursor < count {
+			failure := failures[m.failureCursor]
+			return m.openInEditor(m.failureFile(failure), failure.Line)
+		}
+	case key.Matches(msg, m.keys.Failures), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showFailures = false
+	}
+	return nil
+}
+
+// failureFile resolves a failure's file, which the testing package prints
+// by base name, in the directory of the test's package
+func (m *Model) failureFile(failure domain.Failure) string {
+	dir := m.currentDir()
+	if pkg := m.findPackage(domain.PkgID(m.failuresTest.ID.Pkg)); pkg != nil && pkg.Path != "" {
+		dir = pkg.Path
+	}
+	return filepath.Join(dir, failure.File)
+}
+
+// renderFailures renders the failures view: the t.Error and t.Fatal
+// messages of the test's failed run, the one under the cursor in full
+func (m *Model) renderFailures(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	test := m.failuresTest
+	if test.LastFail.Panic != nil {
+		return m.renderPanic(width, height)
+	}
+	title := titleStyle.Render("Failures") + muted.Render(test.ID.Name+" ("+test.ID.Pkg+")")
+
+	failures := test.LastFail.Failures
+	if len(failures) == 0 {
+		message := "The run of " + test.ID.Name + " failed without reporting a failure with a location."
+		for id, other := range m.testResults {
+			if id.Pkg == test.ID.Pkg && strings.HasPrefix(id.Name, test.ID.Name+"/") && other.Status.Failed() {
+				message = test.ID.Name + " failed through its subtests; their failures are listed on them."
+				break
+			}
+		}
+		return placeBox(width, height, []string{title, "", muted.Render(message), "", muted.Render("esc:Close")})
+	}
+
+	// A rerun may have replaced the failures under the cursor
+	m.failureCursor = min(m.failureCursor, len(failures)-1)
+	inner := max(width-4, 20)
+	lines := []string{title, ""}
+
+	// The message of the failure under the cursor is shown in full below
+	message := strings.Split(failures[m.failureCursor].Message, "\n")
+	visible := max(height-2-6-len(message), 1)
+	start := max(m.failureCursor-visible+1, 0)
+	for i, failure := range failures {
+		if i < start || i >= start+visible {
+			continue
+		}
+		cursor := "  "
+		if i == m.failureCursor {
+			cursor = "▶ "
+		}
+		lines = append(lines, statusFailStyle.Render(truncate(cursor+failure.Summary(), inner)))
+	}
+
+	location := failures[m.failureCursor].File + ":" + strconv.Itoa(failures[m.failureCursor].Line)
+	lines = append(lines, "", titleStyle.Render(location))
+	for _, line := range message {
+		lines = append(lines, truncate("  "+line, inner))
+	}
+
+	lines = append(lines, "", muted.Render("j/k:Move | enter:Open in editor | esc:Close"))
+	return placeBox(width, height, lines)
+}
+
+// renderPanic renders the failures view of a test that panicked: the panic
+// and the stack of the goroutine that panicked, with the frame the panic
+// came from highlighted
+func (m *Model) renderPanic(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	test := m.failuresTest
+	panicked := test.LastFail.Panic
+	title := titleStyle.Render("Panic") + muted.Render(test.ID.Name+" ("+test.ID.Pkg+")")
+
+	inner := max(width-4, 20)
+	g := panicked.Goroutine
+	lines := []string{
+		title,
+		"",
+		statusFailStyle.Render(truncate(panicked.Summary(), inner)),
+		"",
+		titleStyle.Render("Stack of goroutine " + strconv.Itoa(g.ID)),
+	}
+
+	// A rerun may have replaced the panic under the cursor
+	frames := g.Frames
+	m.failureCursor = min(m.failureCursor, max(len(frames)-1, 0))
+	origin := panicked.Origin(m.testModule(test.ID))
+
+	// Below the frames: the creator, a blank line and the hints
+	visible := max(height-2-len(lines)-3, 1)
+	start := max(m.failureCursor-visible+1, 0)
+	for i, frame := range frames {
+		if i < start || i >= start+visible {
+			continue
+		}
+		cursor := "  "
+		if i == m.failureCursor {
+			cursor = "▶ "
+		}
+		line := truncate(cursor+frame.Func+"  "+frame.File+":"+strconv.Itoa(frame.Line), inner)
+		switch {
+		case i == origin:
+			line = statusFailStyle.Bold(true).Render(line)
+		case frame.Std():
+			line = muted.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if g.CreatedBy != nil {
+		lines = append(lines, truncate(muted.Render("  created by "+g.CreatedBy.Func+"  "+
+			g.CreatedBy.File+":"+strconv.Itoa(g.CreatedBy.Line)), inner))
+	}
+
+	lines = append(lines, "", muted.Render("j/k:Move | enter:Open in editor | esc:Close"))
+	return placeBox(width, height, lines)
+}
@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// defaultFuzztime bounds fuzzing runs without a configured -fuzztime, so
+// they stop on their own unless cancelled
+const defaultFuzztime = "30s"
+
+// fuzzTickMsg redraws the progress of a running fuzz target
+type fuzzTickMsg struct{}
+
+// fuzzTick schedules the next redraw of the fuzzing view
+func fuzzTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return fuzzTickMsg{}
+	})
+}
+
+// crasherEntry is a failing input with the contents of its corpus file
+type crasherEntry struct {
+	crasher domain.FuzzCrasher
+	content string
+	err     error
+}
+
+// runFuzz fuzzes the fuzz target selected in the tests pane
+func (m *Model) runFuzz() tea.Cmd {
+	item, ok := m.testList.SelectedItem().(testItem)
+	if m.focusedPane != TestsPane || !ok || domain.KindOf(item.test.ID.Name) != domain.KindFuzz || strings.Contains(item.test.ID.Name, "/") {
+		m.detailsContent = []string{"Select a Fuzz function in the tests pane to fuzz it."}
+		return nil
+	}
+	return m.startFuzz(item.test.ID)
+}
+
+// startFuzz fuzzes a fuzz target and opens the fuzzing view
+func (m *Model) startFuzz(id domain.TestID) tea.Cmd {
+	if !m.startRun() {
+		return nil
+	}
+
+	m.fuzzTarget = id
+	m.fuzzProgress = nil
+	m.fuzzing = true
+	m.crashers = nil
+	m.fuzzCursor = 0
+	m.showFuzz = true
+	m.detailsContent = []string{"Fuzzing " + id.Name + "..."}
+
+	run := func() tea.Msg {
+		err := m.runTestsUC.ExecuteFuzz(m.ctx, id)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return nil
+	}
+	return tea.Batch(run, fuzzTick())
+}
+
+// addCrasher records a failing input and reads its corpus file from the
+// package directory
+func (m *Model) addCrasher(crasher domain.FuzzCrasher) {
+	entry := crasherEntry{crasher: crasher}
+	file, ok := m.corpusFile(crasher)
+	if ok {
+		data, err := os.ReadFile(file)
+		entry.content, entry.err = string(data), err
+	} else {
+		entry.err = os.ErrNotExist
+	}
+
+	m.crashers = append(m.crashers, entry)
+	m.showFuzz = true
+}
+
+// corpusFile resolves the path of a crasher against its package directory
+func (m *Model) corpusFile(crasher domain.FuzzCrasher) (string, bool) {
+	for _, pkg := range m.packages {
+		if string(pkg.ID) == crasher.ID.Pkg && pkg.Path != "" {
+			return filepath.Join(pkg.Path, crasher.Path), true
+		}
+	}
+	return "", false
+}
+
+// handleFuzzKey handles keys while the fuzzing view is open
+func (m *Model) handleFuzzKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.fuzzCursor < len(m.crashers)-1 {
+			m.fuzzCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.fuzzCursor > 0 {
+			m.fuzzCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		// Rerun the failing input as a regular test
+		if m.fuzzCursor < len(m.crashers) {
+			return m.runTest(m.crashers[m.fuzzCursor].crasher.TestID())
+		}
+	case key.Matches(msg, m.keys.FuzzView), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showFuzz = false
+	}
+	return nil
+}
+
+// fuzzFraction returns how much of the -fuzztime budget the progress has
+// used, if the budget is known
+func (m *Model) fuzzFraction(progress domain.FuzzProgress) (float64, bool) {
+	fuzztime := m.runTestsUC.Defaults().Fuzztime
+	if count, ok := strings.CutSuffix(fuzztime, "x"); ok {
+		n, err := strconv.ParseInt(count, 10, 64)
+		if err != nil || n <= 0 || progress.Phase != domain.FuzzPhaseFuzzing {
+			return 0, false
+		}
+		return min(float64(progress.Execs)/float64(n), 1), true
+	}
+	d, err := time.ParseDuration(fuzztime)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return min(float64(progress.Elapsed)/float64(d), 1), true
+}
+
+// progressBar renders fraction as a bar of width cells
+func progressBar(fraction float64, width int) string {
+	filled := int(fraction * float64(width))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// renderFuzz renders the fuzzing view: the progress of the latest fuzzing
+// run and the failing inputs it found
+func (m *Model) renderFuzz(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+
+	if m.fuzzTarget.Name == "" && len(m.crashers) == 0 {
+		lines := []string{
+			titleStyle.Render("Fuzzing"),
+			"",
+			muted.Render("Select a Fuzz function in the tests pane and press " + m.keys.Fuzz.Help().Key + " to fuzz it."),
+			"",
+			muted.Render("esc:Close"),
+		}
+		return placeBox(width, height, lines)
+	}
+
+	status := statusPassStyle.Render("done")
+	if m.fuzzing {
+		status = statusRunningStyle.Render("⟳ running")
+	}
+	if len(m.crashers) > 0 {
+		status = statusFailStyle.Render("✗ failing input found")
+	}
+	title := "Fuzzing " + m.fuzzTarget.Name
+	lines := []string{titleStyle.Render(title) + " " + status, muted.Render(" " + m.fuzzTarget.Pkg), ""}
+
+	if p := m.fuzzProgress; p != nil {
+		phase := string(p.Phase)
+		if p.Phase == domain.FuzzPhaseFuzzing && p.Workers > 0 {
+			phase += " with " + strconv.Itoa(p.Workers) + " workers"
+		}
+		rows := [][]string{{"Phase", phase}, {"Elapsed", p.Elapsed.String()}}
+		if fraction, ok := m.fuzzFraction(*p); ok {
+			rows[1][1] += " of " + m.runTestsUC.Defaults().Fuzztime + "  " + progressBar(fraction, 20)
+		}
+		switch p.Phase {
+		case domain.FuzzPhaseBaseline:
+			rows = append(rows, []string{"Baseline", strconv.Itoa(p.BaselineDone) + "/" + strconv.Itoa(p.BaselineTotal) + " corpus entries"})
+		case domain.FuzzPhaseFuzzing:
+			rows = append(rows,
+				[]string{"Execs", strconv.FormatInt(p.Execs, 10) + " (" + strconv.FormatInt(p.ExecsPerSec, 10) + "/sec)"},
+				[]string{"Interesting", strconv.Itoa(p.NewInteresting) + " new (total " + strconv.Itoa(p.TotalInteresting) + ")"})
+		}
+		for _, line := range alignTable(rows, 2) {
+			lines = append(lines, "  "+line)
+		}
+	} else if m.fuzzing {
+		lines = append(lines, muted.Render("  Building and gathering the baseline coverage..."))
+	}
+
+	if len(m.crashers) > 0 {
+		lines = append(lines, "", titleStyle.Render("Failing inputs"))
+		for i, entry := range m.crashers {
+			cursor := "  "
+			if i == m.fuzzCursor {
+				cursor = "▶ "
+			}
+			line := entry.crasher.Path
+			if test, ok := m.testResults[entry.crasher.TestID()]; ok {
+				switch test.Status {
+				case domain.StatusPassed:
+					line = statusPassStyle.Render("✓ ") + line
+				case domain.StatusFailed:
+					line = statusFailStyle.Render("✗ ") + line
+				case domain.StatusPanicked:
+					line = statusFailStyle.Render("‼ ") + line
+				case domain.StatusRunning:
+					line = statusRunningStyle.Render("⟳ ") + line
+				case domain.StatusCancelled:
+					line = muted.Render("⊘ ") + line
+				}
+			}
+			lines = append(lines, cursor+line)
+		}
+
+		// Contents of the selected input, cut to the space left
+		entry := m.crashers[m.fuzzCursor]
+		lines = append(lines, "")
+		if entry.err != nil {
+			lines = append(lines, muted.Render("Cannot read "+entry.crasher.Path+": "+entry.err.Error()))
+		} else {
+			content := strings.Split(strings.TrimRight(entry.content, "\n"), "\n")
+			room := max(height-len(lines)-5, 1)
+			if len(content) > room {
+				content = append(content[:room-1], "…")
+			}
+			for _, line := range content {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+
+	hints := []string{}
+	if len(m.crashers) > 0 {
+		hints = append(hints, hint(m.keys.Enter, "Rerun input"))
+	}
+	lines = append(lines, "", muted.Render(strings.Join(append(hints, "esc:Close"), " | ")))
+
+	return placeBox(width, height, lines)
+}
+
+// placeBox centers the lines in a bordered box
+func placeBox(width, height int, lines []string) string {
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,287 @@
+package tui
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// runTickMsg redraws the time running tests have been silent
+type runTickMsg struct{}
+
+// runTick schedules the next redraw while tests run
+func runTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return runTickMsg{}
+	})
+}
+
+// addStall marks a test the run reports stalled
+func (m *Model) addStall(stall domain.TestStall) {
+	test, ok := m.testResults[stall.ID]
+	if !ok {
+		return
+	}
+	test.Stall()
+	m.stalls[stall.ID] = stall
+	m.appendDetail("Stalled: " + stall.ID.Name + " printed nothing for " +
+		stall.Idle(time.Now()).Round(time.Second).String() + "; " + m.keys.QuitTest.Help().Key +
+		" sends SIGQUIT to its test binary to see its goroutines")
+	m.updateTestList()
+}
+
+// stalledCount counts the tests currently stalled
+func (m *Model) stalledCount() int {
+	n := 0
+	for _, test := range m.testResults {
+		if test.Status == domain.StatusStalled {
+			n++
+		}
+	}
+	return n
+}
+
+// quitCursorTest sends SIGQUIT to the test binary running the test under
+// the cursor in the tests pane; its goroutine dump opens once the package
+// has exited
+func (m *Model) quitCursorTest() {
+	test := m.cursorTest()
+	if test == nil || (test.Status != domain.StatusRunning && test.Status != domain.StatusStalled) {
+		m.appendDetail("Select a running test in the tests pane to send SIGQUIT to its test binary.")
+		return
+	}
+
+	n, err := runner.QuitTestBinary(m.ctx, test.ID.Pkg)
+	if err != nil {
+		m.appendDetail("Error: " + err.Error())
+		return
+	}
+	id := test.ID
+	m.dumpPending = &id
+	message := "Sent SIGQUIT to the test binary of " + test.ID.Pkg
+	if n > 1 {
+		message += " and " + strconv.Itoa(n-1) + " others of the same name"
+	}
+	m.appendDetail(message + "; its goroutines are shown once it exits.")
+}
+
+// openPendingDump shows the goroutine dump of a test binary sent SIGQUIT
+// once its package finished
+func (m *Model) openPendingDump(pkg string) {
+	if m.dumpPending == nil || m.dumpPending.Pkg != pkg {
+		return
+	}
+	id := *m.dumpPending
+	m.dumpPending = nil
+	m.openGoroutines(id)
+}
+
+// openGoroutines opens the goroutine viewer on the last goroutine dump in
+// the logs of test id. The dump of a binary that crashed may be attributed
+// to another test of the package, which is searched next.
+func (m *Model) openGoroutines(id domain.TestID) {
+	candidates := []domain.TestID{id}
+	var others []domain.TestID
+	for other := range m.testResults {
+		if other.Pkg == id.Pkg && other != id {
+			others = append(others, other)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].Name < others[j].Name })
+	candidates = append(candidates, others...)
+
+	m.dumpTest = id
+	m.goroutines = nil
+	for _, candidate := range candidates {
+		test, ok := m.testResults[candidate]
+		if !ok {
+			continue
+		}
+		if goroutines := domain.ParseGoroutineDump(strings.Join(test.Logs, "")); len(goroutines) > 0 {
+			m.dumpTest = candidate
+			m.goroutines = goroutines
+			break
+		}
+	}
+
+	m.dumpCursor = 0
+	m.frameCursor = 0
+	m.dumpFrames = false
+	m.showDump = true
+}
+
+// visibleGoroutines returns the goroutines listed: those running code
+// outside the standard library first, then the others when dumpAll is set
+func (m *Model) visibleGoroutines() []domain.Goroutine {
+	var user, std []domain.Goroutine
+	for _, g := range m.goroutines {
+		if g.User() {
+			user = append(user, g)
+		} else {
+			std = append(std, g)
+		}
+	}
+	if m.dumpAll || len(user) == 0 {
+		return append(user, std...)
+	}
+	return user
+}
+
+// handleGoroutinesKey handles keys while the goroutine viewer is open
+func (m *Model) handleGoroutinesKey(msg tea.KeyMsg) tea.Cmd {
+	goroutines := m.visibleGoroutines()
+	var frames []domain.StackFrame
+	if m.dumpCursor < len(goroutines) {
+		frames = goroutines[m.dumpCursor].Frames
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.dumpFrames && m.frameCursor < len(frames)-1 {
+			m.frameCursor++
+		} else if !m.dumpFrames && m.dumpCursor < len(goroutines)-1 {
+			m.dumpCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.dumpFrames && m.frameCursor > 0 {
+			m.frameCursor--
+		} else if !m.dumpFrames && m.dumpCursor > 0 {
+			m.dumpCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		if m.dumpFrames && m.frameCursor < len(frames) {
+			frame := frames[m.frameCursor]
+			return m.openInEditor(frame.File, frame.Line)
+		}
+		// Step into the stack at the innermost frame outside the standard
+		// library
+		if len(frames) > 0 {
+			m.dumpFrames = true
+			m.frameCursor = 0
+			for i, frame := range frames {
+				if !frame.Std() {
+					m.frameCursor = i
+					break
+				}
+			}
+		}
+	case msg.String() == "a" && !m.dumpFrames:
+		m.dumpAll = !m.dumpAll
+		m.dumpCursor = 0
+	case m.dumpFrames && (key.Matches(msg, m.keys.PrevPane) || msg.String() == "esc"):
+		m.dumpFrames = false
+	case key.Matches(msg, m.keys.Goroutines), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showDump = false
+	}
+	return nil
+}
+
+// renderGoroutines renders the goroutine viewer: the goroutines of a dump
+// with their state, wait and innermost code outside the standard library,
+// and the stack of the one under the cursor
+func (m *Model) renderGoroutines(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	title := titleStyle.Render("Goroutines") + muted.Render(m.dumpTest.Name+" ("+m.dumpTest.Pkg+")")
+
+	if len(m.goroutines) == 0 {
+		lines := []string{
+			title,
+			"",
+			muted.Render("The logs of " + m.dumpTest.Name + " have no goroutine dump."),
+			muted.Render("Press " + m.keys.QuitTest.Help().Key + " on a running test to make its test binary print one."),
+			"",
+			muted.Render("esc:Close"),
+		}
+		return placeBox(width, height, lines)
+	}
+
+	goroutines := m.visibleGoroutines()
+	inner := max(width-4, 20)
+	lines := []string{title}
+	if hidden := len(m.goroutines) - len(goroutines); hidden > 0 {
+		lines = append(lines, muted.Render(" "+strconv.Itoa(hidden)+" goroutines in the standard library only are hidden"))
+	}
+	lines = append(lines, "")
+
+	rows := make([][]string, len(goroutines))
+	for i, g := range goroutines {
+		state := g.State
+		if g.LockedToThread {
+			state += ", locked"
+		}
+		wait := ""
+		if g.Wait > 0 {
+			wait = g.Wait.String()
+		}
+		where := ""
+		if frame, ok := g.TopFrame(); ok {
+			where = frame.Func + "  " + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+		}
+		rows[i] = []string{"#" + strconv.Itoa(g.ID), state, wait, where}
+	}
+
+	// The list takes up to half the box, the stack the rest
+	listHeight := max(min(len(rows), (height-8)/2), 1)
+	start := max(m.dumpCursor-listHeight+1, 0)
+	for i, row := range alignTable(rows, 4) {
+		if i < start || i >= start+listHeight {
+			continue
+		}
+		cursor := "  "
+		if i == m.dumpCursor {
+			cursor = "▶ "
+		}
+		row = truncate(cursor+row, inner)
+		if !goroutines[i].User() {
+			row = muted.Render(row)
+		}
+		lines = append(lines, row)
+	}
+
+	if m.dumpCursor < len(goroutines) {
+		g := goroutines[m.dumpCursor]
+		lines = append(lines, "", titleStyle.Render("Stack of goroutine "+strconv.Itoa(g.ID)))
+
+		frames := g.Frames
+		// Below the frames: the creator, a blank line and the hints
+		frameHeight := max(height-5-len(lines), 1)
+		frameStart := 0
+		if m.dumpFrames {
+			frameStart = max(m.frameCursor-frameHeight+1, 0)
+		}
+		for i, frame := range frames {
+			if i < frameStart || i >= frameStart+frameHeight {
+				continue
+			}
+			cursor := "  "
+			if m.dumpFrames && i == m.frameCursor {
+				cursor = "▶ "
+			}
+			line := truncate(cursor+frame.Func+"  "+frame.File+":"+strconv.Itoa(frame.Line), inner)
+			if frame.Std() {
+				line = muted.Render(line)
+			}
+			lines = append(lines, line)
+		}
+		if g.CreatedBy != nil {
+			lines = append(lines, truncate(muted.Render("  created by "+g.CreatedBy.Func+"  "+
+				g.CreatedBy.File+":"+strconv.Itoa(g.CreatedBy.Line)), inner))
+		}
+	}
+
+	hints := "j/k:Move | enter:Stack | a:All goroutines | esc:Close"
+	if m.dumpFrames {
+		hints = "j/k:Move | enter:Open in editor | esc:Goroutines"
+	}
+	lines = append(lines, "", muted.Render(hints))
+	return placeBox(width, height, lines)
+}
@@ -0,0 +1,245 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// KeyMap defines keybindings
+type KeyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	NextPane     key.Binding
+	PrevPane     key.Binding
+	Enter        key.Binding
+	Quit         key.Binding
+	Help         key.Binding
+	Rerun        key.Binding
+	RunAll       key.Binding
+	FailedOnly   key.Binding
+	ToggleRace   key.Binding
+	ToggleCover  key.Binding
+	ToggleWatch  key.Binding
+	ToggleSelect key.Binding
+	SelectAll    key.Binding
+	DeselectAll  key.Binding
+	PickProfile  key.Binding
+	EditEnv      key.Binding
+	SwitchDir    key.Binding
+	Benchmarks   key.Binding
+	Fuzz         key.Binding
+	FuzzView     key.Binding
+	ViewSource   key.Binding
+	IndexCover   key.Binding
+	DiffCover    key.Binding
+	Cancel       key.Binding
+	QuitTest     key.Binding
+	Goroutines   key.Binding
+	BuildErrors  key.Binding
+	Failures     key.Binding
+	Races        key.Binding
+}
+
+// DefaultKeyMap returns the default keybindings
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		NextPane: key.NewBinding(
+			key.WithKeys("tab", "l"),
+			key.WithHelp("tab/l", "next pane"),
+		),
+		PrevPane: key.NewBinding(
+			key.WithKeys("shift+tab", "h"),
+			key.WithHelp("shift+tab/h", "prev pane"),
+		),
+		Enter: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "run"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "help"),
+		),
+		Rerun: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "rerun"),
+		),
+		RunAll: key.NewBinding(
+			key.WithKeys("a", "A"),
+			key.WithHelp("A", "all"),
+		),
+		FailedOnly: key.NewBinding(
+			key.WithKeys("f", "F"),
+			key.WithHelp("F", "failed"),
+		),
+		ToggleRace: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "race"),
+		),
+		ToggleCover: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "cover"),
+		),
+		ToggleWatch: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "watch"),
+		),
+		ToggleSelect: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("Space", "toggle"),
+		),
+		SelectAll: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "all"),
+		),
+		DeselectAll: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "none"),
+		),
+		PickProfile: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "profile"),
+		),
+		EditEnv: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "env"),
+		),
+		SwitchDir: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "dir"),
+		),
+		Benchmarks: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "benchmarks"),
+		),
+		Fuzz: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "fuzz"),
+		),
+		FuzzView: key.NewBinding(
+			key.WithKeys("Z"),
+			key.WithHelp("Z", "fuzzing"),
+		),
+		ViewSource: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "source"),
+		),
+		IndexCover: key.NewBinding(
+			key.WithKeys("I"),
+			key.WithHelp("I", "index coverage"),
+		),
+		DiffCover: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "changed lines"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "cancel"),
+		),
+		QuitTest: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "sigquit"),
+		),
+		Goroutines: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "goroutines"),
+		),
+		BuildErrors: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "build errors"),
+		),
+		Failures: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "failures"),
+		),
+		Races: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "data races"),
+		),
+	}
+}
+
+// actions maps configuration action names to their bindings
+func (k *KeyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":            &k.Up,
+		"down":          &k.Down,
+		"next_pane":     &k.NextPane,
+		"prev_pane":     &k.PrevPane,
+		"run":           &k.Enter,
+		"quit":          &k.Quit,
+		"help":          &k.Help,
+		"rerun":         &k.Rerun,
+		"run_all":       &k.RunAll,
+		"failed_only":   &k.FailedOnly,
+		"toggle_race":   &k.ToggleRace,
+		"toggle_cover":  &k.ToggleCover,
+		"toggle_watch":  &k.ToggleWatch,
+		"toggle_select": &k.ToggleSelect,
+		"select_all":    &k.SelectAll,
+		"deselect_all":  &k.DeselectAll,
+		"pick_profile":  &k.PickProfile,
+		"edit_env":      &k.EditEnv,
+		"switch_dir":    &k.SwitchDir,
+		"benchmarks":    &k.Benchmarks,
+		"fuzz":          &k.Fuzz,
+		"fuzz_view":     &k.FuzzView,
+		"view_source":   &k.ViewSource,
+		"index_cover":   &k.IndexCover,
+		"diff_cover":    &k.DiffCover,
+		"cancel":        &k.Cancel,
+		"quit_test":     &k.QuitTest,
+		"goroutines":    &k.Goroutines,
+		"build_errors":  &k.BuildErrors,
+		"failures":      &k.Failures,
+		"races":         &k.Races,
+	}
+}
+
+// Apply rebinds actions by name, e.g. {"rerun": ["ctrl+r"]}
+func (k *KeyMap) Apply(overrides map[string][]string) error {
+	actions := k.actions()
+
+	for action, keys := range overrides {
+		binding, ok := actions[action]
+		if !ok {
+			names := make([]string, 0, len(actions))
+			for name := range actions {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return errors.Invalid("keybindings."+action,
+				"unknown action, expected one of "+strings.Join(names, ", "))
+		}
+		if len(keys) == 0 {
+			return errors.Invalid("keybindings."+action, "at least one key is required")
+		}
+
+		*binding = key.NewBinding(
+			key.WithKeys(keys...),
+			key.WithHelp(strings.Join(keys, "/"), binding.Help().Desc),
+		)
+	}
+
+	return nil
+}
+
+// hint renders a footer entry for a binding
+func hint(b key.Binding, label string) string {
+	return b.Help().Key + ":" + label
+}
@@ -2,13 +2,16 @@ package tui
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
-	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/pkgrepo"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/config"
 	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
 	"github.com/YuminosukeSato/lazygotest/internal/domain"
 	"github.com/YuminosukeSato/lazygotest/internal/shared/eventbus"
@@ -37,6 +40,7 @@ type Model struct {
 	detailsScrollPos int    // Current scroll position in details pane
 	detailsMaxScroll int    // Maximum scroll position
 	lastKey          string // For multi-key commands like gg
+	keys             KeyMap
 
 	// Domain State
 	packages        []*domain.Package
@@ -46,13 +50,133 @@ type Model struct {
 	summary         *domain.TestSummary
 	selectedTests   map[domain.TestID]bool // Track selected tests for batch execution
 
+	// Run configuration
+	patterns    []string
+	baseRunOpts runner.RunOptions
+	editor      string
+	source      string
+	focus       []domain.TestID
+	fuzz        domain.TestID
+
+	// Profiles
+	profiles       []Profile
+	profile        string
+	pickingProfile bool
+	profileCursor  int
+
+	// Env editor; overrides are kept per profile name
+	envByProfile map[string]map[string]*string
+	editingEnv   bool
+	envCursor    int
+	envInput     textinput.Model
+	envMessage   string
+
+	// Directory switcher; rootDir is where lazygotest was started
+	rootDir    string
+	dir        string
+	pickingDir bool
+	dirCursor  int
+	dirModules []string
+	dirInput   textinput.Model
+	dirMessage string
+
+	// Benchmarks view; benchStale marks results of a finished run that the
+	// next run replaces
+	showBenchmarks bool
+	benchmarks     []domain.BenchmarkResult
+	benchCursor    int
+	benchStale     bool
+
+	// Completed runs with benchmark results, for comparisons against the
+	// baseline run; benchBaseline is -1 without one
+	benchRuns     []domain.BenchmarkRun
+	benchBaseline int
+	comparing     bool
+	saveBench     string
+
+	// Fuzzing view; fuzzTarget is the target of the latest fuzzing run and
+	// fuzzing reports whether it is still running
+	showFuzz     bool
+	fuzzing      bool
+	fuzzTarget   domain.TestID
+	fuzzProgress *domain.FuzzProgress
+	crashers     []crasherEntry
+	fuzzCursor   int
+
+	// Statement coverage of the latest run covering each package
+	coverage map[string]domain.PackageCoverage
+
+	// Source viewer on the files of sourcePkg; sourceCursor is the line
+	// whose covering tests are listed
+	showSource   bool
+	sourcePkg    *domain.Package
+	sourceFiles  []sourceFile
+	sourceIndex  int
+	sourceCursor int
+	sourceScroll int
+
+	// Lines executed by each test of the indexing runs; indexing reports
+	// whether one is in progress
+	coverageIndex *domain.CoverageIndex
+	indexing      bool
+
+	// Changed lines view: the coverage of the lines changed since diffBase
+	diffBase     string
+	diffCoverage *domain.DiffCoverage
+	diffErr      error
+	showDiff     bool
+	diffCursor   int
+
+	// Tests the run reports stalled, with when they last printed output
+	stalls  map[domain.TestID]domain.TestStall
+	ticking bool
+
+	// Goroutine viewer on the dump in the logs of dumpTest; dumpFrames
+	// moves the cursor through the stack of the goroutine under
+	// dumpCursor. dumpPending is a test whose binary was sent SIGQUIT.
+	showDump    bool
+	dumpTest    domain.TestID
+	goroutines  []domain.Goroutine
+	dumpAll     bool
+	dumpCursor  int
+	dumpFrames  bool
+	frameCursor int
+	dumpPending *domain.TestID
+
+	// Build errors view on the errors of the latest run; their paths are
+	// relative to buildDir, the directory it ran in
+	showBuild   bool
+	buildErrors []domain.BuildError
+	buildDir    string
+	buildCursor int
+
+	// Failures view on the failures, or the panic, of failuresTest's
+	// failed run
+	showFailures  bool
+	failuresTest  *domain.TestCase
+	failureCursor int
+
+	// Race panel on the unique data races of the latest run; raceFrames
+	// moves the cursor through the stacks of the race under raceCursor
+	showRaces       bool
+	races           domain.RaceLog
+	raceCursor      int
+	raceFrames      bool
+	raceFrameCursor int
+
 	// Dependencies
 	listPkgsUC *usecase.ListPackagesUseCase
 	runTestsUC *usecase.RunTestsUseCase
 	eventBus   *eventbus.EventBus
+	// program receives the events of the bus as messages
+	program atomic.Pointer[tea.Program]
 
-	// Flags
+	// Flags; cancelling reports whether the running tests were asked to stop
 	isRunning       bool
+	cancelling      bool
+	autoRun         bool
+	singleRun       bool
+	runLocked       bool
 	showFailedOnly  bool
 	watchMode       bool
 	raceDetection   bool
@@ -63,29 +187,108 @@ type Model struct {
 	cancel context.CancelFunc
 }
 
+// Config holds the startup settings for the TUI
+type Config struct {
+	// Patterns scopes package discovery and "run all"; empty means ./...
+	Patterns []string
+	// RunOptions are the defaults applied to every run
+	RunOptions runner.RunOptions
+	// Watch starts the TUI with watch mode enabled
+	Watch bool
+	// Editor is the command used to open files
+	Editor string
+	// Runner executes the tests
+	Runner usecase.TestRunner
+	// PackageRepo discovers the packages shown in the packages pane
+	PackageRepo usecase.PackageRepository
+	// AutoRun runs all tests as soon as the packages are loaded
+	AutoRun bool
+	// Focus lists tests to run as soon as the packages are loaded, instead
+	// of AutoRun's full run
+	Focus []domain.TestID
+	// Fuzz is a fuzz target to fuzz as soon as the packages are loaded
+	Fuzz domain.TestID
+	// Profiles are offered by the profile picker; none disables it
+	Profiles []Profile
+	// Profile is the name of the active profile
+	Profile string
+	// Dir is the module root go runs in; empty means the working directory
+	Dir string
+	// Baseline is the benchmark run comparisons start from, such as one
+	// loaded from a file
+	Baseline *domain.BenchmarkRun
+	// SaveBenchmarks is a file the benchmark results of each run are
+	// written to
+	SaveBenchmarks string
+	// DiffBase is a git ref; the lines changed since it are checked against
+	// the coverage of each run
+	DiffBase string
+	// StallThreshold is how long a running test may print nothing before
+	// it is flagged stalled; zero disables the watch
+	StallThreshold time.Duration
+	// SingleRun disables runs after the first one, for inputs that can only
+	// be read once such as stdin
+	SingleRun bool
+	// Source names a non-toolchain input (replay, stdin) for the header
+	Source string
+	// Keys overrides the default keybindings when set
+	Keys *KeyMap
+	// Theme overrides the default colors
+	Theme config.Theme
+}
+
 // New creates a new TUI application model
-func New() *Model {
+func New(cfg Config) *Model {
 	// Initialize dependencies
 	bus := eventbus.New(1000)
-	pkgRepo := pkgrepo.NewGoPackageRepo()
-	testRunner := runner.NewTestRunner()
 
-	listPkgsUC := usecase.NewListPackagesUseCase(pkgRepo, bus)
-	runTestsUC := usecase.NewRunTestsUseCase(testRunner, bus)
+	listPkgsUC := usecase.NewListPackagesUseCase(cfg.PackageRepo, bus)
+	runTestsUC := usecase.NewRunTestsUseCase(cfg.Runner, bus)
+	runTestsUC.SetStallThreshold(cfg.StallThreshold)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	keys := DefaultKeyMap()
+	if cfg.Keys != nil {
+		keys = *cfg.Keys
+	}
+	applyTheme(cfg.Theme)
+
 	m := &Model{
-		focusedPane:    PackagesPane,
-		packages:       make([]*domain.Package, 0),
-		testResults:    make(map[domain.TestID]*domain.TestCase),
-		selectedTests:  make(map[domain.TestID]bool),
-		detailsContent: make([]string, 0),
-		listPkgsUC:     listPkgsUC,
-		runTestsUC:     runTestsUC,
-		eventBus:       bus,
-		ctx:            ctx,
-		cancel:         cancel,
+		focusedPane:     PackagesPane,
+		keys:            keys,
+		patterns:        cfg.Patterns,
+		baseRunOpts:     cfg.RunOptions,
+		editor:          cfg.Editor,
+		watchMode:       cfg.Watch,
+		autoRun:         cfg.AutoRun,
+		singleRun:       cfg.SingleRun,
+		source:          cfg.Source,
+		focus:           cfg.Focus,
+		fuzz:            cfg.Fuzz,
+		profiles:        cfg.Profiles,
+		profile:         cfg.Profile,
+		envByProfile:    make(map[string]map[string]*string),
+		envInput:        newEnvInput(),
+		rootDir:         startDir(),
+		dir:             cfg.Dir,
+		dirInput:        newDirInput(),
+		benchBaseline:   -1,
+		saveBench:       cfg.SaveBenchmarks,
+		diffBase:        cfg.DiffBase,
+		raceDetection:   cfg.RunOptions.Race,
+		coverageEnabled: cfg.RunOptions.Cover,
+		packages:        make([]*domain.Package, 0),
+		testResults:     make(map[domain.TestID]*domain.TestCase),
+		selectedTests:   make(map[domain.TestID]bool),
+		coverage:        make(map[string]domain.PackageCoverage),
+		stalls:          make(map[domain.TestID]domain.TestStall),
+		detailsContent:  make([]string, 0),
+		listPkgsUC:      listPkgsUC,
+		runTestsUC:      runTestsUC,
+		eventBus:        bus,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 
 	// Initialize lists with custom styles
@@ -123,6 +326,13 @@ func New() *Model {
 	m.testList.SetShowStatusBar(false)
 	m.testList.SetShowHelp(false)
 
+	if cfg.Baseline != nil {
+		m.benchRuns = []domain.BenchmarkRun{*cfg.Baseline}
+		m.benchBaseline = 0
+	}
+
+	m.syncRunOptions()
+
 	// Subscribe to events
 	m.subscribeToEvents()
 
@@ -138,6 +348,16 @@ func (m *Model) Init() tea.Cmd {
 	)
 }
 
+// Wait cancels the runs in progress and waits for their processes to exit,
+// which are killed runner.QuitGrace after the cancel. Call it once the
+// program has quit, before exiting.
+func (m *Model) Wait() {
+	m.cancel()
+	if !m.runTestsUC.Wait(runner.QuitGrace + time.Second) {
+		logger.Warn("Exiting with test processes still running")
+	}
+}
+
 // Update handles messages
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -149,20 +369,86 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateSizes()
 
 	case tea.KeyMsg:
+		// Overlays take all keys; the lists must not move underneath them
+		if m.pickingProfile {
+			return m, m.handleProfilePickerKey(msg)
+		}
+		if m.editingEnv {
+			return m, m.handleEnvEditorKey(msg)
+		}
+		if m.pickingDir {
+			return m, m.handleDirSwitcherKey(msg)
+		}
+		if m.showBenchmarks {
+			return m, m.handleBenchmarksKey(msg)
+		}
+		if m.showFuzz {
+			return m, m.handleFuzzKey(msg)
+		}
+		if m.showSource {
+			return m, m.handleSourceKey(msg)
+		}
+		if m.showDiff {
+			return m, m.handleDiffKey(msg)
+		}
+		if m.showDump {
+			return m, m.handleGoroutinesKey(msg)
+		}
+		if m.showBuild {
+			return m, m.handleBuildErrorsKey(msg)
+		}
+		if m.showFailures {
+			return m, m.handleFailuresKey(msg)
+		}
+		if m.showRaces {
+			return m, m.handleRacesKey(msg)
+		}
 		cmds = append(cmds, m.handleKeyPress(msg))
 
 	case packagesLoadedMsg:
 		m.packages = msg.packages
 		m.updatePackageList()
+		if m.fuzz.Name != "" {
+			fuzz := m.fuzz
+			m.fuzz = domain.TestID{}
+			m.autoRun = false
+			cmds = append(cmds, m.startFuzz(fuzz))
+		} else if len(m.focus) > 0 {
+			focus := m.focus
+			m.focus = nil
+			m.autoRun = false
+			cmds = append(cmds, m.runFocusedTests(focus))
+		} else if m.autoRun {
+			m.autoRun = false
+			cmds = append(cmds, m.runAllTests())
+		}
 
 	case testEventMsg:
 		m.handleTestEvent(msg.event)
 
+	case busEventMsg:
+		m.handleBusEvent(msg)
+
+	case runTickMsg:
+		m.ticking = false
+
+	case fuzzTickMsg:
+		// Redraw the progress until the fuzzing run completes
+		if m.fuzzing {
+			cmds = append(cmds, fuzzTick())
+		}
+
 	case errorMsg:
 		logger.Error("Error occurred", "error", msg.err)
 		m.appendDetail("Error: " + msg.err.Error())
 	}
 
+	// Redraw every second while tests run, so stalls show up without input
+	if m.isRunning && !m.ticking {
+		m.ticking = true
+		cmds = append(cmds, runTick())
+	}
+
 	// Update the focused list
 	switch m.focusedPane {
 	case PackagesPane:
@@ -186,61 +472,115 @@ func (m *Model) View() string {
 // handleKeyPress handles keyboard input
 func (m *Model) handleKeyPress(msg tea.KeyMsg) tea.Cmd {
 	// Global keybindings (work in any pane)
-	switch msg.String() {
-	case "q", "ctrl+c":
+	switch {
+	case key.Matches(msg, m.keys.Quit):
 		m.cancel()
 		return tea.Quit
 
-	case "tab", "l": // l for right movement
+	case key.Matches(msg, m.keys.NextPane):
 		m.focusNextPane()
 		return nil
 
-	case "shift+tab", "h": // h for left movement
+	case key.Matches(msg, m.keys.PrevPane):
 		m.focusPrevPane()
 		return nil
 
-	case "enter":
+	case key.Matches(msg, m.keys.Enter):
 		return m.handleEnter()
 
-	case "f", "F":
+	case key.Matches(msg, m.keys.FailedOnly):
 		m.showFailedOnly = !m.showFailedOnly
 		m.updateTestList()
 		return nil
 
-	case "r":
-		if m.selectedTest != nil {
-			return m.rerunTest()
-		}
+	case key.Matches(msg, m.keys.Rerun) && m.selectedTest != nil:
+		return m.rerunTest()
 
-	case "R":
+	case key.Matches(msg, m.keys.ToggleRace):
 		m.raceDetection = !m.raceDetection
+		m.syncRunOptions()
 		return nil
 
-	case "C":
+	case key.Matches(msg, m.keys.ToggleCover):
 		m.coverageEnabled = !m.coverageEnabled
+		m.syncRunOptions()
 		return nil
 
-	case "W":
+	case key.Matches(msg, m.keys.ToggleWatch):
 		m.watchMode = !m.watchMode
 		return nil
 
-	case " ": // Space key for selection toggle
+	case key.Matches(msg, m.keys.PickProfile):
+		m.openProfilePicker()
+		return nil
+
+	case key.Matches(msg, m.keys.EditEnv):
+		m.openEnvEditor()
+		return nil
+
+	case key.Matches(msg, m.keys.SwitchDir):
+		m.openDirSwitcher()
+		return nil
+
+	case key.Matches(msg, m.keys.Benchmarks):
+		m.showBenchmarks = true
+		return nil
+
+	case key.Matches(msg, m.keys.Fuzz):
+		return m.runFuzz()
+
+	case key.Matches(msg, m.keys.FuzzView):
+		m.showFuzz = true
+		return nil
+
+	case key.Matches(msg, m.keys.ViewSource):
+		m.openSourceViewer()
+		return nil
+
+	case key.Matches(msg, m.keys.DiffCover):
+		m.showDiff = true
+		return nil
+
+	case key.Matches(msg, m.keys.Cancel) && m.isRunning:
+		m.cancelRun()
+		return nil
+
+	case key.Matches(msg, m.keys.QuitTest):
+		m.quitCursorTest()
+		return nil
+
+	case key.Matches(msg, m.keys.Goroutines) && m.cursorTest() != nil:
+		m.openGoroutines(m.cursorTest().ID)
+		return nil
+
+	case key.Matches(msg, m.keys.BuildErrors):
+		m.showBuild = true
+		return nil
+
+	case key.Matches(msg, m.keys.Failures):
+		m.openFailures()
+		return nil
+
+	case key.Matches(msg, m.keys.Races):
+		m.openRaces()
+		return nil
+
+	case key.Matches(msg, m.keys.IndexCover) && m.selectedPackage != nil:
+		return m.runCoverageIndex(m.indexTests(m.selectedPackage.ID))
+
+	case key.Matches(msg, m.keys.ToggleSelect):
 		if m.focusedPane == TestsPane {
 			return m.toggleTestSelection()
 		}
 		return nil
 
-	case "a": // Select all visible tests or run all tests
-		if m.focusedPane == TestsPane {
-			return m.selectAllTests()
-		}
-		// Run all tests from any pane
-		return m.runAllTests()
+	case m.focusedPane == TestsPane && key.Matches(msg, m.keys.SelectAll):
+		return m.selectAllTests()
 
-	case "A": // Deselect all tests or run all tests
-		if m.focusedPane == TestsPane {
-			return m.deselectAllTests()
-		}
+	case m.focusedPane == TestsPane && key.Matches(msg, m.keys.DeselectAll):
+		return m.deselectAllTests()
+
+	case key.Matches(msg, m.keys.RunAll):
 		// Run all tests from any pane
 		return m.runAllTests()
 	}
@@ -265,12 +605,12 @@ func (m *Model) handleListVimKeys(msg tea.KeyMsg) tea.Cmd {
 		targetList = &m.testList
 	}
 
-	switch msg.String() {
-	case "j", "down":
+	switch {
+	case key.Matches(msg, m.keys.Down):
 		targetList.CursorDown()
-	case "k", "up":
+	case key.Matches(msg, m.keys.Up):
 		targetList.CursorUp()
-	case "g":
+	case msg.String() == "g":
 		// Wait for second 'g' for gg command
 		if m.lastKey == "g" {
 			// gg - go to top
@@ -281,18 +621,18 @@ func (m *Model) handleListVimKeys(msg tea.KeyMsg) tea.Cmd {
 		} else {
 			m.lastKey = "g"
 		}
-	case "G":
+	case msg.String() == "G":
 		// Go to bottom
 		items := targetList.Items()
 		for targetList.Cursor() < len(items)-1 {
 			targetList.CursorDown()
 		}
-	case "ctrl+d":
+	case msg.String() == "ctrl+d":
 		// Half page down
 		for i := 0; i < 10; i++ {
 			targetList.CursorDown()
 		}
-	case "ctrl+u":
+	case msg.String() == "ctrl+u":
 		// Half page up
 		for i := 0; i < 10; i++ {
 			targetList.CursorUp()
@@ -309,18 +649,18 @@ func (m *Model) handleListVimKeys(msg tea.KeyMsg) tea.Cmd {
 
 // handleDetailsVimKeys handles Vim keys for details pane
 func (m *Model) handleDetailsVimKeys(msg tea.KeyMsg) tea.Cmd {
-	switch msg.String() {
-	case "j", "down":
+	switch {
+	case key.Matches(msg, m.keys.Down):
 		if m.detailsScrollPos < m.detailsMaxScroll {
 			m.detailsScrollPos++
 		}
-	case "k", "up":
+	case key.Matches(msg, m.keys.Up):
 		if m.detailsScrollPos > 0 {
 			m.detailsScrollPos--
 		}
-	case "G":
+	case msg.String() == "G":
 		m.detailsScrollPos = m.detailsMaxScroll
-	case "g":
+	case msg.String() == "g":
 		if m.lastKey == "g" {
 			// gg - go to top
 			m.detailsScrollPos = 0
@@ -328,12 +668,12 @@ func (m *Model) handleDetailsVimKeys(msg tea.KeyMsg) tea.Cmd {
 		} else {
 			m.lastKey = "g"
 		}
-	case "ctrl+d":
+	case msg.String() == "ctrl+d":
 		m.detailsScrollPos += 10
 		if m.detailsScrollPos > m.detailsMaxScroll {
 			m.detailsScrollPos = m.detailsMaxScroll
 		}
-	case "ctrl+u":
+	case msg.String() == "ctrl+u":
 		m.detailsScrollPos -= 10
 		if m.detailsScrollPos < 0 {
 			m.detailsScrollPos = 0
@@ -346,48 +686,3 @@ func (m *Model) handleDetailsVimKeys(msg tea.KeyMsg) tea.Cmd {
 
 	return nil
 }
-
-// KeyMap defines keybindings
-type KeyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	NextPane key.Binding
-	PrevPane key.Binding
-	Enter    key.Binding
-	Quit     key.Binding
-	Help     key.Binding
-}
-
-// DefaultKeyMap returns the default keybindings
-func DefaultKeyMap() KeyMap {
-	return KeyMap{
-		Up: key.NewBinding(
-			key.WithKeys("up", "k"),
-			key.WithHelp("↑/k", "up"),
-		),
-		Down: key.NewBinding(
-			key.WithKeys("down", "j"),
-			key.WithHelp("↓/j", "down"),
-		),
-		NextPane: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "next pane"),
-		),
-		PrevPane: key.NewBinding(
-			key.WithKeys("shift+tab"),
-			key.WithHelp("shift+tab", "prev pane"),
-		),
-		Enter: key.NewBinding(
-			key.WithKeys("enter"),
-			key.WithHelp("enter", "select"),
-		),
-		Quit: key.NewBinding(
-			key.WithKeys("q", "ctrl+c"),
-			key.WithHelp("q", "quit"),
-		),
-		Help: key.NewBinding(
-			key.WithKeys("?"),
-			key.WithHelp("?", "help"),
-		),
-	}
-}
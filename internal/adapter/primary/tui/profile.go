@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+)
+
+// Profile is a named set of run options selectable from the profile picker
+type Profile struct {
+	// Name is empty for the configuration without a profile
+	Name string
+	// Patterns scope package discovery and "run all"
+	Patterns   []string
+	RunOptions runner.RunOptions
+}
+
+// label returns the name shown in the picker
+func (p Profile) label() string {
+	if p.Name == "" {
+		return "(none)"
+	}
+	return p.Name
+}
+
+// openProfilePicker shows the picker with the active profile under the cursor
+func (m *Model) openProfilePicker() {
+	if len(m.profiles) == 0 {
+		m.detailsContent = []string{"No profiles configured.", "Add profiles to .lazygotest.yaml to switch between them."}
+		return
+	}
+
+	m.pickingProfile = true
+	m.profileCursor = 0
+	for i, profile := range m.profiles {
+		if profile.Name == m.profile {
+			m.profileCursor = i
+		}
+	}
+}
+
+// handleProfilePickerKey handles keys while the picker is open
+func (m *Model) handleProfilePickerKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.profileCursor < len(m.profiles)-1 {
+			m.profileCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.profileCursor > 0 {
+			m.profileCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		m.pickingProfile = false
+		return m.applyProfile(m.profiles[m.profileCursor])
+	case key.Matches(msg, m.keys.PickProfile), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.pickingProfile = false
+	}
+	return nil
+}
+
+// applyProfile makes p the active profile and reloads the packages it selects
+func (m *Model) applyProfile(p Profile) tea.Cmd {
+	if m.isRunning {
+		m.detailsContent = []string{"Cannot switch profiles while tests are running."}
+		return nil
+	}
+
+	m.profile = p.Name
+	m.patterns = p.Patterns
+	m.baseRunOpts = p.RunOptions
+	m.raceDetection = p.RunOptions.Race
+	m.coverageEnabled = p.RunOptions.Cover
+	m.syncRunOptions()
+
+	m.detailsContent = []string{"Switched to profile " + p.label()}
+	return m.loadPackages()
+}
+
+// renderProfilePicker renders the picker centered in the content area
+func (m *Model) renderProfilePicker(width, height int) string {
+	lines := []string{titleStyle.Render("Profiles"), ""}
+	for i, profile := range m.profiles {
+		cursor := "  "
+		if i == m.profileCursor {
+			cursor = "▶ "
+		}
+		line := cursor + profile.label()
+		if profile.Name == m.profile {
+			line += " (active)"
+		}
+		if summary := describeRunOptions(profile); summary != "" {
+			line += "  " + positionIndicatorStyle.Render(summary)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(mutedColor).Render(
+		hint(m.keys.Enter, "Select")+" | esc:Cancel"))
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// describeRunOptions summarizes what a profile changes for the picker
+func describeRunOptions(p Profile) string {
+	opts := p.RunOptions
+
+	var parts []string
+	if len(p.Patterns) > 0 {
+		parts = append(parts, strings.Join(p.Patterns, " "))
+	}
+	if opts.Tags != "" {
+		parts = append(parts, "tags:"+opts.Tags)
+	}
+	if opts.Race {
+		parts = append(parts, "race")
+	}
+	if opts.Cover {
+		parts = append(parts, "cover")
+	}
+	if opts.Short {
+		parts = append(parts, "short")
+	}
+	if opts.Timeout != "" {
+		parts = append(parts, "timeout:"+opts.Timeout)
+	}
+	if opts.PackageParallel > 0 {
+		parts = append(parts, "p:"+intToString(opts.PackageParallel))
+	}
+	if opts.Parallel > 0 {
+		parts = append(parts, "parallel:"+intToString(opts.Parallel))
+	}
+	parts = append(parts, opts.EnvOverrides()...)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/pkgrepo"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+func TestProfilePicker(t *testing.T) {
+	m := newModel(t, Config{
+		PackageRepo: pkgrepo.NewStaticPackageRepo([]domain.PkgID{"example.com/a", "example.com/b"}),
+		RunOptions:  runner.RunOptions{Timeout: "5m"},
+		Profiles: []Profile{
+			{RunOptions: runner.RunOptions{Timeout: "5m"}},
+			{
+				Name:       "ci",
+				Patterns:   []string{"example.com/b"},
+				RunOptions: runner.RunOptions{Race: true, Timeout: "20m", Env: []string{"CI=1"}},
+			},
+			{Name: "fast", RunOptions: runner.RunOptions{Short: true}},
+		},
+	})
+
+	press(m, "P")
+	if !m.pickingProfile || m.profileCursor != 0 {
+		t.Fatalf("picker open %v on %d, want open on the active profile 0", m.pickingProfile, m.profileCursor)
+	}
+	view := m.renderProfilePicker(80, 20)
+	for _, want := range []string{"(none) (active)", "ci", "example.com/b race timeout:20m CI=1", "fast", "short"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("picker lacks %q:\n%s", want, view)
+		}
+	}
+
+	cmd := press(m, "j", "enter")
+	if m.pickingProfile || m.profile != "ci" {
+		t.Fatalf("picker open %v with profile %q, want closed with ci", m.pickingProfile, m.profile)
+	}
+	opts := m.runTestsUC.Defaults()
+	if !opts.Race || !m.raceDetection || opts.Timeout != "20m" || !reflect.DeepEqual(opts.Env, []string{"CI=1"}) {
+		t.Errorf("run options race %v timeout %q env %q, want the profile's", opts.Race, opts.Timeout, opts.Env)
+	}
+
+	// The packages are reloaded with the profile's patterns
+	if cmd == nil {
+		t.Fatal("switching profiles reloaded no packages")
+	}
+	m.Update(cmd())
+	if len(m.packages) != 1 || m.packages[0].ID != "example.com/b" {
+		t.Errorf("packages = %v, want example.com/b", m.packages)
+	}
+
+	// The picker reopens on the active profile and esc keeps it
+	press(m, "P")
+	if m.profileCursor != 1 {
+		t.Errorf("picker reopened on %d, want 1", m.profileCursor)
+	}
+	press(m, "j", "esc")
+	if m.pickingProfile || m.profile != "ci" {
+		t.Errorf("esc left the picker open %v with profile %q, want closed with ci", m.pickingProfile, m.profile)
+	}
+
+	// Not while tests run
+	m.isRunning = true
+	press(m, "P", "k", "k", "enter")
+	if m.profile != "ci" || m.runTestsUC.Defaults().Timeout != "20m" {
+		t.Errorf("switched to profile %q while running", m.profile)
+	}
+}
+
+func TestProfilePickerWithoutProfiles(t *testing.T) {
+	m := newModel(t, Config{})
+	press(m, "P")
+	if m.pickingProfile {
+		t.Error("picker opened without profiles")
+	}
+	if len(m.detailsContent) == 0 || m.detailsContent[0] != "No profiles configured." {
+		t.Errorf("details = %q, want the missing profiles explained", m.detailsContent)
+	}
+}
@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// addRace adds a data race a test reported to the race panel, noting it in
+// the details pane the first time the run reports it
+func (m *Model) addRace(race domain.DataRace) {
+	if !m.races.Merge(race) || len(race.Tests) == 0 {
+		return
+	}
+	where := race.Tests[0].Name
+	if where == "" {
+		where = race.Tests[0].Pkg
+	}
+	m.appendDetail("Data race in " + where + ": " + race.Summary() + "; " +
+		m.keys.Races.Help().Key + " lists the races")
+}
+
+// openRaces opens the race panel, on the first race the test under the
+// cursor in the tests pane reported if it reported any
+func (m *Model) openRaces() {
+	m.raceCursor = 0
+	if test := m.cursorTest(); test != nil {
+		for i, race := range m.races.Races {
+			if raceReportedBy(race, test.ID) {
+				m.raceCursor = i
+				break
+			}
+		}
+	}
+	m.raceFrames = false
+	m.showRaces = true
+}
+
+// raceReportedBy reports whether test id reported the race
+func raceReportedBy(race domain.DataRace, id domain.TestID) bool {
+	for _, other := range race.Tests {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// raceRow is a row of the stacks of a race in the race panel: a heading,
+// or a frame, which the cursor moves through
+type raceRow struct {
+	text  string
+	frame *domain.StackFrame
+	// site marks the frame where one of the accesses happened
+	site bool
+}
+
+// raceRows lays out the stacks of both accesses of a race, each followed by
+// the go statement that started its goroutine
+func raceRows(race domain.DataRace) []raceRow {
+	var rows []raceRow
+	for i, access := range []domain.RaceAccess{race.Access, race.Previous} {
+		heading := access.Describe()
+		if i == 1 {
+			heading = "Previous " + heading
+		} else {
+			heading = strings.ToUpper(heading[:1]) + heading[1:]
+		}
+		rows = append(rows, raceRow{text: heading})
+		site, _ := access.Site()
+		for j := range access.Frames {
+			frame := &access.Frames[j]
+			rows = append(rows, raceRow{frame: frame, site: *frame == site})
+		}
+		if len(access.Created) > 0 {
+			rows = append(rows, raceRow{text: "Goroutine " + strconv.Itoa(access.Goroutine) +
+				" (" + access.State + ") created at"})
+			for j := range access.Created {
+				rows = append(rows, raceRow{frame: &access.Created[j]})
+			}
+		}
+	}
+	return rows
+}
+
+// raceFrameRows returns the indexes of the rows that are frames, and of
+// those the frames where the accesses happened
+func raceFrameRows(rows []raceRow) (frames, sites []int) {
+	for i, row := range rows {
+		if row.frame == nil {
+			continue
+		}
+		if row.site {
+			sites = append(sites, len(frames))
+		}
+		frames = append(frames, i)
+	}
+	return frames, sites
+}
+
+// handleRacesKey handles keys while the race panel is open
+func (m *Model) handleRacesKey(msg tea.KeyMsg) tea.Cmd {
+	races := m.races.Races
+	var rows []raceRow
+	var frames, sites []int
+	if m.raceCursor < len(races) {
+		rows = raceRows(races[m.raceCursor])
+		frames, sites = raceFrameRows(rows)
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		if m.raceFrames && m.raceFrameCursor < len(frames)-1 {
+			m.raceFrameCursor++
+		} else if !m.raceFrames && m.raceCursor < len(races)-1 {
+			m.raceCursor++
+		}
+	case key.Matches(msg, m.keys.Up):
+		if m.raceFrames && m.raceFrameCursor > 0 {
+			m.raceFrameCursor--
+		} else if !m.raceFrames && m.raceCursor > 0 {
+			m.raceCursor--
+		}
+	case key.Matches(msg, m.keys.Enter):
+		if m.raceFrames && m.raceFrameCursor < len(frames) {
+			frame := rows[frames[m.raceFrameCursor]].frame
+			return m.openInEditor(frame.File, frame.Line)
+		}
+		// Step into the stacks at the site of the access that raced
+		if len(frames) > 0 {
+			m.raceFrames = true
+			m.raceFrameCursor = 0
+			if len(sites) > 0 {
+				m.raceFrameCursor = sites[0]
+			}
+		}
+	case m.raceFrames && msg.String() == "tab":
+		// Jump between the sites of both accesses
+		for _, site := range sites {
+			if site != m.raceFrameCursor {
+				m.raceFrameCursor = site
+				break
+			}
+		}
+	case m.raceFrames && (key.Matches(msg, m.keys.PrevPane) || msg.String() == "esc"):
+		m.raceFrames = false
+	case key.Matches(msg, m.keys.Races), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showRaces = false
+	}
+	return nil
+}
+
+// renderRaces renders the race panel: the unique data races of the latest
+// run with the tests that reported them, and the stacks of the one under
+// the cursor with both access sites highlighted
+func (m *Model) renderRaces(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	races := m.races.Races
+	title := titleStyle.Render("Data races")
+
+	if len(races) == 0 {
+		message := "The latest run reported no data races."
+		if !m.raceDetection {
+			message = "Race detection is off; " + m.keys.ToggleRace.Help().Key + " turns it on for the next run."
+		}
+		return placeBox(width, height, []string{title, "", muted.Render(message), "", muted.Render("esc:Close")})
+	}
+
+	count := strconv.Itoa(len(races)) + " unique"
+	lines := []string{title + muted.Render(count), ""}
+	inner := max(width-4, 20)
+
+	// The list takes up to a third of the box, the stacks the rest
+	m.raceCursor = min(m.raceCursor, len(races)-1)
+	listHeight := max(min(len(races), (height-8)/3), 1)
+	start := max(m.raceCursor-listHeight+1, 0)
+	for i, race := range races {
+		if i < start || i >= start+listHeight {
+			continue
+		}
+		cursor := "  "
+		if i == m.raceCursor {
+			cursor = "▶ "
+		}
+		reported := strconv.Itoa(len(race.Tests)) + " tests"
+		if len(race.Tests) == 1 {
+			reported = raceTestName(race.Tests[0])
+		}
+		summary := truncate(cursor+race.Summary(), max(inner-lipgloss.Width(reported)-2, 10))
+		lines = append(lines, statusFailStyle.Render(summary)+"  "+muted.Render(truncate(reported, inner)))
+	}
+
+	race := races[m.raceCursor]
+	lines = append(lines, "")
+	tests := make([]string, len(race.Tests))
+	for i, id := range race.Tests {
+		tests[i] = raceTestName(id)
+	}
+	lines = append(lines, muted.Render(truncate("Reported by "+strings.Join(tests, ", "), inner)))
+	if race.Location != "" {
+		lines = append(lines, muted.Render(truncate("Location is "+race.Location, inner)))
+	}
+
+	rows := raceRows(race)
+	frames, _ := raceFrameRows(rows)
+	cursorRow := -1
+	if m.raceFrames && m.raceFrameCursor < len(frames) {
+		cursorRow = frames[m.raceFrameCursor]
+	}
+
+	// Below the stacks: a blank line and the hints
+	rowHeight := max(height-4-len(lines), 1)
+	rowStart := max(cursorRow-rowHeight+1, 0)
+	for i, row := range rows {
+		if i < rowStart || i >= rowStart+rowHeight {
+			continue
+		}
+		if row.frame == nil {
+			lines = append(lines, titleStyle.Render(truncate(row.text, inner)))
+			continue
+		}
+		cursor := "    "
+		if i == cursorRow {
+			cursor = "  ▶ "
+		}
+		line := truncate(cursor+row.frame.Func+"  "+row.frame.File+":"+strconv.Itoa(row.frame.Line), inner)
+		switch {
+		case row.site:
+			line = statusFailStyle.Bold(true).Render(line)
+		case row.frame.Std():
+			line = muted.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	hints := "j/k:Move | enter:Stacks | esc:Close"
+	if m.raceFrames {
+		hints = "j/k:Move | enter:Open in editor | tab:Other access | esc:Races"
+	}
+	lines = append(lines, "", muted.Render(hints))
+	return placeBox(width, height, lines)
+}
+
+// raceTestName names a test that reported a race, or its package for
+// races reported outside tests
+func raceTestName(id domain.TestID) string {
+	if id.Name == "" {
+		return id.Pkg
+	}
+	return id.Name
+}
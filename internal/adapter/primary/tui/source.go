@@ -0,0 +1,373 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// sourceContext is the number of lines kept above a block jumped to
+const sourceContext = 3
+
+// sourceFile is a file of the package shown in the source viewer
+type sourceFile struct {
+	name  string
+	lines []string
+	err   error
+	// coverage is nil when no run has covered the file
+	coverage *domain.FileCoverage
+}
+
+// openSourceViewer opens the source viewer on the files of the package
+// under the cursor in the packages pane, or the selected package elsewhere
+func (m *Model) openSourceViewer() {
+	pkg := m.selectedPackage
+	if item, ok := m.packageList.SelectedItem().(packageItem); ok && m.focusedPane == PackagesPane {
+		pkg = item.pkg
+	}
+	if pkg == nil {
+		return
+	}
+	m.openSourceAt(pkg, "", 1)
+}
+
+// openSourceAt opens the source viewer on the files of pkg, on line of the
+// file name or the first file
+func (m *Model) openSourceAt(pkg *domain.Package, name string, line int) {
+	m.sourcePkg = pkg
+	m.sourceFiles = m.loadSourceFiles(pkg)
+	m.sourceIndex = 0
+	for i, file := range m.sourceFiles {
+		if file.name == name {
+			m.sourceIndex = i
+		}
+	}
+	m.sourceCursor = max(line-1, 0)
+	m.sourceScroll = max(m.sourceCursor-sourceContext, 0)
+	m.showSource = true
+}
+
+// loadSourceFiles reads the non-test Go files of the package directory
+// with their coverage from the latest run covering the package
+func (m *Model) loadSourceFiles(pkg *domain.Package) []sourceFile {
+	coverage := m.fileCoverage(pkg.ID)
+
+	// Streamed input has no package directories; show the covered files
+	// by name only
+	names := make([]string, 0, len(coverage))
+	if pkg.Path != "" {
+		entries, _ := os.ReadDir(pkg.Path)
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for name := range coverage {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	files := make([]sourceFile, len(names))
+	for i, name := range names {
+		files[i] = sourceFile{name: name, coverage: coverage[name]}
+		if pkg.Path == "" {
+			files[i].err = os.ErrNotExist
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(pkg.Path, name))
+		files[i].err = err
+		files[i].lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	return files
+}
+
+// fileCoverage returns the latest coverage of the files of pkg by name
+func (m *Model) fileCoverage(pkg domain.PkgID) map[string]*domain.FileCoverage {
+	coverage := make(map[string]*domain.FileCoverage)
+	if cov, ok := m.coverage[string(pkg)]; ok {
+		for i := range cov.Files {
+			coverage[cov.Files[i].Name()] = &cov.Files[i]
+		}
+	}
+	return coverage
+}
+
+// refreshSourceCoverage shows the coverage of a run that completed while
+// the source viewer is open
+func (m *Model) refreshSourceCoverage() {
+	if !m.showSource {
+		return
+	}
+	coverage := m.fileCoverage(m.sourcePkg.ID)
+	for i := range m.sourceFiles {
+		if cov, ok := coverage[m.sourceFiles[i].name]; ok {
+			m.sourceFiles[i].coverage = cov
+		}
+	}
+}
+
+// handleSourceKey handles keys while the source viewer is open
+func (m *Model) handleSourceKey(msg tea.KeyMsg) tea.Cmd {
+	if len(m.sourceFiles) == 0 {
+		m.showSource = false
+		return nil
+	}
+	file := m.sourceFiles[m.sourceIndex]
+	last := max(len(file.lines)-1, 0)
+
+	switch {
+	case key.Matches(msg, m.keys.Down):
+		m.sourceCursor = min(m.sourceCursor+1, last)
+	case key.Matches(msg, m.keys.Up):
+		m.sourceCursor = max(m.sourceCursor-1, 0)
+	case msg.String() == "ctrl+d":
+		m.sourceCursor = min(m.sourceCursor+10, last)
+	case msg.String() == "ctrl+u":
+		m.sourceCursor = max(m.sourceCursor-10, 0)
+	case msg.String() == "g":
+		m.sourceCursor = 0
+	case msg.String() == "G":
+		m.sourceCursor = last
+	case key.Matches(msg, m.keys.NextPane):
+		m.sourceIndex = (m.sourceIndex + 1) % len(m.sourceFiles)
+		m.sourceCursor, m.sourceScroll = 0, 0
+	case key.Matches(msg, m.keys.PrevPane):
+		m.sourceIndex = (m.sourceIndex + len(m.sourceFiles) - 1) % len(m.sourceFiles)
+		m.sourceCursor, m.sourceScroll = 0, 0
+	case msg.String() == "n":
+		if line, ok := nextMissedLine(file, m.sourceCursor+1, 1); ok {
+			m.sourceCursor = line - 1
+			m.sourceScroll = max(m.sourceCursor-sourceContext, 0)
+		}
+	case msg.String() == "N":
+		if line, ok := nextMissedLine(file, m.sourceCursor+1, -1); ok {
+			m.sourceCursor = line - 1
+			m.sourceScroll = max(m.sourceCursor-sourceContext, 0)
+		}
+	case msg.String() == "i":
+		return m.runCoverageIndex(m.indexTests(m.sourcePkg.ID))
+	case key.Matches(msg, m.keys.Enter):
+		return m.runCoveringTests()
+	case key.Matches(msg, m.keys.ViewSource), key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+		m.showSource = false
+	}
+	return nil
+}
+
+// nextMissedLine finds the start line of the first uncovered block after
+// line (dir 1) or the last one before it (dir -1)
+func nextMissedLine(file sourceFile, line, dir int) (int, bool) {
+	if file.coverage == nil {
+		return 0, false
+	}
+
+	found, ok := 0, false
+	for _, block := range file.coverage.Blocks {
+		if block.Count > 0 {
+			continue
+		}
+		switch {
+		case dir > 0 && block.StartLine > line:
+			return block.StartLine, true
+		case dir < 0 && block.StartLine < line:
+			found, ok = block.StartLine, true
+		}
+	}
+	return found, ok
+}
+
+// renderSource renders the source viewer: a tab per file and the selected
+// file with covered and uncovered code highlighted
+func (m *Model) renderSource(width, height int) string {
+	muted := lipgloss.NewStyle().Foreground(mutedColor)
+	hit := lipgloss.NewStyle().Foreground(successColor)
+	missed := lipgloss.NewStyle().Foreground(failureColor).Bold(true)
+
+	// The box border and padding take 4 columns
+	inner := max(width-4, 20)
+
+	title := "Source of " + m.sourcePkg.Name
+	if len(m.sourceFiles) == 0 {
+		return placeBox(width, height, []string{
+			titleStyle.Render(title),
+			"",
+			muted.Render("No Go source files in " + string(m.sourcePkg.ID) + "."),
+			"",
+			muted.Render("esc:Close"),
+		})
+	}
+
+	tabs := make([]string, len(m.sourceFiles))
+	for i, file := range m.sourceFiles {
+		tab := file.name
+		if file.coverage != nil {
+			tab += " " + formatPercent(file.coverage.CoverageCounts)
+		}
+		if i == m.sourceIndex {
+			tab = statusRunningStyle.Render("[" + tab + "]")
+		} else {
+			tab = muted.Render(" " + tab + " ")
+		}
+		tabs[i] = tab
+	}
+
+	file := m.sourceFiles[m.sourceIndex]
+	summary := "not covered by any run"
+	if file.coverage != nil {
+		summary = formatPercent(file.coverage.CoverageCounts) + " of " +
+			strconv.Itoa(file.coverage.Statements) + " statements covered"
+	}
+	lines := []string{
+		titleStyle.Render(title) + muted.Render(summary),
+		truncate(strings.Join(tabs, " "), inner),
+		"",
+	}
+
+	// Title, tabs, blank line, the covering tests, hints and their blank
+	// lines
+	visible := max(height-2-8, 1)
+	if m.sourceCursor < m.sourceScroll {
+		m.sourceScroll = m.sourceCursor
+	} else if m.sourceCursor >= m.sourceScroll+visible {
+		m.sourceScroll = m.sourceCursor - visible + 1
+	}
+	if file.err != nil {
+		lines = append(lines, muted.Render("Cannot read "+file.name+": "+file.err.Error()))
+	} else {
+		gutter := len(strconv.Itoa(len(file.lines)))
+		end := min(m.sourceScroll+visible, len(file.lines))
+		for n := m.sourceScroll; n < end; n++ {
+			text := file.lines[n]
+			var states []domain.CoverState
+			if file.coverage != nil {
+				states = file.coverage.LineCoverage(n+1, len(text))
+			}
+
+			number := strings.Repeat(" ", gutter-len(strconv.Itoa(n+1))) + strconv.Itoa(n+1)
+			if n == m.sourceCursor {
+				number = statusRunningStyle.Render(number)
+			} else {
+				number = muted.Render(number)
+			}
+			code := highlightLine(text, states, inner-gutter-3, hit, missed)
+			lines = append(lines, number+" "+lineMarker(states, hit, missed)+" "+code)
+		}
+	}
+
+	lines = append(lines, "", truncate(m.renderCoveringTests(muted), inner))
+
+	hints := []string{
+		"h/l:File",
+		"j/k:Line",
+		"n/N:Next/Prev uncovered",
+		"i:Index tests",
+		"enter:Run covering tests",
+		"esc:Close",
+	}
+	lines = append(lines, "", truncate(muted.Render(strings.Join(hints, " | ")), inner))
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 1).
+		Width(width - 2).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Top, box)
+}
+
+// renderCoveringTests lists the indexed tests executing the cursor line
+func (m *Model) renderCoveringTests(muted lipgloss.Style) string {
+	line := "Line " + strconv.Itoa(m.sourceCursor+1) + ": "
+	switch {
+	case m.indexing:
+		return muted.Render(line + "indexing the coverage of each test...")
+	case !m.packageIndexed(m.sourcePkg.ID):
+		return muted.Render(line + "press i to learn which tests cover each line")
+	}
+
+	tests := m.coveringTests()
+	if len(tests) == 0 {
+		return muted.Render(line + "not executed by any indexed test")
+	}
+	names := make([]string, len(tests))
+	for i, test := range tests {
+		names[i] = test.Name
+	}
+	return muted.Render(line+"covered by ") + strings.Join(names, ", ")
+}
+
+// lineMarker summarizes the coverage of a line in the gutter, so it reads
+// without colors: + covered, - uncovered, ~ partly covered
+func lineMarker(states []domain.CoverState, hit, missed lipgloss.Style) string {
+	var hasHit, hasMissed bool
+	for _, state := range states {
+		hasHit = hasHit || state == domain.CoverHit
+		hasMissed = hasMissed || state == domain.CoverMissed
+	}
+	switch {
+	case hasHit && hasMissed:
+		return missed.Render("~")
+	case hasMissed:
+		return missed.Render("-")
+	case hasHit:
+		return hit.Render("+")
+	}
+	return " "
+}
+
+// highlightLine styles the runs of bytes with the same coverage state,
+// expanding tabs and cutting the line to width cells
+func highlightLine(text string, states []domain.CoverState, width int, hit, missed lipgloss.Style) string {
+	var out strings.Builder
+	var run strings.Builder
+	current := domain.CoverNone
+	cells := 0
+
+	flush := func() {
+		switch current {
+		case domain.CoverHit:
+			out.WriteString(hit.Render(run.String()))
+		case domain.CoverMissed:
+			out.WriteString(missed.Render(run.String()))
+		default:
+			out.WriteString(run.String())
+		}
+		run.Reset()
+	}
+
+	for i, r := range text {
+		state := domain.CoverNone
+		if i < len(states) {
+			state = states[i]
+		}
+		if state != current {
+			flush()
+			current = state
+		}
+
+		s := string(r)
+		if r == '\t' {
+			s = "    "
+		}
+		if cells+lipgloss.Width(s) > width {
+			run.WriteString("…")
+			break
+		}
+		cells += lipgloss.Width(s)
+		run.WriteString(s)
+	}
+	flush()
+	return out.String()
+}
@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+const sourceA = `package sv
+
+func Covered() int {
+	return 1
+}
+
+func Missed() int {
+	return 2
+}
+
+func Partly(b bool) int {
+	if b {
+		return 3
+	}
+	return 4
+}
+`
+
+// coverageA covers Covered and half of Partly
+var coverageA = domain.FileCoverage{
+	File:           "example.com/sv/a.go",
+	CoverageCounts: domain.CoverageCounts{Statements: 4, Covered: 2},
+	Blocks: []domain.CoverBlock{
+		{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 2, Statements: 1, Count: 1},
+		{StartLine: 7, StartCol: 19, EndLine: 9, EndCol: 2, Statements: 1, Count: 0},
+		{StartLine: 11, StartCol: 25, EndLine: 12, EndCol: 7, Statements: 1, Count: 1},
+		{StartLine: 12, StartCol: 7, EndLine: 14, EndCol: 3, Statements: 1, Count: 0},
+	},
+}
+
+func TestSourceViewer(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.go":      sourceA,
+		"b.go":      "package sv\n\nconst B = 1\n",
+		"a_test.go": "package sv\n",
+		"README.md": "sv\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg := &domain.Package{ID: "example.com/sv", Name: "sv", Path: dir}
+	m := newModel(t, Config{})
+	m.coverage["example.com/sv"] = domain.PackageCoverage{Pkg: "example.com/sv", Files: []domain.FileCoverage{coverageA}}
+	m.Update(packagesLoadedMsg{packages: []*domain.Package{pkg}})
+
+	press(m, "v")
+	if !m.showSource || m.sourcePkg != pkg {
+		t.Fatalf("source viewer open %v on %v, want open on the package under the cursor", m.showSource, m.sourcePkg)
+	}
+	var names []string
+	for _, file := range m.sourceFiles {
+		names = append(names, file.name)
+	}
+	if strings.Join(names, " ") != "a.go b.go" {
+		t.Fatalf("files = %q, want the non-test Go files", names)
+	}
+
+	view := m.renderSource(100, 40)
+	for _, want := range []string{
+		"a.go 50.0%",
+		"50.0% of 4 statements covered",
+		" 3 + func Covered() int {",
+		" 7 - func Missed() int {",
+		"12 ~     if b {",
+		"press i to learn which tests cover each line",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	// n and N jump between the uncovered blocks
+	for _, step := range []struct {
+		key  string
+		line int
+	}{{"n", 7}, {"n", 12}, {"n", 12}, {"N", 7}, {"N", 7}, {"G", 16}, {"g", 1}, {"j", 2}} {
+		press(m, step.key)
+		if m.sourceCursor+1 != step.line {
+			t.Errorf("%s moved to line %d, want %d", step.key, m.sourceCursor+1, step.line)
+		}
+	}
+
+	// l and h switch files, wrapping around
+	press(m, "l")
+	if m.sourceIndex != 1 || m.sourceCursor != 0 {
+		t.Errorf("l showed file %d line %d, want b.go from the top", m.sourceIndex, m.sourceCursor+1)
+	}
+	if view := m.renderSource(100, 40); !strings.Contains(view, "not covered by any run") {
+		t.Errorf("uncovered file shown as covered:\n%s", view)
+	}
+	press(m, "n")
+	if m.sourceCursor != 0 {
+		t.Errorf("n moved to line %d in a file without coverage", m.sourceCursor+1)
+	}
+	press(m, "h", "h")
+	if m.sourceIndex != 1 {
+		t.Errorf("h h showed file %d, want b.go again", m.sourceIndex)
+	}
+
+	// A run completing while the viewer is open updates its coverage
+	coverageB := domain.FileCoverage{File: "example.com/sv/b.go"}
+	m.coverage["example.com/sv"] = domain.PackageCoverage{Pkg: "example.com/sv", Files: []domain.FileCoverage{coverageA, coverageB}}
+	m.refreshSourceCoverage()
+	if m.sourceFiles[1].coverage == nil {
+		t.Error("coverage of b.go not refreshed")
+	}
+
+	press(m, "esc")
+	if m.showSource {
+		t.Error("esc did not close the source viewer")
+	}
+}
+
+// TestSourceViewerStreamed checks packages of recorded input, which have no
+// directory, list their covered files
+func TestSourceViewerStreamed(t *testing.T) {
+	m := newModel(t, Config{})
+	m.coverage["example.com/sv"] = domain.PackageCoverage{Pkg: "example.com/sv", Files: []domain.FileCoverage{coverageA}}
+	m.openSourceAt(&domain.Package{ID: "example.com/sv", Name: "sv"}, "a.go", 7)
+
+	if len(m.sourceFiles) != 1 || m.sourceFiles[0].name != "a.go" || m.sourceCursor != 6 {
+		t.Fatalf("files %+v on line %d, want a.go on line 7", m.sourceFiles, m.sourceCursor+1)
+	}
+	if view := m.renderSource(100, 40); !strings.Contains(view, "Cannot read a.go") {
+		t.Errorf("view lacks the missing file:\n%s", view)
+	}
+	// The blocks are still found without the lines
+	press(m, "n")
+	if m.sourceCursor != 11 {
+		t.Errorf("n moved to line %d, want 12", m.sourceCursor+1)
+	}
+}
@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/config"
+)
+
+// applyTheme overrides the default colors with the configured ones and
+// rebuilds the styles. Empty theme fields keep their defaults.
+func applyTheme(theme config.Theme) {
+	setColor(&primaryColor, theme.Primary)
+	setColor(&accentColor, theme.Accent)
+	setColor(&successColor, theme.Success)
+	setColor(&failureColor, theme.Failure)
+	setColor(&warningColor, theme.Warning)
+	setColor(&mutedColor, theme.Muted)
+	setColor(&focusedBg, theme.FocusedBg)
+
+	buildStyles()
+}
+
+func setColor(dst *lipgloss.Color, value string) {
+	if value != "" {
+		*dst = lipgloss.Color(value)
+	}
+}
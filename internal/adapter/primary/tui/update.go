@@ -2,6 +2,8 @@ package tui
 
 import (
 	"context"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -9,8 +11,10 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
 	"github.com/YuminosukeSato/lazygotest/internal/domain"
 	"github.com/YuminosukeSato/lazygotest/internal/shared/eventbus"
+	"github.com/YuminosukeSato/lazygotest/internal/usecase"
 	"github.com/YuminosukeSato/lazygotest/pkg/logger"
 )
 
@@ -23,6 +27,12 @@ type testEventMsg struct {
 	event domain.TestEvent
 }
 
+// busEventMsg is an event published on the bus under topic
+type busEventMsg struct {
+	topic string
+	event interface{}
+}
+
 type errorMsg struct {
 	err error
 }
@@ -30,7 +40,7 @@ type errorMsg struct {
 // loadPackages loads the list of packages
 func (m *Model) loadPackages() tea.Cmd {
 	return func() tea.Msg {
-		packages, err := m.listPkgsUC.Execute(m.ctx)
+		packages, err := m.listPkgsUC.Execute(m.ctx, m.patterns)
 		if err != nil {
 			return errorMsg{err: err}
 		}
@@ -38,13 +48,45 @@ func (m *Model) loadPackages() tea.Cmd {
 	}
 }
 
+// syncRunOptions pushes the current flag toggles into the run defaults
+func (m *Model) syncRunOptions() {
+	opts := m.baseRunOpts
+	opts.Packages = m.patterns
+	opts.Race = m.raceDetection
+	opts.Cover = m.coverageEnabled
+	opts.Verbose = true
+	opts.Env, opts.Unsetenv = m.effectiveEnv()
+	opts.Dir = m.dir
+	if opts.Fuzztime == "" {
+		opts.Fuzztime = defaultFuzztime
+	}
+	m.runTestsUC.SetDefaults(opts)
+}
+
+// startRun reports whether a new run may start and marks it as running
+func (m *Model) startRun() bool {
+	if m.isRunning {
+		return false
+	}
+	if m.runLocked {
+		m.detailsContent = []string{
+			"Re-running is not available for " + m.source + " input.",
+			"Set rerun_command in .lazygotest.yaml to enable it.",
+		}
+		return false
+	}
+
+	m.isRunning = true
+	m.runLocked = m.singleRun
+	return true
+}
+
 // runAllTests runs all tests
 func (m *Model) runAllTests() tea.Cmd {
-	if m.isRunning {
+	if !m.startRun() {
 		return nil
 	}
 
-	m.isRunning = true
 	m.detailsContent = []string{"Running all tests..."}
 
 	return func() tea.Msg {
@@ -58,11 +100,10 @@ func (m *Model) runAllTests() tea.Cmd {
 
 // runSelectedPackage runs tests for the selected package
 func (m *Model) runSelectedPackage() tea.Cmd {
-	if m.selectedPackage == nil || m.isRunning {
+	if m.selectedPackage == nil || !m.startRun() {
 		return nil
 	}
 
-	m.isRunning = true
 	m.detailsContent = []string{"Running tests in " + m.selectedPackage.Name + "..."}
 
 	return func() tea.Msg {
@@ -76,15 +117,22 @@ func (m *Model) runSelectedPackage() tea.Cmd {
 
 // rerunTest reruns the selected test
 func (m *Model) rerunTest() tea.Cmd {
-	if m.selectedTest == nil || m.isRunning {
+	if m.selectedTest == nil {
 		return nil
 	}
+	return m.runTest(m.selectedTest.ID)
+}
 
-	m.isRunning = true
-	m.detailsContent = []string{"Running " + m.selectedTest.ID.Name + "..."}
+// runTest runs a single test, benchmark or fuzz corpus entry
+func (m *Model) runTest(id domain.TestID) tea.Cmd {
+	if !m.startRun() {
+		return nil
+	}
+
+	m.detailsContent = []string{"Running " + id.Name + "..."}
 
 	return func() tea.Msg {
-		err := m.runTestsUC.ExecuteTest(m.ctx, m.selectedTest.ID)
+		err := m.runTestsUC.ExecuteTest(m.ctx, id)
 		if err != nil {
 			return errorMsg{err: err}
 		}
@@ -92,6 +140,17 @@ func (m *Model) rerunTest() tea.Cmd {
 	}
 }
 
+// cancelRun stops the running tests: their processes get SIGQUIT (SIGINT
+// when fuzzing), which makes test binaries dump their goroutines, and are
+// killed if they are still running runner.QuitGrace later
+func (m *Model) cancelRun() {
+	if m.cancelling || !m.runTestsUC.Cancel() {
+		return
+	}
+	m.cancelling = true
+	m.appendDetail("Cancelling: signalled the test processes, killing them after " + runner.QuitGrace.String() + "...")
+}
+
 // handleEnter handles the enter key based on focused pane
 func (m *Model) handleEnter() tea.Cmd {
 	switch m.focusedPane {
@@ -110,37 +169,111 @@ func (m *Model) handleEnter() tea.Cmd {
 	return nil
 }
 
-// subscribeToEvents sets up event handlers
+// subscribeToEvents forwards the events published on the bus into the
+// program. The bus calls handlers on its own goroutine, so the model only
+// changes in Update, which handleBusEvent does for each event.
 func (m *Model) subscribeToEvents() {
-	// Subscribe to test events
 	m.eventBus.Subscribe(eventbus.TopicTestEvent, func(ctx context.Context, event interface{}) {
 		if e, ok := event.(domain.TestEvent); ok {
-			m.handleTestEventAsync(e)
+			m.send(testEventMsg{event: e})
 		}
 	})
 
-	// Subscribe to test completion
-	m.eventBus.Subscribe(eventbus.TopicTestCompleted, func(ctx context.Context, event interface{}) {
-		if summary, ok := event.(*domain.TestSummary); ok {
-			m.summary = summary
-			m.isRunning = false
-			logger.Info("Tests completed", "summary", summary)
+	for _, topic := range []string{
+		eventbus.TopicTestStarted,
+		eventbus.TopicTestCompleted,
+		eventbus.TopicTestStalled,
+		eventbus.TopicBenchmarkResult,
+		eventbus.TopicCoverageReady,
+		eventbus.TopicCoverageIndex,
+		eventbus.TopicFuzzProgress,
+		eventbus.TopicFuzzCrasher,
+		eventbus.TopicDataRace,
+		eventbus.TopicError,
+	} {
+		m.eventBus.Subscribe(topic, func(ctx context.Context, event interface{}) {
+			m.send(busEventMsg{topic: topic, event: event})
+		})
+	}
+}
+
+// SetProgram sets the program running the model, which the events of runs
+// are sent to. Without one they are dropped.
+func (m *Model) SetProgram(p *tea.Program) {
+	m.program.Store(p)
+}
+
+// send sends a message to the program from another goroutine
+func (m *Model) send(msg tea.Msg) {
+	if p := m.program.Load(); p != nil {
+		p.Send(msg)
+	}
+}
+
+// handleBusEvent applies an event published on the bus to the model
+func (m *Model) handleBusEvent(msg busEventMsg) {
+	switch event := msg.event.(type) {
+	case *usecase.TestStartedEvent:
+		// Record the environment with the output of each run, and let its
+		// benchmark results replace the previous ones
+		m.benchStale = true
+		m.races = domain.RaceLog{}
+		if env := event.Options.EnvOverrides(); len(env) > 0 {
+			m.appendDetail("Env: " + strings.Join(env, " "))
 		}
-	})
 
-	// Subscribe to errors
-	m.eventBus.Subscribe(eventbus.TopicError, func(ctx context.Context, event interface{}) {
-		if err, ok := event.(error); ok {
-			logger.Error("Event bus error", "error", err)
+	case *domain.TestSummary:
+		m.summary = event
+		m.isRunning = false
+		m.cancelling = false
+		m.fuzzing = false
+		m.indexing = false
+		m.recordBenchmarkRun(event)
+		m.addBuildErrors(event)
+		if event.Cancelled {
+			for _, id := range event.Unfinished {
+				if test, ok := m.testResults[id]; ok {
+					test.Cancel()
+				}
+			}
+			m.updateTestList()
 		}
-	})
-}
+		logger.Info("Tests completed", "summary", event)
+
+	case domain.TestStall:
+		// Flag the tests the run reports stalled
+		m.addStall(event)
+
+	case domain.BenchmarkResult:
+		// Collect benchmark results for the benchmarks view
+		m.addBenchmarkResult(event)
+
+	case *domain.Coverage:
+		// Show the statement coverage of runs with -cover
+		m.addCoverage(event)
+
+	case *domain.CoverageIndex:
+		// Learn which tests execute which lines from indexing runs
+		m.addCoverageIndex(event)
+
+	case domain.FuzzProgress:
+		// Follow the progress of fuzzing runs and surface their failing inputs
+		if event.ID.Name != "" {
+			m.fuzzTarget = event.ID
+		}
+		m.fuzzProgress = &event
+
+	case domain.FuzzCrasher:
+		m.addCrasher(event)
+
+	case domain.DataRace:
+		// Collect the data races of runs with -race for the race panel
+		m.addRace(event)
 
-// handleTestEventAsync processes test events asynchronously
-func (m *Model) handleTestEventAsync(event domain.TestEvent) {
-	// This would normally send a message through the Bubble Tea program
-	// For now, we'll update the model directly (in a real app, use tea.Cmd)
-	m.handleTestEvent(event)
+	case error:
+		logger.Error("Event bus error", "error", event)
+		m.appendDetail("Error: " + event.Error())
+	}
 }
 
 // handleTestEvent processes a test event
@@ -161,34 +294,53 @@ func (m *Model) handleTestEvent(event domain.TestEvent) {
 				Logs: []string{},
 			}
 			m.testResults[testID] = test
+			m.ensurePackage(domain.PkgID(event.Package))
 		}
 
-		switch event.Action {
-		case "run":
-			test.Status = domain.StatusRunning
-		case "pass":
-			test.Status = domain.StatusPassed
-		case "fail":
-			test.Status = domain.StatusFailed
-			if test.LastFail == nil {
-				test.LastFail = &domain.FailInfo{}
-			}
-			test.LastFail.FullLog = strings.Join(test.Logs, "\n")
-		case "skip":
-			test.Status = domain.StatusSkipped
-		case "output":
-			test.Logs = append(test.Logs, event.Output)
+		test.Apply(event)
+		if event.Action == "output" {
 			m.appendDetail(event.Output)
 		}
+		if event.Action == "fail" && test.Status == domain.StatusPanicked {
+			m.addPanic(test)
+		}
 	} else if event.Output != "" {
 		// Package-level output
 		m.appendDetail(event.Output)
+	} else if event.Package != "" && (event.Action == "pass" || event.Action == "fail") {
+		m.ensurePackage(domain.PkgID(event.Package))
+		m.openPendingDump(event.Package)
+	}
+
+	if pkg := m.findPackage(domain.PkgID(event.Package)); pkg != nil {
+		status := pkg.Status
+		pkg.Apply(event)
+		if pkg.Status != status {
+			m.updatePackageList()
+		}
 	}
 
 	// Update UI
 	m.updateTestList()
 }
 
+// ensurePackage adds a package first seen in the event stream, so streamed
+// input builds its package list as it arrives
+func (m *Model) ensurePackage(id domain.PkgID) {
+	for _, pkg := range m.packages {
+		if pkg.ID == id {
+			return
+		}
+	}
+
+	m.packages = append(m.packages, &domain.Package{
+		ID:    id,
+		Name:  path.Base(string(id)),
+		Tests: []domain.TestCase{},
+	})
+	m.updatePackageList()
+}
+
 // appendDetail adds a line to the details pane
 func (m *Model) appendDetail(line string) {
 	const maxLines = 1000
@@ -219,10 +371,21 @@ func (m *Model) updateSizes() {
 
 // List items for packages and tests
 type packageItem struct {
-	pkg *domain.Package
+	pkg      *domain.Package
+	coverage *domain.PackageCoverage
+}
+
+func (i packageItem) Title() string {
+	title := i.pkg.Name
+	if i.coverage != nil {
+		title += "  " + formatPercent(i.coverage.CoverageCounts)
+	}
+	if i.pkg.Status == domain.PackageStatusBuildFailed {
+		title += "  " + statusFailStyle.Render("build failed")
+	}
+	return title
 }
 
-func (i packageItem) Title() string       { return i.pkg.Name }
 func (i packageItem) Description() string { return string(i.pkg.ID) }
 func (i packageItem) FilterValue() string { return i.pkg.Name }
 
@@ -258,6 +421,20 @@ func (i testItem) Title() string {
 		// Orange background for running tests
 		statusStyle = statusStyle.Background(lipgloss.Color("#4A3800")).
 			Foreground(lipgloss.Color("#FFFFFF"))
+	case domain.StatusPanicked:
+		status = "‼"
+		// Brighter red background for tests that panicked
+		statusStyle = statusStyle.Background(lipgloss.Color("#8B1A1A")).
+			Foreground(lipgloss.Color("#FFFFFF"))
+	case domain.StatusStalled:
+		status = "⧗"
+		// Dark red background for tests that stopped printing
+		statusStyle = statusStyle.Background(lipgloss.Color("#5C2E00")).
+			Foreground(lipgloss.Color("#FFFFFF"))
+	case domain.StatusCancelled:
+		status = "⊘"
+		statusStyle = statusStyle.Background(lipgloss.Color("#3A3A3C")).
+			Foreground(lipgloss.Color("#FFFFFF"))
 	case domain.StatusSkipped:
 		status = "-"
 	default:
@@ -268,7 +445,8 @@ func (i testItem) Title() string {
 	fullText := checkbox + " " + status + " " + i.test.ID.Name
 
 	// Apply background color if test has been run
-	if i.test.Status == domain.StatusPassed || i.test.Status == domain.StatusFailed {
+	switch i.test.Status {
+	case domain.StatusPassed, domain.StatusFailed, domain.StatusPanicked, domain.StatusStalled, domain.StatusCancelled:
 		return statusStyle.Width(40).Render(fullText)
 	}
 
@@ -276,10 +454,18 @@ func (i testItem) Title() string {
 }
 
 func (i testItem) Description() string {
+	description := ""
 	if i.test.Duration > 0 {
-		return strconv.FormatFloat(i.test.Duration.Seconds(), 'f', 2, 64) + "s"
+		description = strconv.FormatFloat(i.test.Duration.Seconds(), 'f', 2, 64) + "s"
 	}
-	return ""
+	// The panic or first failure of a failed test summarizes why it failed
+	if i.test.Status.Failed() && i.test.LastFail != nil && i.test.LastFail.Error != "" {
+		if description != "" {
+			description += "  "
+		}
+		description += i.test.LastFail.Error
+	}
+	return description
 }
 
 func (i testItem) FilterValue() string { return i.test.ID.Name }
@@ -288,7 +474,11 @@ func (i testItem) FilterValue() string { return i.test.ID.Name }
 func (m *Model) updatePackageList() {
 	items := make([]list.Item, len(m.packages))
 	for i, pkg := range m.packages {
-		items[i] = packageItem{pkg: pkg}
+		item := packageItem{pkg: pkg}
+		if cov, ok := m.coverage[string(pkg.ID)]; ok {
+			item.coverage = &cov
+		}
+		items[i] = item
 	}
 	m.packageList.SetItems(items)
 }
@@ -308,7 +498,7 @@ func (m *Model) updateTestList() {
 			continue
 		}
 
-		if m.showFailedOnly && test.Status != domain.StatusFailed {
+		if m.showFailedOnly && !test.Status.Failed() {
 			continue
 		}
 
@@ -323,14 +513,20 @@ func (m *Model) updateTestList() {
 	m.testList.SetItems(items)
 }
 
-// updateTestsForPackage loads tests for a package
+// cursorTest returns the test under the cursor in the tests pane, nil if
+// the pane is empty
+func (m *Model) cursorTest() *domain.TestCase {
+	if item, ok := m.testList.SelectedItem().(testItem); ok {
+		return item.test
+	}
+	return nil
+}
+
+// updateTestsForPackage shows the tests of pkg seen so far; the run that
+// selecting the package starts reports the others
 func (m *Model) updateTestsForPackage(pkg *domain.Package) {
-	// In a real implementation, this would discover tests
-	// For now, we'll wait for test events to populate the list
 	m.testList.Title = "Tests in " + pkg.Name
-
-	// TODO: Call runner.ListTests to get available tests
-	// m.availableTests = runner.ListTests(ctx, pkg.ID)
+	m.updateTestList()
 }
 
 // toggleTestSelection toggles the selection state of the current test
@@ -363,19 +559,39 @@ func (m *Model) deselectAllTests() tea.Cmd {
 	return nil
 }
 
-// runSelectedTests runs all selected tests or current test if none selected
-func (m *Model) runSelectedTests() tea.Cmd {
-	if m.isRunning || m.selectedPackage == nil {
+// runFocusedTests runs the tests given with -focus at startup
+func (m *Model) runFocusedTests(ids []domain.TestID) tea.Cmd {
+	if !m.startRun() {
+		return nil
+	}
+
+	m.detailsContent = []string{"Running focused tests..."}
+
+	return func() tea.Msg {
+		err := m.runTestsUC.ExecuteMultipleTests(m.ctx, ids)
+		if err != nil {
+			return errorMsg{err: err}
+		}
 		return nil
 	}
+}
 
-	// Get selected test IDs
+// runSelectedTests runs all selected tests or current test if none selected.
+// The selection may span packages, which run as one run with one summary.
+func (m *Model) runSelectedTests() tea.Cmd {
+	// Get selected test IDs, in a stable order
 	var selectedIDs []domain.TestID
 	for testID, isSelected := range m.selectedTests {
-		if isSelected && domain.PkgID(testID.Pkg) == m.selectedPackage.ID {
+		if isSelected {
 			selectedIDs = append(selectedIDs, testID)
 		}
 	}
+	sort.Slice(selectedIDs, func(i, j int) bool {
+		if selectedIDs[i].Pkg != selectedIDs[j].Pkg {
+			return selectedIDs[i].Pkg < selectedIDs[j].Pkg
+		}
+		return selectedIDs[i].Name < selectedIDs[j].Name
+	})
 
 	// If no tests selected, run the current test
 	if len(selectedIDs) == 0 {
@@ -384,11 +600,10 @@ func (m *Model) runSelectedTests() tea.Cmd {
 		}
 	}
 
-	if len(selectedIDs) == 0 {
+	if len(selectedIDs) == 0 || !m.startRun() {
 		return nil
 	}
 
-	m.isRunning = true
 	m.detailsContent = []string{"Running selected tests..."}
 
 	return func() tea.Msg {
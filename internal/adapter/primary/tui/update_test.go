@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/pkgrepo"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/internal/shared/eventbus"
+	"github.com/YuminosukeSato/lazygotest/internal/testutil"
+)
+
+// newModel creates a model with cfg, running nothing and listing no
+// packages unless cfg says otherwise; it is cancelled when the test ends
+func newModel(t *testing.T, cfg Config) *Model {
+	t.Helper()
+	if cfg.Runner == nil {
+		cfg.Runner = &testutil.FakeRunner{}
+	}
+	if cfg.PackageRepo == nil {
+		cfg.PackageRepo = pkgrepo.NewStaticPackageRepo(nil)
+	}
+	m := New(cfg)
+	t.Cleanup(m.cancel)
+	return m
+}
+
+// press sends keys to the model through Update and returns the command of
+// the last one. "enter" and "esc" are those keys; other keys are typed, so
+// a key of several runes enters text.
+func press(m *Model, keys ...string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		_, cmd = m.Update(msg)
+	}
+	return cmd
+}
+
+func TestRunSelectedTestsAcrossPackages(t *testing.T) {
+	r := &testutil.FakeRunner{Tests: map[string][]string{
+		"example.com/a": {"TestA1", "TestA2"},
+		"example.com/b": {"TestB1", "TestB2"},
+	}}
+	m := New(Config{
+		Runner:      r,
+		PackageRepo: pkgrepo.NewStaticPackageRepo([]domain.PkgID{"example.com/a", "example.com/b"}),
+	})
+	defer m.cancel()
+
+	summaries := make(chan *domain.TestSummary, 4)
+	m.eventBus.Subscribe(eventbus.TopicTestCompleted, func(ctx context.Context, event interface{}) {
+		summaries <- event.(*domain.TestSummary)
+	})
+
+	m.selectedTests[domain.TestID{Pkg: "example.com/a", Name: "TestA2"}] = true
+	m.selectedTests[domain.TestID{Pkg: "example.com/b", Name: "TestB1"}] = true
+	m.selectedTests[domain.TestID{Pkg: "example.com/b", Name: "TestB2"}] = false
+
+	cmd := m.runSelectedTests()
+	if cmd == nil {
+		t.Fatal("runSelectedTests started no run")
+	}
+	if msg := cmd(); msg != nil {
+		t.Fatalf("run failed: %v", msg)
+	}
+
+	var summary *domain.TestSummary
+	select {
+	case summary = <-summaries:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no summary published")
+	}
+	select {
+	case extra := <-summaries:
+		t.Fatalf("second summary published: %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if summary.Passed != 2 || summary.TotalTests != 2 || summary.TotalPackages != 2 {
+		t.Errorf("summary has %d passed of %d tests in %d packages, want 2 of 2 in 2",
+			summary.Passed, summary.TotalTests, summary.TotalPackages)
+	}
+	want := map[string]string{"example.com/a": "^(TestA2)$", "example.com/b": "^(TestB1)$"}
+	runs := r.Runs()
+	if len(runs) != len(want) {
+		t.Fatalf("%d runs started, want %d", len(runs), len(want))
+	}
+	for _, opts := range runs {
+		if pattern := want[opts.Packages[0]]; opts.RunRegex != pattern {
+			t.Errorf("package %s ran -run %q, want %q", opts.Packages[0], opts.RunRegex, pattern)
+		}
+	}
+}
+
+func TestBusEventsReachUpdate(t *testing.T) {
+	m := New(Config{Runner: &testutil.FakeRunner{}, PackageRepo: pkgrepo.NewStaticPackageRepo(nil)})
+	defer m.cancel()
+	p := tea.NewProgram(m, tea.WithInput(nil), tea.WithOutput(io.Discard), tea.WithoutRenderer())
+	m.SetProgram(p)
+	ran := make(chan error)
+	go func() {
+		_, err := p.Run()
+		ran <- err
+	}()
+
+	// Handlers run in order, so the program quits once it has handled the
+	// messages the model's handlers sent
+	m.eventBus.Subscribe(eventbus.TopicTestCompleted, func(ctx context.Context, event interface{}) {
+		p.Quit()
+	})
+
+	summary := &domain.TestSummary{Passed: 1, TotalTests: 1}
+	m.eventBus.Publish(context.Background(), eventbus.TopicTestEvent, domain.TestEvent{
+		Action: "run", Package: "example.com/a", Test: "TestA",
+	})
+	m.eventBus.Publish(context.Background(), eventbus.TopicTestCompleted, summary)
+
+	select {
+	case err := <-ran:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("program did not handle the events")
+	}
+
+	if m.summary != summary {
+		t.Error("summary not applied to the model")
+	}
+	if _, ok := m.testResults[domain.TestID{Pkg: "example.com/a", Name: "TestA"}]; !ok {
+		t.Error("test event not applied to the model")
+	}
+}
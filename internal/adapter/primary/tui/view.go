@@ -2,6 +2,7 @@ package tui
 
 import (
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 
@@ -21,56 +22,73 @@ var (
 	// Background colors with different brightness levels
 	focusedBg = lipgloss.Color("#2C2C2E") // Medium gray for focused
 
-	// Styles
+	// Styles, built from the colors by buildStyles
+	titleStyle             lipgloss.Style
+	paneStyle              lipgloss.Style
+	focusedPaneStyle       lipgloss.Style
+	headerStyle            lipgloss.Style
+	footerStyle            lipgloss.Style
+	statusPassStyle        lipgloss.Style
+	statusFailStyle        lipgloss.Style
+	statusRunningStyle     lipgloss.Style
+	positionIndicatorStyle lipgloss.Style
+)
+
+func init() {
+	buildStyles()
+}
+
+// buildStyles (re)creates the styles from the current colors
+func buildStyles() {
 	titleStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true).
-			Padding(0, 1)
+		Foreground(primaryColor).
+		Bold(true).
+		Padding(0, 1)
 
 	paneStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(mutedColor).
-			BorderTop(true).
-			BorderBottom(true).
-			BorderLeft(true).
-			BorderRight(true)
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		BorderTop(true).
+		BorderBottom(true).
+		BorderLeft(true).
+		BorderRight(true)
 
 	focusedPaneStyle = lipgloss.NewStyle().
-				BorderStyle(lipgloss.DoubleBorder()).
-				BorderForeground(accentColor).
-				Bold(true).
-				Background(focusedBg).
-				BorderTop(true).
-				BorderBottom(true).
-				BorderLeft(true).
-				BorderRight(true)
+		BorderStyle(lipgloss.DoubleBorder()).
+		BorderForeground(accentColor).
+		Bold(true).
+		Background(focusedBg).
+		BorderTop(true).
+		BorderBottom(true).
+		BorderLeft(true).
+		BorderRight(true)
 
 	headerStyle = lipgloss.NewStyle().
-			Background(primaryColor).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Padding(0, 1).
-			Bold(true)
+		Background(primaryColor).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Padding(0, 1).
+		Bold(true)
 
 	footerStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			Padding(0, 1)
+		Foreground(mutedColor).
+		Padding(0, 1)
 
 	statusPassStyle = lipgloss.NewStyle().
-			Foreground(successColor).
-			Bold(true)
+		Foreground(successColor).
+		Bold(true)
 
 	statusFailStyle = lipgloss.NewStyle().
-			Foreground(failureColor).
-			Bold(true)
+		Foreground(failureColor).
+		Bold(true)
 
 	statusRunningStyle = lipgloss.NewStyle().
-				Foreground(warningColor).
-				Bold(true)
+		Foreground(warningColor).
+		Bold(true)
 
 	positionIndicatorStyle = lipgloss.NewStyle().
-				Foreground(mutedColor).
-				Italic(true)
-)
+		Foreground(mutedColor).
+		Italic(true)
+}
 
 // render renders the complete UI
 func (m *Model) render() string {
@@ -95,17 +113,34 @@ func (m *Model) renderHeader() string {
 	title := "lazygotest v0.1"
 
 	flags := []string{}
+	if m.source != "" {
+		flags = append(flags, "[source:"+m.source+"]")
+	}
+
+	if m.dir != "" && m.dir != m.rootDir {
+		flags = append(flags, "[dir:"+m.dirLabel(m.dir)+"]")
+	}
+
+	if m.profile != "" {
+		flags = append(flags, "[profile:"+m.profile+"]")
+	}
+
 	if m.raceDetection {
 		flags = append(flags, "[race:ON]")
 	} else {
 		flags = append(flags, "[race:OFF]")
 	}
 
-	if m.coverageEnabled {
+	if total, ok := m.totalCoverage(); ok && m.coverageEnabled {
+		flags = append(flags, "[cover:"+formatPercent(total)+"]")
+	} else if m.coverageEnabled {
 		flags = append(flags, "[cover:ON]")
 	} else {
 		flags = append(flags, "[cover:OFF]")
 	}
+	if m.diffCoverage != nil && len(m.diffCoverage.Files) > 0 {
+		flags = append(flags, "[diff:"+formatPercent(m.diffCoverage.Total())+"]")
+	}
 
 	if m.watchMode {
 		flags = append(flags, "[watch:ON]")
@@ -113,6 +148,42 @@ func (m *Model) renderHeader() string {
 		flags = append(flags, "[watch:OFF]")
 	}
 
+	if m.baseRunOpts.Short {
+		flags = append(flags, "[short]")
+	}
+
+	if m.baseRunOpts.Tags != "" {
+		flags = append(flags, "[tags:"+m.baseRunOpts.Tags+"]")
+	}
+
+	if m.baseRunOpts.Bench != "" {
+		flags = append(flags, "[bench:"+m.baseRunOpts.Bench+"]")
+	}
+
+	if m.fuzzing {
+		flags = append(flags, "[fuzz:"+m.fuzzTarget.Name+"]")
+	}
+
+	if n := m.stalledCount(); n > 0 {
+		flags = append(flags, statusFailStyle.Render("[stalled:"+intToString(n)+"]"))
+	}
+
+	if n := m.panickedCount(); n > 0 {
+		flags = append(flags, statusFailStyle.Render("[panicked:"+intToString(n)+"]"))
+	}
+
+	if n := len(m.races.Races); n > 0 {
+		flags = append(flags, statusFailStyle.Render("[races:"+intToString(n)+"]"))
+	}
+
+	if n := m.buildFailedCount(); n > 0 {
+		flags = append(flags, statusFailStyle.Render("[build failed:"+intToString(n)+"]"))
+	}
+
+	if env := m.envHeader(); env != "" {
+		flags = append(flags, "[env:"+env+"]")
+	}
+
 	if m.showFailedOnly {
 		flags = append(flags, "[failed-only]")
 	}
@@ -121,11 +192,16 @@ func (m *Model) renderHeader() string {
 
 	// Status summary
 	status := ""
-	if m.summary != nil {
+	if m.cancelling {
+		status = statusRunningStyle.Render("⟳ Cancelling...")
+	} else if m.summary != nil {
 		pass := statusPassStyle.Render("PASS " + intToString(m.summary.Passed))
 		fail := statusFailStyle.Render("FAIL " + intToString(m.summary.Failed))
 		skip := lipgloss.NewStyle().Foreground(mutedColor).Render("SKIP " + intToString(m.summary.Skipped))
 		status = pass + " | " + fail + " | " + skip
+		if m.summary.Cancelled {
+			status += " | " + statusRunningStyle.Render("CANCELLED")
+		}
 	} else if m.isRunning {
 		status = statusRunningStyle.Render("⟳ Running...")
 	}
@@ -146,7 +222,7 @@ func (m *Model) renderHeader() string {
 func (m *Model) renderFooter() string {
 	// Show different keys based on focused pane
 	commonKeys := []string{
-		"q:Quit",
+		hint(m.keys.Quit, "Quit"),
 		"h/l:←/→ Pane",
 	}
 
@@ -156,25 +232,40 @@ func (m *Model) renderFooter() string {
 		paneKeys = []string{
 			"j/k:↓/↑",
 			"gg/G:Top/Bot",
-			"Enter:Run",
+			hint(m.keys.Enter, "Run"),
 			"/:Search",
+			hint(m.keys.ViewSource, "Source"),
 		}
 	case TestsPane:
 		paneKeys = []string{
 			"j/k:↓/↑",
-			"Space:Toggle",
-			"a/A:All/None",
-			"Enter:Run",
+			hint(m.keys.ToggleSelect, "Toggle"),
+			m.keys.SelectAll.Help().Key + "/" + m.keys.DeselectAll.Help().Key + ":All/None",
+			hint(m.keys.Enter, "Run"),
+		}
+		if item, ok := m.testList.SelectedItem().(testItem); ok && domain.KindOf(item.test.ID.Name) == domain.KindFuzz && !strings.Contains(item.test.ID.Name, "/") {
+			paneKeys = append(paneKeys, hint(m.keys.Fuzz, "Fuzz"))
+		}
+		if test := m.cursorTest(); test != nil {
+			switch test.Status {
+			case domain.StatusRunning, domain.StatusStalled:
+				paneKeys = append(paneKeys, hint(m.keys.QuitTest, "SIGQUIT"))
+			case domain.StatusFailed:
+				paneKeys = append(paneKeys, hint(m.keys.Failures, "Failures"), hint(m.keys.Goroutines, "Goroutines"))
+			case domain.StatusPanicked:
+				paneKeys = append(paneKeys, hint(m.keys.Failures, "Panic"), hint(m.keys.Goroutines, "Goroutines"))
+			}
 		}
-		// Add selection count if tests are selected
+		// Add selection count if tests are selected; enter runs the
+		// selection of every package
 		selectedCount := 0
-		for testID, isSelected := range m.selectedTests {
-			if isSelected && m.selectedPackage != nil && domain.PkgID(testID.Pkg) == m.selectedPackage.ID {
+		for _, isSelected := range m.selectedTests {
+			if isSelected {
 				selectedCount++
 			}
 		}
 		if selectedCount > 0 {
-			paneKeys = append(paneKeys, "["+intToString(selectedCount)+" selected]")
+			paneKeys = append(paneKeys, hint(m.keys.IndexCover, "Index"), "["+intToString(selectedCount)+" selected]")
 		}
 	case DetailsPane:
 		paneKeys = []string{
@@ -184,12 +275,29 @@ func (m *Model) renderFooter() string {
 		}
 	}
 
-	actionKeys := []string{
-		"A:All",
-		"F:Failed",
-		"W:Watch",
-		"R:Race",
-		"C:Cover",
+	actionKeys := []string{}
+	if m.isRunning {
+		actionKeys = append(actionKeys, hint(m.keys.Cancel, "Cancel"))
+	}
+	actionKeys = append(actionKeys,
+		hint(m.keys.RunAll, "All"),
+		hint(m.keys.FailedOnly, "Failed"),
+		hint(m.keys.ToggleWatch, "Watch"),
+		hint(m.keys.ToggleRace, "Race"),
+		hint(m.keys.ToggleCover, "Cover"),
+	)
+	if len(m.profiles) > 0 {
+		actionKeys = append(actionKeys, hint(m.keys.PickProfile, "Profile"))
+	}
+	actionKeys = append(actionKeys, hint(m.keys.EditEnv, "Env"), hint(m.keys.Benchmarks, "Bench"))
+	if len(m.buildErrors) > 0 {
+		actionKeys = append(actionKeys, hint(m.keys.BuildErrors, "Build errors"))
+	}
+	if len(m.races.Races) > 0 {
+		actionKeys = append(actionKeys, hint(m.keys.Races, "Races"))
+	}
+	if m.source == "" {
+		actionKeys = append(actionKeys, hint(m.keys.SwitchDir, "Dir"))
 	}
 
 	allKeys := append(commonKeys, paneKeys...)
@@ -203,6 +311,40 @@ func (m *Model) renderContent() string {
 	paneWidth := m.width / 3
 	paneHeight := m.height - 4 // Account for header and footer
 
+	if m.pickingProfile {
+		return m.renderProfilePicker(m.width, paneHeight)
+	}
+	if m.editingEnv {
+		return m.renderEnvEditor(m.width, paneHeight)
+	}
+	if m.pickingDir {
+		return m.renderDirSwitcher(m.width, paneHeight)
+	}
+	if m.showBenchmarks {
+		return m.renderBenchmarks(m.width, paneHeight)
+	}
+	if m.showFuzz {
+		return m.renderFuzz(m.width, paneHeight)
+	}
+	if m.showSource {
+		return m.renderSource(m.width, paneHeight)
+	}
+	if m.showDiff {
+		return m.renderDiff(m.width, paneHeight)
+	}
+	if m.showDump {
+		return m.renderGoroutines(m.width, paneHeight)
+	}
+	if m.showBuild {
+		return m.renderBuildErrors(m.width, paneHeight)
+	}
+	if m.showFailures {
+		return m.renderFailures(m.width, paneHeight)
+	}
+	if m.showRaces {
+		return m.renderRaces(m.width, paneHeight)
+	}
+
 	// Render each pane
 	packagesPane := m.renderPackagesPane(paneWidth, paneHeight)
 	testsPane := m.renderTestsPane(paneWidth, paneHeight)
@@ -225,8 +367,12 @@ func (m *Model) renderPackagesPane(width, height int) string {
 		style = focusedPaneStyle
 	}
 
+	// Per-file coverage of the package under the cursor takes up to a
+	// third of the pane below the list
+	fileCoverage := m.renderFileCoverage(width-2, (height-4)/3)
+
 	// Update list size
-	m.packageList.SetSize(width-2, height-4) // Leave space for title and position
+	m.packageList.SetSize(width-2, height-4-len(fileCoverage)) // Leave space for title and position
 
 	// Build title with focus indicator
 	title := "Packages"
@@ -249,12 +395,11 @@ func (m *Model) renderPackagesPane(width, height int) string {
 	content := m.packageList.View()
 
 	// Combine all elements
-	fullContent := lipgloss.JoinVertical(
-		lipgloss.Top,
-		titleContent,
-		content,
-		position,
-	)
+	parts := []string{titleContent, content}
+	if len(fileCoverage) > 0 {
+		parts = append(parts, strings.Join(fileCoverage, "\n"))
+	}
+	fullContent := lipgloss.JoinVertical(lipgloss.Top, append(parts, position)...)
 
 	return style.Width(width).Height(height).Render(fullContent)
 }
@@ -347,10 +492,19 @@ func (m *Model) renderDetailsPane(width, height int) string {
 		switch m.selectedTest.Status {
 		case domain.StatusFailed:
 			title = statusFailStyle.Render("✗ ") + title
+		case domain.StatusPanicked:
+			title = statusFailStyle.Render("‼ ") + title
 		case domain.StatusPassed:
 			title = statusPassStyle.Render("✓ ") + title
 		case domain.StatusRunning:
 			title = statusRunningStyle.Render("⟳ ") + title
+		case domain.StatusStalled:
+			title = statusFailStyle.Render("⧗ ") + title
+			if stall, ok := m.stalls[m.selectedTest.ID]; ok {
+				title += " (no output for " + stall.Idle(time.Now()).Round(time.Second).String() + ")"
+			}
+		case domain.StatusCancelled:
+			title = lipgloss.NewStyle().Foreground(mutedColor).Render("⊘ ") + title
 		}
 	}
 	if isFocused {
@@ -0,0 +1,142 @@
+package benchstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// storedRun is the JSON form of a benchmark run
+type storedRun struct {
+	Label     string         `json:"label,omitempty"`
+	StartedAt time.Time      `json:"startedAt"`
+	Results   []storedResult `json:"results"`
+}
+
+// storedResult is the JSON form of a benchmark result
+type storedResult struct {
+	Package    string         `json:"package"`
+	Name       string         `json:"name"`
+	Procs      int            `json:"procs,omitempty"`
+	Iterations int64          `json:"iterations"`
+	Metrics    []storedMetric `json:"metrics"`
+}
+
+// storedMetric is the JSON form of a benchmark metric
+type storedMetric struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// Save writes the run to path as JSON
+func Save(path string, run domain.BenchmarkRun) error {
+	stored := storedRun{
+		Label:     run.Label,
+		StartedAt: run.StartedAt,
+		Results:   make([]storedResult, len(run.Results)),
+	}
+	for i, result := range run.Results {
+		metrics := make([]storedMetric, len(result.Metrics))
+		for j, metric := range result.Metrics {
+			metrics[j] = storedMetric{Value: metric.Value, Unit: metric.Unit}
+		}
+		stored.Results[i] = storedResult{
+			Package:    result.ID.Pkg,
+			Name:       result.ID.Name,
+			Procs:      result.Procs,
+			Iterations: result.Iterations,
+			Metrics:    metrics,
+		}
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode benchmark results")
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return errors.Wrapf(err, "failed to write benchmark results %s", path)
+	}
+	return nil
+}
+
+// Load reads a run saved by Save, or the plain text output of
+// go test -bench as benchstat reads it. The run is labeled with the file name.
+func Load(path string) (domain.BenchmarkRun, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return domain.BenchmarkRun{}, errors.Wrapf(err, "failed to read benchmark results %s", path)
+	}
+
+	var run domain.BenchmarkRun
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		run, err = decodeJSON(data)
+		if err != nil {
+			return domain.BenchmarkRun{}, errors.Wrapf(err, "failed to parse benchmark results %s", path)
+		}
+	} else {
+		run = parseText(data)
+	}
+
+	if len(run.Results) == 0 {
+		return domain.BenchmarkRun{}, errors.Newf("%s contains no benchmark results", path)
+	}
+	if run.Label == "" {
+		run.Label = filepath.Base(path)
+	}
+	if run.StartedAt.IsZero() {
+		if info, err := os.Stat(path); err == nil {
+			run.StartedAt = info.ModTime()
+		}
+	}
+	return run, nil
+}
+
+// decodeJSON decodes the format written by Save
+func decodeJSON(data []byte) (domain.BenchmarkRun, error) {
+	var stored storedRun
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return domain.BenchmarkRun{}, err
+	}
+
+	run := domain.BenchmarkRun{Label: stored.Label, StartedAt: stored.StartedAt}
+	for _, result := range stored.Results {
+		metrics := make([]domain.BenchmarkMetric, len(result.Metrics))
+		for i, metric := range result.Metrics {
+			metrics[i] = domain.BenchmarkMetric{Value: metric.Value, Unit: metric.Unit}
+		}
+		run.Results = append(run.Results, domain.BenchmarkResult{
+			ID:         domain.TestID{Pkg: result.Package, Name: result.Name},
+			Procs:      result.Procs,
+			Iterations: result.Iterations,
+			Metrics:    metrics,
+		})
+	}
+	return run, nil
+}
+
+// parseText parses go test -bench output, attributing results to the
+// package named by the preceding "pkg:" line
+func parseText(data []byte) domain.BenchmarkRun {
+	var run domain.BenchmarkRun
+	var names domain.BenchmarkNames
+	pkg := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(name)
+			continue
+		}
+		if result, ok := names.ParseLine(pkg, line); ok {
+			run.Results = append(run.Results, result)
+		}
+	}
+	return run
+}
@@ -0,0 +1,116 @@
+package benchstore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+func TestSaveLoad(t *testing.T) {
+	run := domain.BenchmarkRun{
+		Label:     "main",
+		StartedAt: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC),
+		Results: []domain.BenchmarkResult{
+			{
+				ID:         domain.TestID{Pkg: "example.com/bs/encode", Name: "BenchmarkEncode"},
+				Procs:      8,
+				Iterations: 1000000,
+				Metrics: []domain.BenchmarkMetric{
+					{Value: 1052.5, Unit: domain.UnitNsPerOp},
+					{Value: 256, Unit: domain.UnitBytesPerOp},
+					{Value: 3, Unit: domain.UnitAllocsPerOp},
+				},
+			},
+			{
+				// Without a GOMAXPROCS suffix, with a custom metric
+				ID:         domain.TestID{Pkg: "example.com/bs/decode", Name: "BenchmarkDecode/big"},
+				Iterations: 500,
+				Metrics:    []domain.BenchmarkMetric{{Value: 0.5, Unit: "hits/op"}},
+			},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "base.json")
+	if err := Save(path, run); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, run) {
+		t.Errorf("Load =\n%+v\nwant\n%+v", got, run)
+	}
+
+	// Without a label the run is named after the file, and dated by it
+	run.Label = ""
+	run.StartedAt = time.Time{}
+	if err := Save(path, run); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Label != "base.json" || got.StartedAt.IsZero() {
+		t.Errorf("Load = label %q started %v, want base.json and the file's time", got.Label, got.StartedAt)
+	}
+}
+
+func TestLoadText(t *testing.T) {
+	path := filepath.Join("testdata", "bench.txt")
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := domain.TestID{Pkg: "example.com/bs/encode", Name: "BenchmarkEncode"}
+	want := []domain.BenchmarkResult{
+		{ID: encode, Procs: 8, Iterations: 1000000, Metrics: []domain.BenchmarkMetric{
+			{Value: 1052, Unit: domain.UnitNsPerOp}, {Value: 256, Unit: domain.UnitBytesPerOp}, {Value: 3, Unit: domain.UnitAllocsPerOp},
+		}},
+		{ID: encode, Procs: 8, Iterations: 1000000, Metrics: []domain.BenchmarkMetric{
+			{Value: 1048, Unit: domain.UnitNsPerOp}, {Value: 256, Unit: domain.UnitBytesPerOp}, {Value: 3, Unit: domain.UnitAllocsPerOp},
+		}},
+		{
+			ID: domain.TestID{Pkg: "example.com/bs/encode", Name: "BenchmarkEncode/small"}, Procs: 8, Iterations: 5000000,
+			Metrics: []domain.BenchmarkMetric{{Value: 240.5, Unit: domain.UnitNsPerOp}, {Value: 12.3, Unit: domain.UnitMBPerSec}},
+		},
+		// The bare name and the --- BENCH log are not results
+		{
+			ID: domain.TestID{Pkg: "example.com/bs/decode", Name: "BenchmarkDecode"}, Procs: 8, Iterations: 500000,
+			Metrics: []domain.BenchmarkMetric{{Value: 2210, Unit: domain.UnitNsPerOp}, {Value: 0.5, Unit: "hits/op"}},
+		},
+	}
+	if !reflect.DeepEqual(got.Results, want) {
+		t.Errorf("results =\n%+v\nwant\n%+v", got.Results, want)
+	}
+	if got.Label != "bench.txt" {
+		t.Errorf("label = %q, want bench.txt", got.Label)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"empty.txt":   "",
+		"nobench.txt": "PASS\nok  \texample.com/bs\t0.002s\n",
+		"broken.json": `{"results": [`,
+		"none.json":   `{"label": "x", "results": []}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"empty.txt", "nobench.txt", "broken.json", "none.json", "missing.json"} {
+		if _, err := Load(filepath.Join(dir, name)); err == nil {
+			t.Errorf("Load(%s) succeeded", name)
+		}
+	}
+}
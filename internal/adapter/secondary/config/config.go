@@ -0,0 +1,437 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+	"github.com/YuminosukeSato/lazygotest/pkg/logger"
+)
+
+// ProjectFileName is the repo-level configuration file name
+const ProjectFileName = ".lazygotest.yaml"
+
+// Config is the lazygotest configuration loaded from YAML files
+type Config struct {
+	Run      RunConfig      `yaml:"run"`
+	Packages PackagesConfig `yaml:"packages"`
+	Editor   string         `yaml:"editor"`
+	// RerunCommand runs tests when the input is a stream (stdin), see runner.CommandRunner
+	RerunCommand string `yaml:"rerun_command"`
+	// DiffBase is the git ref whose changes coverage runs are checked against
+	DiffBase string `yaml:"diff_base"`
+	// StallThreshold is how long a running test may print nothing before
+	// it is flagged stalled; "0" disables the watch
+	StallThreshold string             `yaml:"stall_threshold"`
+	Keybindings    map[string]KeyList `yaml:"keybindings"`
+	Theme          Theme              `yaml:"theme"`
+	// Profiles are named run settings selected with -profile or from the TUI
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// RunConfig holds the default go test options. Pointer fields distinguish
+// "not set" from an explicit false or 0 so a project file can turn off a
+// user default; a 0 leaves the value to go test.
+type RunConfig struct {
+	Tags            string `yaml:"tags"`
+	Timeout         string `yaml:"timeout"`
+	Race            *bool  `yaml:"race"`
+	Cover           *bool  `yaml:"cover"`
+	Short           *bool  `yaml:"short"`
+	Parallel        *int   `yaml:"parallel"`
+	PackageParallel *int   `yaml:"package_parallel"`
+	// Bench is the -bench regexp; benchmarks only run when it is set
+	Bench     string `yaml:"bench"`
+	Benchtime string `yaml:"benchtime"`
+	Benchmem  *bool  `yaml:"benchmem"`
+	Count     *int   `yaml:"count"`
+	// Fuzztime bounds fuzzing runs; the TUI defaults it to 30s
+	Fuzztime string `yaml:"fuzztime"`
+	// Env holds extra environment variables for the go command; a null
+	// value removes an inherited variable
+	Env map[string]*string `yaml:"env"`
+}
+
+// Profile bundles run settings under a name. Its fields override the run
+// defaults, and Packages replaces packages.include.
+type Profile struct {
+	RunConfig `yaml:",inline"`
+	Packages  []string `yaml:"packages"`
+}
+
+// PackagesConfig scopes package discovery
+type PackagesConfig struct {
+	// Include are the default package patterns when none are given on the command line
+	Include []string `yaml:"include"`
+	// Exclude removes matching packages from discovery
+	Exclude []string `yaml:"exclude"`
+}
+
+// Theme overrides the TUI colors. Values are hex (#RRGGBB) or ANSI color numbers.
+type Theme struct {
+	Primary   string `yaml:"primary"`
+	Accent    string `yaml:"accent"`
+	Success   string `yaml:"success"`
+	Failure   string `yaml:"failure"`
+	Warning   string `yaml:"warning"`
+	Muted     string `yaml:"muted"`
+	FocusedBg string `yaml:"focused_bg"`
+}
+
+// KeyList is a list of keys bound to an action. It accepts either a single
+// key or a sequence of keys in YAML.
+type KeyList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (k *KeyList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*k = KeyList{node.Value}
+		return nil
+	}
+
+	var keys []string
+	if err := node.Decode(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// Load reads the user configuration and the project configuration found in
+// dir or its nearest parent, and returns them merged with the project
+// file taking precedence.
+func Load(dir string) (*Config, error) {
+	cfg := &Config{}
+
+	if path := UserConfigPath(); path != "" {
+		user, err := LoadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if user != nil {
+			cfg.Merge(user)
+		}
+	}
+
+	if path := FindProjectConfig(dir); path != "" {
+		project, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Merge(project)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadFile reads a single configuration file
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read config %s", path)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse config %s", path)
+	}
+
+	logger.Debug("Loaded config", "path", path)
+	return cfg, nil
+}
+
+// UserConfigPath returns the user-level configuration file path under
+// $XDG_CONFIG_HOME (or ~/.config when it is unset)
+func UserConfigPath() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "lazygotest", "config.yaml")
+}
+
+// FindProjectConfig looks for the project configuration file in dir and its
+// parents and returns its path, or "" when there is none
+func FindProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Merge overlays the values set in other onto c
+func (c *Config) Merge(other *Config) {
+	c.Run.merge(other.Run)
+
+	if len(other.Packages.Include) > 0 {
+		c.Packages.Include = other.Packages.Include
+	}
+	if len(other.Packages.Exclude) > 0 {
+		c.Packages.Exclude = other.Packages.Exclude
+	}
+
+	if other.Editor != "" {
+		c.Editor = other.Editor
+	}
+	if other.RerunCommand != "" {
+		c.RerunCommand = other.RerunCommand
+	}
+	if other.DiffBase != "" {
+		c.DiffBase = other.DiffBase
+	}
+	if other.StallThreshold != "" {
+		c.StallThreshold = other.StallThreshold
+	}
+
+	for action, keys := range other.Keybindings {
+		if c.Keybindings == nil {
+			c.Keybindings = make(map[string]KeyList)
+		}
+		c.Keybindings[action] = keys
+	}
+
+	mergeString(&c.Theme.Primary, other.Theme.Primary)
+	mergeString(&c.Theme.Accent, other.Theme.Accent)
+	mergeString(&c.Theme.Success, other.Theme.Success)
+	mergeString(&c.Theme.Failure, other.Theme.Failure)
+	mergeString(&c.Theme.Warning, other.Theme.Warning)
+	mergeString(&c.Theme.Muted, other.Theme.Muted)
+	mergeString(&c.Theme.FocusedBg, other.Theme.FocusedBg)
+
+	// Profiles are replaced as a whole so a project profile is self-contained
+	for name, profile := range other.Profiles {
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]Profile)
+		}
+		c.Profiles[name] = profile
+	}
+}
+
+// merge overlays the values set in other onto r
+func (r *RunConfig) merge(other RunConfig) {
+	mergeString(&r.Tags, other.Tags)
+	mergeString(&r.Timeout, other.Timeout)
+	if other.Race != nil {
+		r.Race = other.Race
+	}
+	if other.Cover != nil {
+		r.Cover = other.Cover
+	}
+	if other.Short != nil {
+		r.Short = other.Short
+	}
+	if other.Parallel != nil {
+		r.Parallel = other.Parallel
+	}
+	if other.PackageParallel != nil {
+		r.PackageParallel = other.PackageParallel
+	}
+	mergeString(&r.Bench, other.Bench)
+	mergeString(&r.Benchtime, other.Benchtime)
+	if other.Benchmem != nil {
+		r.Benchmem = other.Benchmem
+	}
+	if other.Count != nil {
+		r.Count = other.Count
+	}
+	mergeString(&r.Fuzztime, other.Fuzztime)
+
+	if len(other.Env) > 0 {
+		// Copy so that merging never writes into a map shared with another config
+		env := make(map[string]*string, len(r.Env)+len(other.Env))
+		for name, value := range r.Env {
+			env[name] = value
+		}
+		for name, value := range other.Env {
+			env[name] = value
+		}
+		r.Env = env
+	}
+}
+
+// ProfileNames returns the configured profile names in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile returns a copy of the configuration with the named profile
+// overlaid on the run defaults and package patterns
+func (c *Config) ApplyProfile(name string) (*Config, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return nil, errors.Invalid("profile", "unknown profile "+name+", no profiles are configured")
+		}
+		return nil, errors.Invalid("profile",
+			"unknown profile "+name+", expected one of "+strings.Join(c.ProfileNames(), ", "))
+	}
+
+	applied := *c
+	applied.Run.merge(profile.RunConfig)
+	if len(profile.Packages) > 0 {
+		applied.Packages.Include = profile.Packages
+	}
+	return &applied, nil
+}
+
+// EnvLists splits an environment map into sorted NAME=VALUE pairs to set
+// and the names to unset (nil values)
+func EnvLists(env map[string]*string) (set, unset []string) {
+	for name, value := range env {
+		if value == nil {
+			unset = append(unset, name)
+			continue
+		}
+		set = append(set, name+"="+*value)
+	}
+	sort.Strings(set)
+	sort.Strings(unset)
+	return set, unset
+}
+
+func mergeString(dst *string, src string) {
+	if src != "" {
+		*dst = src
+	}
+}
+
+var hexColorRegex = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Validate checks the configuration values
+func (c *Config) Validate() error {
+	if err := c.Run.validate("run"); err != nil {
+		return err
+	}
+	for name, profile := range c.Profiles {
+		if name == "" {
+			return errors.Invalid("profiles", "profile names must not be empty")
+		}
+		if err := profile.RunConfig.validate("profiles." + name); err != nil {
+			return err
+		}
+	}
+
+	if c.StallThreshold != "" {
+		if d, err := time.ParseDuration(c.StallThreshold); err != nil || d < 0 {
+			return errors.Invalid("stall_threshold", "expected a duration such as 30s, or 0 to disable")
+		}
+	}
+
+	colors := map[string]string{
+		"theme.primary":    c.Theme.Primary,
+		"theme.accent":     c.Theme.Accent,
+		"theme.success":    c.Theme.Success,
+		"theme.failure":    c.Theme.Failure,
+		"theme.warning":    c.Theme.Warning,
+		"theme.muted":      c.Theme.Muted,
+		"theme.focused_bg": c.Theme.FocusedBg,
+	}
+	for field, color := range colors {
+		if color != "" && !isColor(color) {
+			return errors.Invalid(field, "expected #RRGGBB or an ANSI color number")
+		}
+	}
+
+	return nil
+}
+
+// validate checks the run settings; prefix names their YAML section
+func (r RunConfig) validate(prefix string) error {
+	if r.Timeout != "" {
+		if _, err := time.ParseDuration(r.Timeout); err != nil {
+			return errors.Invalid(prefix+".timeout", "expected a duration such as 5m")
+		}
+	}
+	if r.Parallel != nil && *r.Parallel < 0 {
+		return errors.Invalid(prefix+".parallel", "must not be negative")
+	}
+	if r.PackageParallel != nil && *r.PackageParallel < 0 {
+		return errors.Invalid(prefix+".package_parallel", "must not be negative")
+	}
+	if r.Bench != "" {
+		if _, err := regexp.Compile(r.Bench); err != nil {
+			return errors.Invalid(prefix+".bench", "invalid regexp: "+err.Error())
+		}
+	}
+	if r.Benchtime != "" && !ValidBenchtime(r.Benchtime) {
+		return errors.Invalid(prefix+".benchtime", "expected a duration such as 2s or a count such as 100x")
+	}
+	if r.Count != nil && *r.Count < 0 {
+		return errors.Invalid(prefix+".count", "must not be negative")
+	}
+	if r.Fuzztime != "" && !ValidBenchtime(r.Fuzztime) {
+		return errors.Invalid(prefix+".fuzztime", "expected a duration such as 30s or a count such as 1000x")
+	}
+	for name := range r.Env {
+		if name == "" || strings.Contains(name, "=") {
+			return errors.Invalid(prefix+".env", "invalid variable name "+strconv.Quote(name))
+		}
+	}
+	return nil
+}
+
+// ValidBenchtime reports whether s is a -benchtime or -fuzztime value: a
+// positive duration or an iteration count such as 100x
+func ValidBenchtime(s string) bool {
+	if count, ok := strings.CutSuffix(s, "x"); ok {
+		n, err := strconv.Atoi(count)
+		return err == nil && n > 0
+	}
+	d, err := time.ParseDuration(s)
+	return err == nil && d > 0
+}
+
+// TimeoutDuration returns the parsed run timeout, or zero when unset
+func (c *Config) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(c.Run.Timeout)
+	return d
+}
+
+// StallThresholdDuration returns the parsed stall threshold, or zero when
+// unset
+func (c *Config) StallThresholdDuration() time.Duration {
+	d, _ := time.ParseDuration(c.StallThreshold)
+	return d
+}
+
+// isColor reports whether s is a hex color or an ANSI color number
+func isColor(s string) bool {
+	if hexColorRegex.MatchString(s) {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
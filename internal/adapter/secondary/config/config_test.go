@@ -0,0 +1,358 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+func loadFixture(t *testing.T, name string) *Config {
+	t.Helper()
+	cfg, err := LoadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// copyFixture writes the testdata file name to path
+func copyFixture(t *testing.T, name, path string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func intPtr(n int) *int { return &n }
+
+func stringPtr(s string) *string { return &s }
+
+// merged is user.yaml with project.yaml merged onto it
+func merged() *Config {
+	return &Config{
+		Run: RunConfig{
+			Tags:     "integration",
+			Timeout:  "10m",
+			Race:     boolPtr(false),
+			Cover:    boolPtr(true),
+			Short:    boolPtr(true),
+			Parallel: intPtr(0),
+			Count:    intPtr(2),
+			Env:      map[string]*string{"CGO_ENABLED": stringPtr("1"), "GOFLAGS": nil},
+		},
+		Packages: PackagesConfig{
+			Include: []string{"./internal/..."},
+			Exclude: []string{"./vendor/..."},
+		},
+		Editor:         "vim",
+		DiffBase:       "main",
+		StallThreshold: "1m",
+		Keybindings: map[string]KeyList{
+			"run":  {"r", "enter"},
+			"quit": {"q", "ctrl+c"},
+		},
+		Theme: Theme{Primary: "#112233", Accent: "#abcdef"},
+		Profiles: map[string]Profile{
+			// The project profile replaces the user one as a whole
+			"ci": {
+				RunConfig: RunConfig{Cover: boolPtr(true), Count: intPtr(1)},
+				Packages:  []string{"./..."},
+			},
+			"bench": {
+				RunConfig: RunConfig{Bench: ".", Benchtime: "100x", Benchmem: boolPtr(true)},
+			},
+		},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	user := loadFixture(t, "user.yaml")
+	project := loadFixture(t, "project.yaml")
+
+	cfg := &Config{}
+	cfg.Merge(user)
+	cfg.Merge(project)
+	if want := merged(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Merge =\n%+v\nwant\n%+v", cfg, want)
+	}
+
+	// Merging never writes into the maps of the merged configurations
+	if got := user.Run.Env["GOFLAGS"]; got == nil || *got != "-mod=mod" {
+		t.Errorf("user GOFLAGS changed to %v by the merge", got)
+	}
+	if got := user.Keybindings["run"]; !reflect.DeepEqual(got, KeyList{"r"}) {
+		t.Errorf("user run keys changed to %v by the merge", got)
+	}
+	if got := user.Profiles["ci"].Timeout; got != "20m" {
+		t.Errorf("user ci timeout changed to %q by the merge", got)
+	}
+}
+
+// TestMergeNumbers checks an explicit 0 resets a number, as go test's
+// default, while an unset one keeps the value merged onto
+func TestMergeNumbers(t *testing.T) {
+	base := RunConfig{Parallel: intPtr(4), PackageParallel: intPtr(2), Count: intPtr(3)}
+	tests := []struct {
+		name  string
+		other RunConfig
+		want  RunConfig
+	}{
+		{name: "unset", want: base},
+		{
+			name:  "reset",
+			other: RunConfig{Parallel: intPtr(0), PackageParallel: intPtr(0), Count: intPtr(0)},
+			want:  RunConfig{Parallel: intPtr(0), PackageParallel: intPtr(0), Count: intPtr(0)},
+		},
+		{
+			name:  "override",
+			other: RunConfig{Count: intPtr(1)},
+			want:  RunConfig{Parallel: intPtr(4), PackageParallel: intPtr(2), Count: intPtr(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := base
+			got.merge(tt.other)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merge =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	dir := filepath.Join(root, "internal", "pkg")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither file exists
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, &Config{}) {
+		t.Errorf("Load without files = %+v, want an empty configuration", cfg)
+	}
+
+	copyFixture(t, "user.yaml", UserConfigPath())
+	copyFixture(t, "project.yaml", filepath.Join(root, ProjectFileName))
+	if got := FindProjectConfig(dir); got != filepath.Join(root, ProjectFileName) {
+		t.Errorf("FindProjectConfig(%q) = %q, want the file of %q", dir, got, root)
+	}
+	cfg, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := merged(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load =\n%+v\nwant\n%+v", cfg, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		project string
+		// want is a substring of the error
+		want string
+	}{
+		{
+			name:    "malformed project file",
+			project: "run: [\n",
+			want:    "failed to parse config",
+		},
+		{
+			name: "malformed user file",
+			user: "run:\n  race: maybe\n",
+			want: "failed to parse config",
+		},
+		{
+			// Each file is valid on its own terms; the merged values are checked
+			name:    "invalid merged value",
+			user:    "run:\n  timeout: 5m\n",
+			project: "run:\n  timeout: soon\n",
+			want:    "run.timeout",
+		},
+		{
+			name:    "invalid profile",
+			project: "profiles:\n  ci:\n    count: -1\n",
+			want:    "profiles.ci.count",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", home)
+			dir := t.TempDir()
+			if tt.user != "" {
+				path := UserConfigPath()
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.user), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.project != "" {
+				if err := os.WriteFile(filepath.Join(dir, ProjectFileName), []byte(tt.project), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := Load(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		// field is the invalid field, empty for a valid configuration
+		field string
+	}{
+		{name: "empty"},
+		{
+			name: "valid values",
+			cfg: Config{
+				Run: RunConfig{
+					Timeout: "1m30s", Bench: "^BenchmarkJoin$", Benchtime: "100x", Fuzztime: "30s",
+					Env: map[string]*string{"GOFLAGS": nil},
+				},
+				StallThreshold: "0",
+				Theme:          Theme{Primary: "#A0b1C2", Muted: "255"},
+				Profiles:       map[string]Profile{"ci": {RunConfig: RunConfig{Count: intPtr(3)}}},
+			},
+		},
+		{name: "timeout", cfg: Config{Run: RunConfig{Timeout: "5"}}, field: "run.timeout"},
+		{name: "parallel", cfg: Config{Run: RunConfig{Parallel: intPtr(-1)}}, field: "run.parallel"},
+		{name: "package parallel", cfg: Config{Run: RunConfig{PackageParallel: intPtr(-2)}}, field: "run.package_parallel"},
+		{name: "bench", cfg: Config{Run: RunConfig{Bench: "Join("}}, field: "run.bench"},
+		{name: "zero benchtime", cfg: Config{Run: RunConfig{Benchtime: "0s"}}, field: "run.benchtime"},
+		{name: "benchtime count", cfg: Config{Run: RunConfig{Benchtime: "0x"}}, field: "run.benchtime"},
+		{name: "count", cfg: Config{Run: RunConfig{Count: intPtr(-1)}}, field: "run.count"},
+		{name: "fuzztime", cfg: Config{Run: RunConfig{Fuzztime: "forever"}}, field: "run.fuzztime"},
+		{
+			name:  "env name",
+			cfg:   Config{Run: RunConfig{Env: map[string]*string{"A=B": stringPtr("c")}}},
+			field: "run.env",
+		},
+		{
+			name:  "profile",
+			cfg:   Config{Profiles: map[string]Profile{"bench": {RunConfig: RunConfig{Benchtime: "x"}}}},
+			field: "profiles.bench.benchtime",
+		},
+		{name: "profile name", cfg: Config{Profiles: map[string]Profile{"": {}}}, field: "profiles"},
+		{name: "negative stall threshold", cfg: Config{StallThreshold: "-1s"}, field: "stall_threshold"},
+		{name: "stall threshold", cfg: Config{StallThreshold: "30"}, field: "stall_threshold"},
+		{name: "hex color", cfg: Config{Theme: Theme{Accent: "#abc"}}, field: "theme.accent"},
+		{name: "ANSI color", cfg: Config{Theme: Theme{FocusedBg: "256"}}, field: "theme.focused_bg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			var invalid *errors.ValidationError
+			switch {
+			case tt.field == "" && err != nil:
+				t.Errorf("Validate = %v, want nil", err)
+			case tt.field != "" && (!errors.As(err, &invalid) || invalid.Field != tt.field):
+				t.Errorf("Validate = %v, want an invalid %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	cfg := merged()
+
+	applied, err := cfg.ApplyProfile("ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := merged()
+	want.Run.Cover = boolPtr(true)
+	want.Run.Count = intPtr(1)
+	want.Packages.Include = []string{"./..."}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("ApplyProfile(ci) =\n%+v\nwant\n%+v", applied, want)
+	}
+	if !reflect.DeepEqual(cfg, merged()) {
+		t.Errorf("ApplyProfile changed the configuration to %+v", cfg)
+	}
+
+	// The copy shares the run environment until the profile changes it
+	cfg.Profiles["cgo"] = Profile{RunConfig: RunConfig{Env: map[string]*string{"CGO_ENABLED": stringPtr("0")}}}
+	if applied, err = cfg.ApplyProfile("cgo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := *applied.Run.Env["CGO_ENABLED"]; got != "0" {
+		t.Errorf("ApplyProfile(cgo) CGO_ENABLED = %q, want 0", got)
+	}
+	if got := *cfg.Run.Env["CGO_ENABLED"]; got != "1" {
+		t.Errorf("ApplyProfile(cgo) changed the configured CGO_ENABLED to %q", got)
+	}
+
+	if _, err := cfg.ApplyProfile("race"); err == nil || !strings.Contains(err.Error(), "expected one of bench, cgo, ci") {
+		t.Errorf("ApplyProfile(race) = %v, want the configured profiles", err)
+	}
+	if _, err := (&Config{}).ApplyProfile("ci"); err == nil || !strings.Contains(err.Error(), "no profiles are configured") {
+		t.Errorf("ApplyProfile without profiles = %v, want an error", err)
+	}
+}
+
+func TestValidBenchtime(t *testing.T) {
+	tests := map[string]bool{
+		"2s":    true,
+		"1m30s": true,
+		"100x":  true,
+		"1x":    true,
+		"":      false,
+		"0s":    false,
+		"-1s":   false,
+		"0x":    false,
+		"-5x":   false,
+		"x":     false,
+		"100":   false,
+		"1.5x":  false,
+	}
+	for s, want := range tests {
+		if got := ValidBenchtime(s); got != want {
+			t.Errorf("ValidBenchtime(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestEnvLists(t *testing.T) {
+	set, unset := EnvLists(map[string]*string{
+		"GOFLAGS":     stringPtr("-mod=mod"),
+		"CGO_ENABLED": stringPtr("0"),
+		"EMPTY":       stringPtr(""),
+		"GOPROXY":     nil,
+		"GOCACHE":     nil,
+	})
+	if want := []string{"CGO_ENABLED=0", "EMPTY=", "GOFLAGS=-mod=mod"}; !reflect.DeepEqual(set, want) {
+		t.Errorf("set = %q, want %q", set, want)
+	}
+	if want := []string{"GOCACHE", "GOPROXY"}; !reflect.DeepEqual(unset, want) {
+		t.Errorf("unset = %q, want %q", unset, want)
+	}
+}
@@ -0,0 +1,77 @@
+package coverprofile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// Load reads the coverage profile go test -coverprofile wrote to path
+func Load(path string) (mode string, blocks []domain.CoverBlock, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to open coverage profile %s", path)
+	}
+	defer f.Close()
+
+	mode, blocks, err = Parse(f)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to parse coverage profile %s", path)
+	}
+	return mode, blocks, nil
+}
+
+// Parse reads a coverage profile: a "mode:" line followed by one line per
+// block, "file:startLine.startCol,endLine.endCol statements count"
+func Parse(r io.Reader) (mode string, blocks []domain.CoverBlock, err error) {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if m, ok := strings.CutPrefix(text, "mode: "); ok {
+			// Profiles of several packages concatenated repeat the mode line
+			mode = m
+			continue
+		}
+
+		block, ok := parseBlock(text)
+		if !ok {
+			return "", nil, errors.Newf("line %d: malformed block %q", line, text)
+		}
+		blocks = append(blocks, block)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	return mode, blocks, nil
+}
+
+// parseBlock parses a block line; the file name may itself contain colons
+func parseBlock(text string) (domain.CoverBlock, bool) {
+	i := strings.LastIndexByte(text, ':')
+	if i <= 0 {
+		return domain.CoverBlock{}, false
+	}
+
+	block := domain.CoverBlock{File: text[:i]}
+	_, err := fmt.Sscanf(text[i+1:], "%d.%d,%d.%d %d %d",
+		&block.StartLine, &block.StartCol, &block.EndLine, &block.EndCol, &block.Statements, &block.Count)
+	return block, err == nil
+}
+
+// TempFile creates an empty file for a run to write its profile to
+func TempFile() (string, error) {
+	f, err := os.CreateTemp("", "lazygotest-*.cover")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create coverage profile")
+	}
+	f.Close()
+	return f.Name(), nil
+}
@@ -0,0 +1,119 @@
+package coverprofile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+func readProfile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParse(t *testing.T) {
+	const calc = "example.com/cs/calc/calc.go"
+	sign := readProfile(t, "sign.cover")
+	sum := readProfile(t, "sum.cover")
+
+	tests := []struct {
+		name   string
+		input  string
+		mode   string
+		blocks int
+		// first is the first block, and last the last one
+		first, last domain.CoverBlock
+	}{
+		{
+			name:   "one profile",
+			input:  sign,
+			mode:   "count",
+			blocks: 8,
+			first:  domain.CoverBlock{File: calc, StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 11, Statements: 1, Count: 1},
+			last:   domain.CoverBlock{File: calc, StartLine: 17, StartCol: 2, EndLine: 17, EndCol: 14, Statements: 1, Count: 0},
+		},
+		{
+			// Profiles concatenated repeat the mode line and the blocks
+			name:   "concatenated profiles",
+			input:  sign + sum,
+			mode:   "count",
+			blocks: 16,
+			first:  domain.CoverBlock{File: calc, StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 11, Statements: 1, Count: 1},
+			last:   domain.CoverBlock{File: calc, StartLine: 17, StartCol: 2, EndLine: 17, EndCol: 14, Statements: 1, Count: 1},
+		},
+		{
+			name:   "blank lines and carriage returns",
+			input:  "\nmode: set\r\n\r\nexample.com/m/a.go:1.13,3.2 2 1\r\n\n",
+			mode:   "set",
+			blocks: 1,
+			first:  domain.CoverBlock{File: "example.com/m/a.go", StartLine: 1, StartCol: 13, EndLine: 3, EndCol: 2, Statements: 2, Count: 1},
+			last:   domain.CoverBlock{File: "example.com/m/a.go", StartLine: 1, StartCol: 13, EndLine: 3, EndCol: 2, Statements: 2, Count: 1},
+		},
+		{
+			// Files outside a module are named by their path
+			name:   "colon in the file name",
+			input:  "mode: atomic\nC:/src/a.go:10.2,12.16 3 42\n",
+			mode:   "atomic",
+			blocks: 1,
+			first:  domain.CoverBlock{File: "C:/src/a.go", StartLine: 10, StartCol: 2, EndLine: 12, EndCol: 16, Statements: 3, Count: 42},
+			last:   domain.CoverBlock{File: "C:/src/a.go", StartLine: 10, StartCol: 2, EndLine: 12, EndCol: 16, Statements: 3, Count: 42},
+		},
+		{
+			name:  "empty",
+			input: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, blocks, err := Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode != tt.mode || len(blocks) != tt.blocks {
+				t.Fatalf("Parse = mode %q with %d blocks, want %q with %d", mode, len(blocks), tt.mode, tt.blocks)
+			}
+			if len(blocks) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(blocks[0], tt.first) || !reflect.DeepEqual(blocks[len(blocks)-1], tt.last) {
+				t.Errorf("blocks from %+v to %+v, want from %+v to %+v", blocks[0], blocks[len(blocks)-1], tt.first, tt.last)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"mode: set\nnot a block\n", "line 2"},
+		{"mode: set\nexample.com/m/a.go:1.2,3 1 0\n", "line 2"},
+		{"mode: set\n\nexample.com/m/a.go 1.2,3.4 1 0\n", "line 3"},
+		{"example.com/m/a.go:1.2,3.4 one 0\n", "line 1"},
+	}
+	for _, tt := range tests {
+		_, _, err := Parse(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) error = %v, want one at %s", tt.input, err, tt.err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	mode, blocks, err := Load(filepath.Join("testdata", "sum.cover"))
+	if err != nil || mode != "count" || len(blocks) != 8 {
+		t.Errorf("Load = mode %q with %d blocks, %v; want count with 8", mode, len(blocks), err)
+	}
+	if _, _, err := Load(filepath.Join("testdata", "missing.cover")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
@@ -0,0 +1,191 @@
+package gitdiff
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// ChangedLines returns the lines of the Go files under dir that changed
+// since the current branch left base: commits since their merge base,
+// uncommitted changes and untracked files. Deleted lines are not reported.
+func ChangedLines(ctx context.Context, dir, base string) ([]domain.ChangedFile, error) {
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve directory")
+	}
+
+	// Without a common history, compare with base itself
+	from := base
+	if out, err := git(ctx, dir, "merge-base", base, "HEAD"); err == nil {
+		from = strings.TrimSpace(string(out))
+	}
+
+	// The prefixes are set for parseDiff, whatever diff.noprefix or
+	// diff.mnemonicPrefix say
+	out, err := git(ctx, dir, "diff", "--relative", "--unified=0", "--no-color", "--no-ext-diff", "-M",
+		"--src-prefix=a/", "--dst-prefix=b/", from, "--", "*.go")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to diff against %s", base)
+	}
+	lines, err := parseDiff(out)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the diff against %s", base)
+	}
+
+	out, err = git(ctx, dir, "ls-files", "--others", "--exclude-standard", "--", "*.go")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list untracked files")
+	}
+	for _, name := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if name == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		n := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			n++
+		}
+		for line := 1; line <= n; line++ {
+			lines[name] = append(lines[name], line)
+		}
+	}
+
+	modules := make(map[string]string)
+	files := make([]domain.ChangedFile, 0, len(lines))
+	for name, changed := range lines {
+		sort.Ints(changed)
+		files = append(files, domain.ChangedFile{
+			Path:  name,
+			File:  importPath(modules, filepath.Join(dir, filepath.FromSlash(name))),
+			Lines: changed,
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// git runs a git command in dir, returning its standard output
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "core.quotePath=false"}, args...)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Wrap(err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseDiff collects the added lines of a --unified=0 diff per file, whose
+// new files are prefixed with b/. Hunk headers read
+// "@@ -start,count +start,count @@"; a missing count is 1.
+func parseDiff(diff []byte) (map[string][]int, error) {
+	lines := make(map[string][]int)
+	var file, prev string
+
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for ; scanner.Scan(); prev = scanner.Text() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "+++ ") && strings.HasPrefix(prev, "--- "):
+			// The new file's name follows the old one's; an added line
+			// may read the same
+			file = ""
+			// Names with spaces end in a tab, for patch
+			name := strings.TrimSuffix(strings.TrimPrefix(text, "+++ "), "\t")
+			if unquoted, err := strconv.Unquote(name); err == nil {
+				name = unquoted
+			}
+			// Deleted files are diffed against /dev/null
+			if rest, ok := strings.CutPrefix(name, "b/"); ok {
+				file = rest
+			}
+		case strings.HasPrefix(text, "@@ ") && file != "":
+			fields := strings.Fields(text)
+			if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+				return nil, errors.Newf("malformed hunk header %q", text)
+			}
+			start, count, err := parseRange(strings.TrimPrefix(fields[2], "+"))
+			if err != nil {
+				return nil, errors.Newf("malformed hunk header %q", text)
+			}
+			for n := start; n < start+count; n++ {
+				lines[file] = append(lines[file], n)
+			}
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseRange parses "start,count" or "start" of a hunk header
+func parseRange(s string) (start, count int, err error) {
+	startText, countText, ok := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(startText); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if ok {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
+
+// importPath returns the file in import path form: the path of the module
+// of the nearest go.mod joined with the file's path within it. modules
+// caches the module path of each directory looked up.
+func importPath(modules map[string]string, file string) string {
+	var rel []string
+	for dir := filepath.Dir(file); ; dir = filepath.Dir(dir) {
+		module, ok := modules[dir]
+		if !ok {
+			module = modulePath(filepath.Join(dir, "go.mod"))
+			modules[dir] = module
+		}
+		if module != "" {
+			return path.Join(append([]string{module}, rel...)...) + "/" + filepath.Base(file)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return ""
+		}
+		rel = append([]string{filepath.Base(dir)}, rel...)
+	}
+}
+
+// modulePath reads the module directive of a go.mod file, "" when there is
+// none
+func modulePath(gomod string) string {
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,227 @@
+package gitdiff
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+func TestParseDiff(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "changes.diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		diff string
+		want map[string][]int
+	}{
+		{
+			// git diff -M of a module whose commit modifies, deletes,
+			// renames and adds lines, with core.quotePath=false
+			name: "recorded",
+			diff: string(fixture),
+			want: map[string][]int{
+				// A hunk of deleted lines adds none
+				"pkg/a.go":    {4},
+				"pkg/café.go": {8},
+				// The name ends in a tab
+				"pkg/my file.go": {8},
+				// Renamed from pkg/gone.go; pkg/old.go is deleted
+				"pkg/new.go":     {12},
+				`pkg/we"ird.go`:  {8},
+				"sub/inner/x.go": {3, 5, 6},
+			},
+		},
+		{
+			name: "quoted octal escapes",
+			diff: "--- \"a/caf\\303\\251.go\"\n+++ \"b/caf\\303\\251.go\"\n@@ -1,0 +2 @@\n+x\n",
+			want: map[string][]int{"café.go": {2}},
+		},
+		{
+			name: "added line like a header",
+			diff: "--- a/x.go\n+++ b/x.go\n@@ -1,0 +2,2 @@\n+++ b/y.go\n+z\n",
+			want: map[string][]int{"x.go": {2, 3}},
+		},
+		{
+			name: "new file",
+			diff: "diff --git a/n.go b/n.go\nnew file mode 100644\n--- /dev/null\n+++ b/n.go\n@@ -0,0 +1,3 @@\n+a\n+b\n+c\n",
+			want: map[string][]int{"n.go": {1, 2, 3}},
+		},
+		{
+			// ChangedLines sets the prefixes, so others name no file
+			name: "mnemonic prefixes",
+			diff: "--- c/x.go\n+++ w/x.go\n@@ -1,0 +2 @@\n+x\n",
+			want: map[string][]int{},
+		},
+		{
+			name: "no prefixes",
+			diff: "--- x.go\n+++ x.go\n@@ -1,0 +2 @@\n+x\n",
+			want: map[string][]int{},
+		},
+		{
+			name: "hunk without a file",
+			diff: "@@ -1 +1 @@\n-a\n+b\n",
+			want: map[string][]int{},
+		},
+		{
+			name: "empty",
+			diff: "",
+			want: map[string][]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDiff([]byte(tt.diff))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDiff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDiffMalformed(t *testing.T) {
+	for _, hunk := range []string{"@@ -1 @@", "@@ -1 -2 @@", "@@ -1 +x @@", "@@ -1 +2,y @@"} {
+		diff := "--- a/x.go\n+++ b/x.go\n" + hunk + "\n"
+		if _, err := parseDiff([]byte(diff)); err == nil {
+			t.Errorf("parseDiff accepted the hunk header %q", hunk)
+		}
+	}
+}
+
+// writeFiles writes files, named by slash-separated paths, under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImportPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"mod/go.mod":              "module example.com/gs\n\ngo 1.23\n",
+		"mod/sub/go.mod":          "// nested module\nmodule \"example.com/gs/sub\"\n",
+		"mod/nomod/go.mod":        "go 1.23\n",
+		"mod/pkg/deep/a.go":       "package deep\n",
+		"mod/sub/inner/x.go":      "package inner\n",
+		"mod/nomod/inner/y.go":    "package inner\n",
+		"outside/pkg/z.go":        "package pkg\n",
+		"mod/sub/testdata/t.go":   "package testdata\n",
+		"mod/top.go":              "package gs\n",
+		"mod/sub/inner/x_test.go": "package inner\n",
+	})
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"mod/top.go", "example.com/gs/top.go"},
+		{"mod/pkg/deep/a.go", "example.com/gs/pkg/deep/a.go"},
+		// The nearest go.mod is the nested module's
+		{"mod/sub/inner/x.go", "example.com/gs/sub/inner/x.go"},
+		{"mod/sub/inner/x_test.go", "example.com/gs/sub/inner/x_test.go"},
+		{"mod/sub/testdata/t.go", "example.com/gs/sub/testdata/t.go"},
+		// A go.mod without a module directive is skipped
+		{"mod/nomod/inner/y.go", "example.com/gs/nomod/inner/y.go"},
+	}
+	modules := make(map[string]string)
+	for _, tt := range tests {
+		file := filepath.Join(dir, filepath.FromSlash(tt.file))
+		if got := importPath(modules, file); got != tt.want {
+			t.Errorf("importPath(%s) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+	if got := modules[filepath.Join(dir, "mod", "sub", "inner")]; got != "" {
+		t.Errorf("cached module of a directory without go.mod = %q, want empty", got)
+	}
+	if got := modules[filepath.Join(dir, "mod", "sub")]; got != "example.com/gs/sub" {
+		t.Errorf("cached module of the nested module = %q", got)
+	}
+
+	// Above every go.mod, unless the temporary directory is in a module
+	if modulePath(filepath.Join(filepath.Dir(dir), "go.mod")) == "" {
+		if got := importPath(modules, filepath.Join(dir, "outside", "pkg", "z.go")); got != "" {
+			t.Errorf("importPath outside a module = %q, want empty", got)
+		}
+	}
+}
+
+func TestChangedLines(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null",
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/gs\n",
+		"pkg/a.go":   "package pkg\n\nfunc A() int {\n\treturn 1\n}\n",
+		"pkg/b.go":   "package pkg\n",
+		"README.txt": "readme\n",
+	})
+	run("init", "-q", "-b", "main")
+	run("add", "-A")
+	run("commit", "-q", "-m", "base")
+	run("checkout", "-q", "-b", "feature")
+
+	// Committed on the branch, uncommitted, untracked and deleted
+	writeFiles(t, dir, map[string]string{"pkg/a.go": "package pkg\n\nfunc A() int {\n\treturn 10\n}\n"})
+	run("commit", "-q", "-am", "change")
+	writeFiles(t, dir, map[string]string{
+		"pkg/a.go":   "package pkg\n\n// A returns ten\nfunc A() int {\n\treturn 10\n}\n",
+		"pkg/new.go": "package pkg\n\nfunc New() {}",
+		"README.txt": "changed\n",
+	})
+	run("rm", "-q", "pkg/b.go")
+
+	want := []domain.ChangedFile{
+		{Path: "pkg/a.go", File: "example.com/gs/pkg/a.go", Lines: []int{3, 5}},
+		// Untracked, without a final newline
+		{Path: "pkg/new.go", File: "example.com/gs/pkg/new.go", Lines: []int{1, 2, 3}},
+	}
+	// The prefixes the user configured do not change the files
+	for _, config := range []string{"", "diff.noprefix", "diff.mnemonicPrefix"} {
+		t.Run("config "+config, func(t *testing.T) {
+			if config != "" {
+				t.Setenv("GIT_CONFIG_COUNT", "1")
+				t.Setenv("GIT_CONFIG_KEY_0", config)
+				t.Setenv("GIT_CONFIG_VALUE_0", "true")
+			}
+			files, err := ChangedLines(context.Background(), dir, "main")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("ChangedLines = %+v, want %+v", files, want)
+			}
+		})
+	}
+
+	if _, err := ChangedLines(context.Background(), dir, "no-such-branch"); err == nil {
+		t.Error("ChangedLines against a missing base succeeded")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/YuminosukeSato/lazygotest/internal/domain"
 	"github.com/YuminosukeSato/lazygotest/pkg/errors"
@@ -20,21 +21,73 @@ type GoPackageInfo struct {
 	GoFiles      []string `json:"GoFiles"`
 	TestGoFiles  []string `json:"TestGoFiles"`
 	XTestGoFiles []string `json:"XTestGoFiles"`
+	// Module is nil for packages outside a module, such as in GOPATH mode
+	Module *GoModuleInfo `json:"Module"`
+}
+
+// GoModuleInfo represents the module of a package in the output of go list
+type GoModuleInfo struct {
+	Path string `json:"Path"`
+}
+
+// modulePath returns the path of the package's module, empty if it has none
+func (p GoPackageInfo) modulePath() string {
+	if p.Module == nil {
+		return ""
+	}
+	return p.Module.Path
 }
 
 // GoPackageRepo discovers Go packages using go list
-type GoPackageRepo struct{}
+type GoPackageRepo struct {
+	excludes []string
+
+	mu sync.RWMutex
+	// dir is the directory go list runs in; empty means the working directory
+	dir string
+}
 
 // NewGoPackageRepo creates a new package repository
 func NewGoPackageRepo() *GoPackageRepo {
 	return &GoPackageRepo{}
 }
 
-// ListPackages discovers all packages with tests in the module
-func (r *GoPackageRepo) ListPackages(ctx context.Context) ([]*domain.Package, error) {
-	logger.Debug("Discovering packages with tests")
+// SetExcludes sets the package patterns removed from discovery results.
+// Patterns follow go list syntax: import paths, ./relative paths and "..." wildcards.
+func (r *GoPackageRepo) SetExcludes(patterns []string) {
+	r.excludes = patterns
+}
+
+// SetDir sets the directory (module root) packages are discovered from.
+// Relative patterns and excludes are resolved against it.
+func (r *GoPackageRepo) SetDir(dir string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dir = dir
+}
 
-	cmd := exec.CommandContext(ctx, "go", "list", "-json", "./...")
+// Dir returns the directory packages are discovered from
+func (r *GoPackageRepo) Dir() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.dir
+}
+
+// ListPackages discovers all packages with tests matching the given patterns.
+// An empty pattern list means every package in the module.
+func (r *GoPackageRepo) ListPackages(ctx context.Context, patterns []string) ([]*domain.Package, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	logger.Debug("Discovering packages with tests", "patterns", patterns)
+
+	// -e keeps packages with errors so go test can report them
+	args := append([]string{"list", "-e", "-json"}, patterns...)
+	dir := r.Dir()
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to execute go list")
@@ -55,11 +108,17 @@ func (r *GoPackageRepo) ListPackages(ctx context.Context) ([]*domain.Package, er
 			continue
 		}
 
+		if r.isExcluded(pkgInfo, dir) {
+			logger.Debug("Excluded package", "package", pkgInfo.ImportPath)
+			continue
+		}
+
 		pkg := &domain.Package{
-			ID:    domain.PkgID(pkgInfo.ImportPath),
-			Path:  pkgInfo.Dir,
-			Name:  pkgInfo.Name,
-			Tests: []domain.TestCase{},
+			ID:     domain.PkgID(pkgInfo.ImportPath),
+			Path:   pkgInfo.Dir,
+			Name:   pkgInfo.Name,
+			Module: pkgInfo.modulePath(),
+			Tests:  []domain.TestCase{},
 		}
 
 		packages = append(packages, pkg)
@@ -73,6 +132,7 @@ func (r *GoPackageRepo) ListPackages(ctx context.Context) ([]*domain.Package, er
 // GetPackage retrieves information about a specific package
 func (r *GoPackageRepo) GetPackage(ctx context.Context, pkgPath string) (*domain.Package, error) {
 	cmd := exec.CommandContext(ctx, "go", "list", "-json", pkgPath)
+	cmd.Dir = r.Dir()
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, errors.NotFound(pkgPath)
@@ -84,10 +144,11 @@ func (r *GoPackageRepo) GetPackage(ctx context.Context, pkgPath string) (*domain
 	}
 
 	return &domain.Package{
-		ID:    domain.PkgID(pkgInfo.ImportPath),
-		Path:  pkgInfo.Dir,
-		Name:  pkgInfo.Name,
-		Tests: []domain.TestCase{},
+		ID:     domain.PkgID(pkgInfo.ImportPath),
+		Path:   pkgInfo.Dir,
+		Name:   pkgInfo.Name,
+		Module: pkgInfo.modulePath(),
+		Tests:  []domain.TestCase{},
 	}, nil
 }
 
@@ -98,6 +159,11 @@ func (r *GoPackageRepo) hasTests(pkg GoPackageInfo) bool {
 
 // FilterPackages filters packages based on a pattern
 func (r *GoPackageRepo) FilterPackages(packages []*domain.Package, pattern string) []*domain.Package {
+	return filterPackages(packages, pattern)
+}
+
+// filterPackages keeps packages whose import path or name contains pattern
+func filterPackages(packages []*domain.Package, pattern string) []*domain.Package {
 	if pattern == "" {
 		return packages
 	}
@@ -0,0 +1,63 @@
+package pkgrepo
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// FindModules returns the directories under root that contain a go.mod,
+// sorted with root first when it is a module itself. Hidden directories,
+// vendor, testdata and node_modules are not searched.
+func FindModules(root string) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve directory")
+	}
+
+	var modules []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than failing the search
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" ||
+				name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Name() == "go.mod" {
+			modules = append(modules, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to search for modules")
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i] == root || modules[j] == root {
+			return modules[i] == root
+		}
+		return modules[i] < modules[j]
+	})
+	return modules, nil
+}
+
+// IsDir reports whether path is an existing directory
+func IsDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
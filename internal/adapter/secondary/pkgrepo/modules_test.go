@@ -0,0 +1,50 @@
+package pkgrepo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindModules(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{
+		".", "tools", "svc/api", "svc/api/internal/gen", "svc/web",
+		// Not searched
+		"vendor/example.com/dep", "svc/api/testdata/mod", "web/node_modules/x", ".cache/mod",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A directory without a module
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindModules(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{root}
+	for _, dir := range []string{"svc/api", "svc/api/internal/gen", "svc/web", "tools"} {
+		want = append(want, filepath.Join(root, dir))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindModules =\n%q\nwant\n%q", got, want)
+	}
+
+	// Below a module root, the root is not a module
+	got, err = FindModules(filepath.Join(root, "svc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{filepath.Join(root, "svc/api"), filepath.Join(root, "svc/api/internal/gen"), filepath.Join(root, "svc/web")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindModules(svc) =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package pkgrepo
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// isExcluded reports whether the package matches one of the exclude
+// patterns; relative patterns are resolved against dir
+func (r *GoPackageRepo) isExcluded(pkg GoPackageInfo, dir string) bool {
+	for _, pattern := range r.excludes {
+		if isRelativePattern(pattern) {
+			if matchPattern(filepath.ToSlash(filepath.Clean(pattern)), relativeDir(dir, pkg.Dir)) {
+				return true
+			}
+			continue
+		}
+		if matchPattern(pattern, pkg.ImportPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRelativePattern reports whether the pattern is a ./ or ../ path pattern
+func isRelativePattern(pattern string) bool {
+	return pattern == "." || pattern == ".." ||
+		strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../")
+}
+
+// relativeDir returns dir relative to base (the working directory when
+// empty) in slash form
+func relativeDir(base, dir string) string {
+	if base == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return dir
+		}
+		base = wd
+	}
+	rel, err := filepath.Rel(base, dir)
+	if err != nil {
+		return dir
+	}
+	return filepath.ToSlash(rel)
+}
+
+// matchPattern reports whether name matches a go list style pattern where
+// "..." matches any string and a trailing "/..." also matches the parent
+func matchPattern(pattern, name string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	matched, err := regexp.MatchString("^"+re+"$", name)
+	return err == nil && matched
+}
@@ -0,0 +1,62 @@
+package pkgrepo
+
+import (
+	"context"
+	"path"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// StaticPackageRepo serves a fixed package list, for sources such as
+// recorded test2json files where there is no module to run go list in
+type StaticPackageRepo struct {
+	packages []*domain.Package
+}
+
+// NewStaticPackageRepo creates a repository for the given package IDs
+func NewStaticPackageRepo(ids []domain.PkgID) *StaticPackageRepo {
+	packages := make([]*domain.Package, len(ids))
+	for i, id := range ids {
+		packages[i] = &domain.Package{
+			ID:    id,
+			Name:  path.Base(string(id)),
+			Tests: []domain.TestCase{},
+		}
+	}
+	return &StaticPackageRepo{packages: packages}
+}
+
+// ListPackages returns the known packages matching the patterns. Relative
+// patterns cannot be resolved without a module on disk and match everything.
+func (r *StaticPackageRepo) ListPackages(ctx context.Context, patterns []string) ([]*domain.Package, error) {
+	if len(patterns) == 0 {
+		return r.packages, nil
+	}
+
+	packages := make([]*domain.Package, 0, len(r.packages))
+	for _, pkg := range r.packages {
+		for _, pattern := range patterns {
+			if isRelativePattern(pattern) || matchPattern(pattern, string(pkg.ID)) {
+				packages = append(packages, pkg)
+				break
+			}
+		}
+	}
+	return packages, nil
+}
+
+// GetPackage returns a known package by import path
+func (r *StaticPackageRepo) GetPackage(ctx context.Context, pkgPath string) (*domain.Package, error) {
+	for _, pkg := range r.packages {
+		if string(pkg.ID) == pkgPath {
+			return pkg, nil
+		}
+	}
+	return nil, errors.NotFound(pkgPath)
+}
+
+// FilterPackages filters packages based on a pattern
+func (r *StaticPackageRepo) FilterPackages(packages []*domain.Package, pattern string) []*domain.Package {
+	return filterPackages(packages, pattern)
+}
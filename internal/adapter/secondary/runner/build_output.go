@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// BuildOutputDecoder decodes the plain text go test writes to stderr, such
+// as compiler errors. Toolchains before Go 1.24 write the output of
+// package builds there instead of reporting it as test2json events.
+type BuildOutputDecoder struct {
+	reader *bufio.Scanner
+}
+
+// NewBuildOutputDecoder creates a new decoder for build output
+func NewBuildOutputDecoder(r io.Reader) *BuildOutputDecoder {
+	return &BuildOutputDecoder{
+		reader: bufio.NewScanner(r),
+	}
+}
+
+// Decode reads the lines of the input stream as build-output events
+func (d *BuildOutputDecoder) Decode(ctx context.Context) (<-chan domain.TestEvent, <-chan error) {
+	events := make(chan domain.TestEvent, 100)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		var build buildOutput
+		for d.reader.Scan() {
+			select {
+			case events <- build.event(d.reader.Text()):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := d.reader.Err(); err != nil {
+			errs <- errors.Wrap(err, "failed to read build output")
+		}
+	}()
+
+	return events, errs
+}
+
+// buildOutput attributes lines of build output to the package named by the
+// last "# pkg" header, until the package's output ends
+type buildOutput struct {
+	pkg string
+}
+
+// event returns the build-output event of a line without its newline
+func (b *buildOutput) event(line string) domain.TestEvent {
+	if pkg, ok := domain.ParseBuildHeader(line); ok {
+		b.pkg = pkg
+	} else if strings.HasPrefix(line, "go: ") {
+		// Messages of the go command are no package's
+		b.end()
+	}
+	return domain.TestEvent{
+		Time:    time.Now(),
+		Action:  "build-output",
+		Package: b.pkg,
+		Output:  line + "\n",
+	}
+}
+
+// end ends the output of the last header's package. go prints the output
+// of each build at once, so any other output ends it.
+func (b *buildOutput) end() {
+	b.pkg = ""
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// decode returns the events and error a decoder's channels yield
+func decode(t *testing.T, events <-chan domain.TestEvent, errs <-chan error) []domain.TestEvent {
+	t.Helper()
+	var decoded []domain.TestEvent
+	for event := range events {
+		decoded = append(decoded, event)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// openFixture opens a file of testdata followed by more lines
+func openFixture(t *testing.T, name string, more ...string) io.Reader {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return io.MultiReader(strings.NewReader(string(data)), strings.NewReader(strings.Join(more, "")))
+}
+
+// attribution lists each event's package and output line
+func attribution(events []domain.TestEvent) [][2]string {
+	var lines [][2]string
+	for _, event := range events {
+		lines = append(lines, [2]string{event.Package, strings.TrimSuffix(event.Output, "\n")})
+	}
+	return lines
+}
+
+func TestBuildOutputDecoder(t *testing.T) {
+	const (
+		bad    = "example.com/bs/bad"
+		broken = "example.com/bs/broken"
+		ext    = "example.com/bs/ext"
+	)
+	tests := []struct {
+		name    string
+		fixture string
+		more    []string
+		want    [][2]string
+	}{
+		{
+			name:    "from the module root",
+			fixture: "build_stderr.txt",
+			more:    []string{"go: warning: \"./none/...\" matched no packages\n", "\tindented\n"},
+			want: [][2]string{
+				{bad, "# example.com/bs/bad [example.com/bs/bad.test]"},
+				{bad, "bad/bad_test.go:6:15: too many arguments in call to Add"},
+				{bad, "\thave (number, number, number)"},
+				{bad, "\twant (int, int)"},
+				{bad, "bad/bad_test.go:7:11: undefined: missing"},
+				{broken, "# example.com/bs/broken"},
+				{broken, `broken/broken.go:3:27: cannot use "value" (untyped string constant) as int value in return statement`},
+				{ext, "# example.com/bs/ext_test [example.com/bs/ext.test]"},
+				{ext, "ext/ext_test.go:10:14: cannot use ext.Name() (value of type string) as int value in variable declaration"},
+				// The go command's messages end the last package's output
+				{"", `go: warning: "./none/..." matched no packages`},
+				{"", "\tindented"},
+			},
+		},
+		{
+			name:    "relative paths",
+			fixture: "build_stderr_relative.txt",
+			want: [][2]string{
+				{bad, "# example.com/bs/bad [example.com/bs/bad.test]"},
+				{bad, "./bad_test.go:6:15: too many arguments in call to Add"},
+				{bad, "\thave (number, number, number)"},
+				{bad, "\twant (int, int)"},
+				{bad, "./bad_test.go:7:11: undefined: missing"},
+				{ext, "# example.com/bs/ext_test [example.com/bs/ext.test]"},
+				{ext, "../ext/ext_test.go:10:14: cannot use ext.Name() (value of type string) as int value in variable declaration"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, errs := NewBuildOutputDecoder(openFixture(t, tt.fixture, tt.more...)).Decode(context.Background())
+			events := decode(t, decoded, errs)
+			for _, event := range events {
+				if event.Action != "build-output" {
+					t.Errorf("event %q has action %q, want build-output", event.Output, event.Action)
+				}
+			}
+			if got := attribution(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTest2JsonDecoderBuildOutput(t *testing.T) {
+	// Both streams of an older toolchain piped together
+	stream := strings.Join([]string{
+		"# example.com/bs/bad [example.com/bs/bad.test]",
+		"bad/bad_test.go:7:11: undefined: missing",
+		`{"Action":"start","Package":"example.com/bs/bad"}`,
+		"stray text",
+		`{"ImportPath":"example.com/bs/ext_test [example.com/bs/ext.test]","Action":"build-output","Output":"# example.com/bs/ext_test [example.com/bs/ext.test]\n"}`,
+		`{"ImportPath":"example.com/bs/ext_test [example.com/bs/ext.test]","Action":"build-fail"}`,
+		`{"Action":"fail","Package":"example.com/bs/ext","FailedBuild":"example.com/bs/ext_test [example.com/bs/ext.test]"}`,
+	}, "\n") + "\n"
+	decoded, errs := NewTest2JsonDecoder(strings.NewReader(stream)).Decode(context.Background())
+	events := decode(t, decoded, errs)
+
+	want := []struct{ action, pkg string }{
+		{"build-output", "example.com/bs/bad"},
+		{"build-output", "example.com/bs/bad"},
+		{"start", "example.com/bs/bad"},
+		// An event ends the text of the package before it
+		{"build-output", ""},
+		// Build events of newer toolchains are attributed to the tested
+		// package
+		{"build-output", "example.com/bs/ext"},
+		{"build-fail", "example.com/bs/ext"},
+		{"fail", "example.com/bs/ext"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("%d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.Action != want[i].action || event.Package != want[i].pkg {
+			t.Errorf("event %d = %s of %q, want %s of %q", i, event.Action, event.Package, want[i].action, want[i].pkg)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// ListError reports the packages whose tests could not be listed, such as
+// packages that do not build. ListTests returns it with the tests of the
+// other packages.
+type ListError struct {
+	// Failures are sorted by package
+	Failures []ListFailure
+}
+
+// ListFailure is a package whose tests could not be listed
+type ListFailure struct {
+	Pkg string
+	// Reason is the first error of the failed build, or the first line the
+	// test binary printed before failing
+	Reason string
+}
+
+func (e *ListError) Error() string {
+	lines := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		lines[i] = failure.Pkg + ": " + failure.Reason
+	}
+	if len(lines) == 1 {
+		return "failed to list the tests of " + lines[0]
+	}
+	return "failed to list the tests of " + strconv.Itoa(len(lines)) + " packages:\n\t" + strings.Join(lines, "\n\t")
+}
+
+// Reason returns why the tests of pkg could not be listed, "" when they
+// were
+func (e *ListError) Reason(pkg string) string {
+	for _, failure := range e.Failures {
+		if failure.Pkg == pkg {
+			return failure.Reason
+		}
+	}
+	return ""
+}
+
+// listedPackage is what go test -list reported of a package
+type listedPackage struct {
+	// failed is set when its test binary failed, and build when the binary
+	// could not be built; dep is the package build that failed it when
+	// the toolchain reports it
+	failed bool
+	build  bool
+	dep    string
+	// output is the first line the binary printed other than a test name
+	output string
+}
+
+// reason describes the failure of pkg with the errors of the builds
+func (p *listedPackage) reason(pkg string, errs []domain.BuildError) string {
+	if !p.build {
+		if p.output == "" {
+			return "test binary failed"
+		}
+		return "test binary failed: " + p.output
+	}
+
+	reason := "build failed"
+	if p.dep != "" && p.dep != pkg {
+		reason = "build of " + p.dep + " failed"
+	}
+	// A missing dependency is reported by the build of the package that
+	// imports it
+	for _, from := range []string{p.dep, pkg} {
+		for _, e := range errs {
+			if from != "" && e.Pkg == from {
+				message, _, _ := strings.Cut(e.Message, "\n")
+				return reason + ": " + e.Location() + ": " + message
+			}
+		}
+	}
+	return reason
+}
+
+// parseTestList reads the test2json output of go test -list and the build
+// output it wrote to stderr. The packages that could not be listed are
+// reported by a *ListError returned with the tests of the others.
+func parseTestList(ctx context.Context, stdout, stderr io.Reader) ([]domain.TestID, error) {
+	var tests []domain.TestID
+	var build domain.BuildOutput
+	packages := make(map[string]*listedPackage)
+	listed := func(pkg string) *listedPackage {
+		if packages[pkg] == nil {
+			packages[pkg] = &listedPackage{}
+		}
+		return packages[pkg]
+	}
+
+	handle := func(event domain.TestEvent) {
+		switch {
+		case event.Action == "build-output":
+			build.Add(event.Package, event.Output)
+		case event.Action == "build-fail":
+			build.End()
+		case event.Package == "" || event.Test != "":
+			// Only the output of packages lists tests
+		case event.Action == "output":
+			line := strings.TrimSpace(event.Output)
+			if isTestFuncName(line) {
+				tests = append(tests, domain.TestID{Pkg: event.Package, Name: line})
+			} else if pkg, ok := domain.ParseBuildFailed(event.Output); ok {
+				listed(pkg).build = true
+			} else if p := listed(event.Package); line != "" && !event.IsFrame() && p.output == "" {
+				p.output = line
+			}
+		case event.Action == "fail":
+			build.End()
+			p := listed(event.Package)
+			p.failed = true
+			if event.FailedBuild != "" {
+				p.build = true
+				p.dep = domain.TestedPackage(event.FailedBuild)
+			}
+		case event.Action == "pass" || event.Action == "skip":
+			build.End()
+		}
+	}
+
+	events, errs := NewTest2JsonDecoder(stdout).Decode(ctx)
+	for event := range events {
+		handle(event)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	// Toolchains before Go 1.24 write build output to stderr
+	events, errs = NewBuildOutputDecoder(stderr).Decode(ctx)
+	for event := range events {
+		handle(event)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	var listErr ListError
+	for pkg, p := range packages {
+		if p.failed {
+			listErr.Failures = append(listErr.Failures, ListFailure{Pkg: pkg, Reason: p.reason(pkg, build.Errors)})
+		}
+	}
+	if len(listErr.Failures) == 0 {
+		return tests, nil
+	}
+	sort.Slice(listErr.Failures, func(i, j int) bool {
+		return listErr.Failures[i].Pkg < listErr.Failures[j].Pkg
+	})
+	return tests, &listErr
+}
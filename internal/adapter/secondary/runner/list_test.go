@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+func TestParseTestList(t *testing.T) {
+	tests := []struct {
+		name           string
+		stdout, stderr string
+		want           []ListFailure
+	}{
+		{
+			name:   "build events",
+			stdout: "list.json",
+			want: []ListFailure{
+				{"example.com/bs/bad", "build failed: bad/bad_test.go:6:15: too many arguments in call to Add"},
+				{"example.com/bs/broken", `build failed: broken/broken.go:3:27: cannot use "value" (untyped string constant) as int value in return statement`},
+				{"example.com/bs/ext", "build failed: ext/ext_test.go:10:14: cannot use ext.Name() (value of type string) as int value in variable declaration"},
+				{"example.com/bs/user", `build of example.com/bs/broken failed: broken/broken.go:3:27: cannot use "value" (untyped string constant) as int value in return statement`},
+				{"example.com/ls/initp", "test binary failed: panic: no config"},
+				{"example.com/ls/setup", "build of example.com/nowhere/pkg failed: setup/setup.go:3:8: module example.com/nowhere/pkg: reading https://proxy.golang.org/example.com/nowhere/pkg/@v/list: 400 Bad Request"},
+			},
+		},
+		{
+			// Before Go 1.24 the fail events do not name the failed build
+			name:   "build output on stderr",
+			stdout: "list_text.json",
+			stderr: "list_stderr.txt",
+			want: []ListFailure{
+				{"example.com/bs/bad", "build failed: bad/bad_test.go:6:15: too many arguments in call to Add"},
+				{"example.com/bs/broken", `build failed: broken/broken.go:3:27: cannot use "value" (untyped string constant) as int value in return statement`},
+				{"example.com/bs/ext", "build failed: ext/ext_test.go:10:14: cannot use ext.Name() (value of type string) as int value in variable declaration"},
+				{"example.com/bs/user", "build failed"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr io.Reader = strings.NewReader("")
+			if tt.stderr != "" {
+				stderr = openFixture(t, tt.stderr)
+			}
+			got, err := parseTestList(context.Background(), openFixture(t, tt.stdout), stderr)
+
+			want := []domain.TestID{{Pkg: "example.com/bs/fails", Name: "TestFails"}, {Pkg: "example.com/bs/ok", Name: "TestOK"}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("tests = %v, want %v", got, want)
+			}
+			var listErr *ListError
+			if !errors.As(err, &listErr) {
+				t.Fatalf("error = %v, want a *ListError", err)
+			}
+			if !reflect.DeepEqual(listErr.Failures, tt.want) {
+				t.Errorf("failures =\n%q\nwant\n%q", listErr.Failures, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTestListListed(t *testing.T) {
+	stdout := strings.Join([]string{
+		`{"Action":"start","Package":"example.com/bs/ok"}`,
+		`{"Action":"output","Package":"example.com/bs/ok","Output":"TestOK\n"}`,
+		`{"Action":"output","Package":"example.com/bs/ok","Output":"ExampleOK\n"}`,
+		`{"Action":"output","Package":"example.com/bs/ok","Output":"ok  \texample.com/bs/ok\t0.002s\n"}`,
+		`{"Action":"pass","Package":"example.com/bs/ok"}`,
+		`{"Action":"output","Package":"example.com/bs/plain","Output":"?   \texample.com/bs/plain\t[no test files]\n"}`,
+		`{"Action":"skip","Package":"example.com/bs/plain"}`,
+	}, "\n")
+	got, err := parseTestList(context.Background(), strings.NewReader(stdout), strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.TestID{{Pkg: "example.com/bs/ok", Name: "TestOK"}, {Pkg: "example.com/bs/ok", Name: "ExampleOK"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tests = %v, want %v", got, want)
+	}
+}
+
+func TestListError(t *testing.T) {
+	one := &ListError{Failures: []ListFailure{{"example.com/bs/bad", "build failed"}}}
+	if got, want := one.Error(), "failed to list the tests of example.com/bs/bad: build failed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	two := &ListError{Failures: []ListFailure{
+		{"example.com/bs/bad", "build failed"},
+		{"example.com/ls/initp", "test binary failed"},
+	}}
+	want := "failed to list the tests of 2 packages:\n\texample.com/bs/bad: build failed\n\texample.com/ls/initp: test binary failed"
+	if got := two.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got := two.Reason("example.com/ls/initp"); got != "test binary failed" {
+		t.Errorf("Reason(initp) = %q", got)
+	}
+	if got := two.Reason("example.com/bs/ok"); got != "" {
+		t.Errorf("Reason(ok) = %q, want none", got)
+	}
+}
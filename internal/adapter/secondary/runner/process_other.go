@@ -0,0 +1,35 @@
+//go:build !unix
+
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// setProcessGroup is a no-op without process groups; cancelling only
+// reaches the go command itself
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// interruptGroup kills cmd; there are no signals to interrupt it with
+func interruptGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// quitGroup kills cmd; there is no SIGQUIT to collect goroutine dumps with
+func quitGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killGroup kills cmd
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// quitProcesses cannot signal processes without SIGQUIT
+func quitProcesses(ctx context.Context, name string) (int, error) {
+	return 0, errors.Newf("signalling test binaries is not supported on %s", runtime.GOOS)
+}
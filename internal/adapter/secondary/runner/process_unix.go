@@ -0,0 +1,100 @@
+//go:build unix
+
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+// setProcessGroup starts cmd in a process group of its own, so signals
+// reach the compilers and test binaries go starts, not just go itself
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptGroup sends SIGINT to the process group of cmd
+func interruptGroup(cmd *exec.Cmd) error {
+	return signalGroup(cmd, syscall.SIGINT)
+}
+
+// quitGroup sends SIGQUIT to the process group of cmd; test binaries exit
+// with a dump of their goroutines
+func quitGroup(cmd *exec.Cmd) error {
+	return signalGroup(cmd, syscall.SIGQUIT)
+}
+
+// killGroup sends SIGKILL to the process group of cmd
+func killGroup(cmd *exec.Cmd) error {
+	return signalGroup(cmd, syscall.SIGKILL)
+}
+
+func signalGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	err := syscall.Kill(-cmd.Process.Pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
+
+// quitProcesses sends SIGQUIT to the processes whose executable is named
+// name in the process groups this process started, returning how many
+func quitProcesses(ctx context.Context, name string) (int, error) {
+	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "pgid=", "-o", "args=").Output()
+	if err != nil {
+		return 0, err
+	}
+
+	var processes []process
+	groups := make(map[int]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		p, ok := parseProcess(line)
+		if !ok {
+			continue
+		}
+		processes = append(processes, p)
+		// Each command a run starts leads its own group
+		if p.pid == p.pgid && p.ppid == os.Getpid() {
+			groups[p.pgid] = true
+		}
+	}
+
+	n := 0
+	for _, p := range processes {
+		if groups[p.pgid] && p.exe == name && syscall.Kill(p.pid, syscall.SIGQUIT) == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// process is a line of ps output
+type process struct {
+	pid, ppid, pgid int
+	// exe is the base name of the executable
+	exe string
+}
+
+// parseProcess parses a "pid ppid pgid args..." line of ps output
+func parseProcess(line string) (process, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return process{}, false
+	}
+	ids := make([]int, 3)
+	for i := range ids {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return process{}, false
+		}
+		ids[i] = n
+	}
+	return process{pid: ids[0], ppid: ids[1], pgid: ids[2], exe: filepath.Base(fields[3])}, true
+}
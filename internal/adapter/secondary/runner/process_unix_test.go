@@ -0,0 +1,202 @@
+//go:build unix
+
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// helperEnv makes the test binary run TestHelperProcess as the command of a
+// run; its value is what the helper does on a signal
+const helperEnv = "LAZYGOTEST_HELPER_PROCESS"
+
+// TestHelperProcess is the process the cancellation tests run. It prints
+// "ready", then "ignore" ignores SIGQUIT and SIGINT while "report" prints
+// the first of them it gets and exits.
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	switch mode {
+	case "ignore":
+		signal.Ignore(syscall.SIGQUIT, syscall.SIGINT)
+	case "report":
+		signal.Notify(signals, syscall.SIGQUIT, syscall.SIGINT)
+	}
+	fmt.Println("ready")
+	select {
+	case sig := <-signals:
+		fmt.Println(sig)
+		os.Exit(0)
+	case <-time.After(time.Minute):
+		os.Exit(1)
+	}
+}
+
+// startHelper starts the helper process through command and waits for it
+// to be ready, returning the rest of its output
+func startHelper(t *testing.T, ctx context.Context, mode string, opts RunOptions) (*exec.Cmd, *bufio.Reader) {
+	t.Helper()
+	opts.Env = append(opts.Env, helperEnv+"="+mode)
+	cmd := command(ctx, opts, os.Args[0], "-test.run=^TestHelperProcess$")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	out := bufio.NewReader(stdout)
+	if line, err := out.ReadString('\n'); line != "ready\n" {
+		t.Fatalf("helper printed %q, %v", line, err)
+	}
+	return cmd, out
+}
+
+func TestCommandCancelSignal(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RunOptions
+		want string
+	}{
+		{name: "tests", want: syscall.SIGQUIT.String()},
+		// Fuzzing is interrupted instead, to keep its report
+		{name: "fuzzing", opts: RunOptions{Fuzz: "^FuzzFind$"}, want: syscall.SIGINT.String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			cmd, out := startHelper(t, ctx, "report", tt.opts)
+
+			cancel()
+			got, _ := out.ReadString('\n')
+			// Wait reports the cancellation however the helper exits
+			_ = cmd.Wait()
+			if !cmd.ProcessState.Success() {
+				t.Errorf("helper exited with %v", cmd.ProcessState)
+			}
+			if got != tt.want+"\n" {
+				t.Errorf("helper got %q, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandCancelKills(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the quit grace period")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd, _ := startHelper(t, ctx, "ignore", RunOptions{})
+
+	cancel()
+	cancelled := time.Now()
+	err := cmd.Wait()
+	elapsed := time.Since(cancelled)
+
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGKILL {
+		t.Errorf("helper exited with %v, want killed", err)
+	}
+	if elapsed < QuitGrace || elapsed > cmd.WaitDelay {
+		t.Errorf("helper killed %s after the cancellation, want between %s and %s", elapsed, QuitGrace, cmd.WaitDelay)
+	}
+}
+
+// TestStuckTestDump checks the goroutine dump of a stuck test binary is
+// read, whether the run is cancelled or only the binary is quit
+func TestStuckTestDump(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a test binary")
+	}
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/stuck\n\ngo 1.23\n",
+		"stuck_test.go": "package stuck\n\nimport (\n\t\"fmt\"\n\t\"testing\"\n)\n\n" +
+			"func TestStuck(t *testing.T) {\n\tfmt.Println(\"stuck\")\n\tselect {}\n}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		stop func(ctx context.Context, cancel context.CancelFunc) error
+	}{
+		{
+			name: "cancel",
+			stop: func(ctx context.Context, cancel context.CancelFunc) error {
+				cancel()
+				return nil
+			},
+		},
+		{
+			name: "quit binary",
+			stop: func(ctx context.Context, cancel context.CancelFunc) error {
+				_, err := QuitTestBinary(ctx, "example.com/stuck")
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events, errs := NewTestRunner().Run(ctx, RunOptions{Packages: []string{"."}, Dir: dir, Timeout: "1m"})
+
+			var output strings.Builder
+			stopped := false
+			timeout := time.After(time.Minute)
+			for events != nil || errs != nil {
+				select {
+				case event, ok := <-events:
+					if !ok {
+						events = nil
+						continue
+					}
+					// Stopped once the test blocks
+					if event.Test == "TestStuck" && event.Output == "stuck\n" {
+						if err := tt.stop(ctx, cancel); err != nil {
+							t.Fatal(err)
+						}
+						stopped = true
+					}
+					output.WriteString(event.Output)
+				case err, ok := <-errs:
+					if !ok {
+						errs = nil
+						continue
+					}
+					t.Errorf("run error: %v", err)
+				case <-timeout:
+					t.Fatalf("run did not end; output:\n%s", output.String())
+				}
+			}
+
+			if !stopped {
+				t.Fatalf("TestStuck never blocked; output:\n%s", output.String())
+			}
+			// The dump shows where the test is stuck
+			for _, want := range []string{"SIGQUIT: quit", "example.com/stuck.TestStuck("} {
+				if !strings.Contains(output.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, output.String())
+				}
+			}
+		})
+	}
+}
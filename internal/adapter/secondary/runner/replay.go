@@ -0,0 +1,268 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+	"github.com/YuminosukeSato/lazygotest/pkg/logger"
+)
+
+// ReplayRunner replays recorded `go test -json` output instead of running go.
+// Events from several files are merged in time order.
+type ReplayRunner struct {
+	events []domain.TestEvent
+	// speed scales the recorded pacing: 0 replays instantly, 1 in real time,
+	// 2 twice as fast and so on
+	speed float64
+}
+
+// NewReplayRunner loads the recorded events from the given files
+func NewReplayRunner(paths []string, speed float64) (*ReplayRunner, error) {
+	if len(paths) == 0 {
+		return nil, errors.Invalid("replay", "at least one file is required")
+	}
+	if speed < 0 {
+		return nil, errors.Invalid("replay-speed", "must not be negative")
+	}
+
+	var events []domain.TestEvent
+	for _, path := range paths {
+		fileEvents, err := loadEvents(path)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, fileEvents...)
+	}
+
+	// Interleave shards recorded in parallel the way they happened
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	logger.Info("Loaded replay", "files", len(paths), "events", len(events))
+	return &ReplayRunner{events: events, speed: speed}, nil
+}
+
+// loadEvents decodes a test2json file. Build output has no timestamp, and
+// the decoder stamps plain text mixed into the log with the time it read
+// it; both inherit the time of the previous event.
+func loadEvents(path string) ([]domain.TestEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open replay file %s", path)
+	}
+	defer f.Close()
+
+	decoded, decodeErrs := NewTest2JsonDecoder(f).Decode(context.Background())
+
+	var events []domain.TestEvent
+	var last time.Time
+	for event := range decoded {
+		if event.Time.IsZero() || event.Action == "build-output" {
+			event.Time = last
+		}
+		last = event.Time
+		events = append(events, event)
+	}
+
+	if err := <-decodeErrs; err != nil {
+		return nil, errors.Wrapf(err, "failed to read replay file %s", path)
+	}
+
+	return events, nil
+}
+
+// Run streams the recorded events matching the options' packages and -run regex
+func (r *ReplayRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.TestEvent, <-chan error) {
+	events := make(chan domain.TestEvent, 100)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		filter, err := newReplayFilter(opts)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		var prev time.Time
+		for _, event := range r.events {
+			if !filter.match(event) {
+				continue
+			}
+
+			if r.speed > 0 && !prev.IsZero() && event.Time.After(prev) {
+				delay := time.Duration(float64(event.Time.Sub(prev)) / r.speed)
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = event.Time
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errs
+}
+
+// ListTests returns the top-level tests recorded for opts.Packages
+func (r *ReplayRunner) ListTests(ctx context.Context, opts RunOptions) ([]domain.TestID, error) {
+	return recordedTests(r.events, opts)
+}
+
+// recordedTests returns the top-level tests found in events for the
+// packages selected by opts, in order of appearance
+func recordedTests(events []domain.TestEvent, opts RunOptions) ([]domain.TestID, error) {
+	filter, err := newReplayFilter(RunOptions{Packages: opts.Packages})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[domain.TestID]bool)
+	var tests []domain.TestID
+	for _, event := range events {
+		if event.Test == "" || strings.Contains(event.Test, "/") || !filter.match(event) {
+			continue
+		}
+		id := domain.TestID{Pkg: event.Package, Name: event.Test}
+		if !seen[id] {
+			seen[id] = true
+			tests = append(tests, id)
+		}
+	}
+	return tests, nil
+}
+
+// Packages returns the packages present in the recording, in order of appearance
+func (r *ReplayRunner) Packages() []domain.PkgID {
+	seen := make(map[string]bool)
+	var pkgs []domain.PkgID
+	for _, event := range r.events {
+		if event.Package == "" || seen[event.Package] {
+			continue
+		}
+		seen[event.Package] = true
+		pkgs = append(pkgs, domain.PkgID(event.Package))
+	}
+	return pkgs
+}
+
+// replayFilter selects recorded events the way go test would for the options
+type replayFilter struct {
+	packages map[string]bool
+	// run and bench hold one regexp per subtest level
+	run   []*regexp.Regexp
+	bench []*regexp.Regexp
+}
+
+func newReplayFilter(opts RunOptions) (*replayFilter, error) {
+	f := &replayFilter{}
+
+	for _, pattern := range opts.Packages {
+		// Wildcard and relative patterns cannot be resolved without the
+		// module on disk, so they select every recorded package
+		if strings.Contains(pattern, "...") || strings.HasPrefix(pattern, ".") {
+			f.packages = nil
+			break
+		}
+		if f.packages == nil {
+			f.packages = make(map[string]bool)
+		}
+		f.packages[pattern] = true
+	}
+
+	if opts.RunRegex != "" {
+		levels, err := compileLevels(opts.RunRegex)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid -run regex")
+		}
+		f.run = levels
+	}
+
+	if opts.Bench != "" {
+		levels, err := compileLevels(opts.Bench)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid -bench regex")
+		}
+		f.bench = levels
+	}
+
+	return f, nil
+}
+
+func (f *replayFilter) match(event domain.TestEvent) bool {
+	// Output not attributed to a package (build errors) is always kept
+	if f.packages != nil && event.Package != "" && !f.packages[event.Package] {
+		return false
+	}
+	if event.Test == "" {
+		return true
+	}
+
+	// Benchmarks are selected by -bench when it is given
+	levels := f.run
+	if f.bench != nil && domain.KindOf(event.Test) == domain.KindBenchmark {
+		levels = f.bench
+	}
+
+	// Like go test, each level of the name must match the regexp of its
+	// level; subtests deeper than the pattern follow their parent
+	for i, part := range strings.Split(event.Test, "/") {
+		if i >= len(levels) {
+			break
+		}
+		if !levels[i].MatchString(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// compileLevels splits a -run or -bench pattern at the slashes outside
+// brackets and parentheses and compiles one regexp per subtest level
+func compileLevels(pattern string) ([]*regexp.Regexp, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			// Skip the escaped character
+			i++
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case '/':
+			if depth == 0 {
+				parts = append(parts, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, pattern[start:])
+
+	levels := make([]*regexp.Regexp, len(parts))
+	for i, part := range parts {
+		re, err := regexp.Compile(part)
+		if err != nil {
+			return nil, err
+		}
+		levels[i] = re
+	}
+	return levels, nil
+}
@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+func TestCompileLevels(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"TestA", []string{"TestA"}},
+		{"^(TestA)$/^(x|y)$", []string{"^(TestA)$", "^(x|y)$"}},
+		{"TestA/", []string{"TestA", ""}},
+		// Slashes within brackets, groups or escaped do not split levels
+		{"TestA/[a/b]", []string{"TestA", "[a/b]"}},
+		{"(a/b)/c", []string{"(a/b)", "c"}},
+		{`TestA\/x/y`, []string{`TestA\/x`, "y"}},
+	}
+	for _, tt := range tests {
+		levels, err := compileLevels(tt.pattern)
+		if err != nil {
+			t.Errorf("compileLevels(%q): %v", tt.pattern, err)
+			continue
+		}
+		got := make([]string, len(levels))
+		for i, level := range levels {
+			got[i] = level.String()
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("compileLevels(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := compileLevels("TestA/(x"); err == nil {
+		t.Error("compileLevels accepted an unclosed group")
+	}
+}
+
+// replayed runs r with opts and lists the events as "action package test",
+// leaving out output
+func replayed(t *testing.T, r *ReplayRunner, opts RunOptions) ([]string, error) {
+	t.Helper()
+	events, errs := r.Run(context.Background(), opts)
+	var got []string
+	for event := range events {
+		if event.Action != "output" {
+			got = append(got, strings.TrimSpace(event.Action+" "+event.Package+" "+event.Test))
+		}
+	}
+	return got, <-errs
+}
+
+func TestReplayRunner(t *testing.T) {
+	r, err := NewReplayRunner([]string{
+		filepath.Join("testdata", "replay_a.json"),
+		filepath.Join("testdata", "replay_b.json"),
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts RunOptions
+		want []string
+	}{
+		{
+			// The shards were recorded in parallel; the text line keeps the
+			// time of the event before it
+			name: "merged in time order",
+			want: []string{
+				"start example.com/rp/a",
+				"start example.com/rp/b",
+				"run example.com/rp/a TestA",
+				"run example.com/rp/b TestB",
+				"build-output example.com/rp/b",
+				"run example.com/rp/a TestA/one",
+				"pass example.com/rp/b TestB",
+				"pass example.com/rp/a TestA/one",
+				"run example.com/rp/b TestAB",
+				"run example.com/rp/a TestA/two",
+				"pass example.com/rp/b TestAB",
+				"fail example.com/rp/a TestA/two",
+				"fail example.com/rp/a TestA",
+				"pass example.com/rp/b",
+				"run example.com/rp/a BenchmarkA",
+				"pass example.com/rp/a BenchmarkA",
+				"fail example.com/rp/a",
+			},
+		},
+		{
+			name: "package",
+			opts: RunOptions{Packages: []string{"example.com/rp/b"}},
+			want: []string{
+				"start example.com/rp/b",
+				"run example.com/rp/b TestB",
+				"build-output example.com/rp/b",
+				"pass example.com/rp/b TestB",
+				"run example.com/rp/b TestAB",
+				"pass example.com/rp/b TestAB",
+				"pass example.com/rp/b",
+			},
+		},
+		{
+			// Subtests follow their parent
+			name: "run regex",
+			opts: RunOptions{Packages: []string{"example.com/rp/a"}, RunRegex: "^(TestA)$"},
+			want: []string{
+				"start example.com/rp/a",
+				"run example.com/rp/a TestA",
+				"run example.com/rp/a TestA/one",
+				"pass example.com/rp/a TestA/one",
+				"run example.com/rp/a TestA/two",
+				"fail example.com/rp/a TestA/two",
+				"fail example.com/rp/a TestA",
+				"fail example.com/rp/a",
+			},
+		},
+		{
+			name: "subtest level",
+			opts: RunOptions{Packages: []string{"./..."}, RunRegex: "^(TestA)$/^(one)$"},
+			want: []string{
+				"start example.com/rp/a",
+				"start example.com/rp/b",
+				"run example.com/rp/a TestA",
+				"build-output example.com/rp/b",
+				"run example.com/rp/a TestA/one",
+				"pass example.com/rp/a TestA/one",
+				"fail example.com/rp/a TestA",
+				"pass example.com/rp/b",
+				"fail example.com/rp/a",
+			},
+		},
+		{
+			// -bench selects the benchmarks, -run the other tests
+			name: "bench",
+			opts: RunOptions{Packages: []string{"example.com/rp/a"}, RunRegex: "^$", Bench: "."},
+			want: []string{
+				"start example.com/rp/a",
+				"run example.com/rp/a BenchmarkA",
+				"pass example.com/rp/a BenchmarkA",
+				"fail example.com/rp/a",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replayed(t, r, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replayed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	if _, err := replayed(t, r, RunOptions{RunRegex: "Test("}); err == nil {
+		t.Error("Run accepted an invalid -run regex")
+	}
+
+	listed, err := r.ListTests(context.Background(), RunOptions{Packages: []string{"example.com/rp/b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.TestID{{Pkg: "example.com/rp/b", Name: "TestB"}, {Pkg: "example.com/rp/b", Name: "TestAB"}}
+	if !reflect.DeepEqual(listed, want) {
+		t.Errorf("ListTests = %v, want %v", listed, want)
+	}
+	wantPkgs := []domain.PkgID{"example.com/rp/a", "example.com/rp/b"}
+	if got := r.Packages(); !reflect.DeepEqual(got, wantPkgs) {
+		t.Errorf("Packages = %v, want %v", got, wantPkgs)
+	}
+}
+
+func TestNewReplayRunnerErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		speed float64
+		field string
+	}{
+		{name: "no files", field: "replay"},
+		{name: "negative speed", paths: []string{filepath.Join("testdata", "replay_a.json")}, speed: -1, field: "replay-speed"},
+		{name: "missing file", paths: []string{filepath.Join("testdata", "missing.json")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReplayRunner(tt.paths, tt.speed)
+			var invalid *errors.ValidationError
+			switch {
+			case err == nil:
+				t.Fatal("NewReplayRunner succeeded")
+			case tt.field != "" && (!errors.As(err, &invalid) || invalid.Field != tt.field):
+				t.Errorf("NewReplayRunner = %v, want an invalid %s", err, tt.field)
+			}
+		})
+	}
+}
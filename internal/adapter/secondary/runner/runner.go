@@ -1,27 +1,72 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"os"
 	"os/exec"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/YuminosukeSato/lazygotest/internal/domain"
 	"github.com/YuminosukeSato/lazygotest/pkg/errors"
 	"github.com/YuminosukeSato/lazygotest/pkg/logger"
 )
 
+// QuitGrace is how long the processes of a cancelled run have to exit
+// after SIGQUIT, while test binaries dump their goroutines, before they are
+// killed
+const QuitGrace = 5 * time.Second
+
 // RunOptions configures test execution
 type RunOptions struct {
-	Packages     []string
-	RunRegex     string
-	Tags         string
-	Race         bool
-	Cover        bool
-	Verbose      bool
-	Parallel     int
-	Timeout      string
-	CoverProfile string
+	Packages        []string
+	RunRegex        string
+	Tags            string
+	Race            bool
+	Cover           bool
+	Short           bool
+	Verbose         bool
+	Parallel        int
+	PackageParallel int
+	Timeout         string
+	CoverProfile    string
+	// Bench is the -bench regexp selecting the benchmarks to run; empty
+	// runs none
+	Bench string
+	// Benchtime is the -benchtime value, a duration or an iteration count
+	// such as 100x
+	Benchtime string
+	Benchmem  bool
+	// Fuzz is the -fuzz regexp; it must match exactly one fuzz target of a
+	// single package
+	Fuzz string
+	// Fuzztime is the -fuzztime value, a duration or an iteration count;
+	// empty fuzzes until interrupted
+	Fuzztime string
+	// Count is the -count value; 0 leaves it to go test
+	Count int
+	// Env holds extra NAME=VALUE environment variables for the go command
+	Env []string
+	// Unsetenv names variables removed from the inherited environment
+	Unsetenv []string
+	// Dir is the directory (module root) go runs in; empty means the
+	// working directory
+	Dir string
+}
+
+// EnvOverrides lists the environment changes for display and records:
+// NAME=VALUE for set variables and -NAME for unset ones
+func (o RunOptions) EnvOverrides() []string {
+	overrides := make([]string, 0, len(o.Env)+len(o.Unsetenv))
+	overrides = append(overrides, o.Env...)
+	for _, name := range o.Unsetenv {
+		overrides = append(overrides, "-"+name)
+	}
+	return overrides
 }
 
 // TestRunner executes go test commands
@@ -34,6 +79,99 @@ func NewTestRunner() *TestRunner {
 
 // Run executes tests with the given options and streams events
 func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.TestEvent, <-chan error) {
+	args := r.buildArgs(opts)
+	logger.Info("Running go test", "args", strings.Join(args, " "))
+
+	return streamCommand(ctx, command(ctx, opts, "go", args...))
+}
+
+// command creates a command that runs in the options' directory with their
+// extra environment. It runs in a process group of its own; cancelling ctx
+// sends the group SIGQUIT and kills it after QuitGrace.
+func command(ctx context.Context, opts RunOptions, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = opts.Dir
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		stop := quitGroup
+		if opts.Fuzz != "" {
+			// Fuzzing stops cleanly on an interrupt; goroutine dumps of the
+			// workers would bury its report
+			stop = interruptGroup
+		}
+		time.AfterFunc(QuitGrace, func() {
+			if err := killGroup(cmd); err == nil {
+				logger.Warn("Killed test processes still running after SIGQUIT", "pid", cmd.Process.Pid)
+			}
+		})
+		return stop(cmd)
+	}
+	// Wait gives up on the group after the kill
+	cmd.WaitDelay = QuitGrace + time.Second
+	if len(opts.Env) == 0 && len(opts.Unsetenv) == 0 {
+		return cmd
+	}
+
+	unset := make(map[string]bool, len(opts.Unsetenv))
+	for _, name := range opts.Unsetenv {
+		unset[name] = true
+	}
+	for _, pair := range os.Environ() {
+		if name, _, _ := strings.Cut(pair, "="); !unset[name] {
+			cmd.Env = append(cmd.Env, pair)
+		}
+	}
+	// Later entries win, so the overrides replace inherited values
+	cmd.Env = append(cmd.Env, opts.Env...)
+	return cmd
+}
+
+// QuitTestBinary sends SIGQUIT to the running test binary of the package
+// pkg, among the runs this process started: the binary prints a dump of its
+// goroutines and exits, failing the test it was running. Packages whose
+// import paths end in the same element share binary names; all of their
+// running binaries get the signal. It returns how many were signalled.
+func QuitTestBinary(ctx context.Context, pkg string) (int, error) {
+	n, err := quitProcesses(ctx, testBinaryName(pkg))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to signal the test binary")
+	}
+	if n == 0 {
+		return 0, errors.Newf("no test binary of %s is running", pkg)
+	}
+	return n, nil
+}
+
+// testBinaryName returns the name go test gives the test binary of pkg:
+// the last element of its import path, or the one before a major version
+// suffix such as v2
+func testBinaryName(pkg string) string {
+	name := path.Base(pkg)
+	if parent := path.Dir(pkg); parent != "." && majorVersionRegex.MatchString(name) {
+		name = path.Base(parent)
+	}
+	return name + ".test"
+}
+
+var majorVersionRegex = regexp.MustCompile(`^v[0-9]+$`)
+
+// GOROOT returns the GOROOT of the go command run in dir, which may be a
+// toolchain it switched to, whose standard library the tests are built with
+func GOROOT(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOROOT")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get GOROOT")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// streamCommand starts cmd and streams the test2json events it writes to
+// stdout and stderr. The streams are read to the end even after ctx is
+// cancelled, so the output of processes quitting, such as goroutine dumps,
+// is kept; the caller must drain the channels until they are closed.
+func streamCommand(ctx context.Context, cmd *exec.Cmd) (<-chan domain.TestEvent, <-chan error) {
 	events := make(chan domain.TestEvent, 100)
 	errs := make(chan error, 1)
 
@@ -41,11 +179,6 @@ func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.Te
 		defer close(events)
 		defer close(errs)
 
-		args := r.buildArgs(opts)
-		logger.Info("Running go test", "args", strings.Join(args, " "))
-
-		cmd := exec.CommandContext(ctx, "go", args...)
-
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
 			errs <- errors.Wrap(err, "failed to get pipe")
@@ -59,17 +192,18 @@ func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.Te
 		}
 
 		if err := cmd.Start(); err != nil {
-			errs <- errors.Wrap(err, "failed to start go test command")
+			errs <- errors.Wrap(err, "failed to start test command")
 			return
 		}
 
-		// Decode stdout (test2json output)
+		// Decode stdout (test2json output) until the processes exit
+		readCtx := context.WithoutCancel(ctx)
 		decoder := NewTest2JsonDecoder(stdout)
-		decodedEvents, decodeErrs := decoder.Decode(ctx)
+		decodedEvents, decodeErrs := decoder.Decode(readCtx)
 
 		// Also capture stderr for build errors
-		stderrDecoder := NewTest2JsonDecoder(stderr)
-		stderrEvents, stderrErrs := stderrDecoder.Decode(ctx)
+		stderrDecoder := NewBuildOutputDecoder(stderr)
+		stderrEvents, stderrErrs := stderrDecoder.Decode(readCtx)
 
 		// Merge streams
 		done := make(chan struct{})
@@ -85,11 +219,7 @@ func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.Te
 						}
 						continue
 					}
-					select {
-					case events <- event:
-					case <-ctx.Done():
-						return
-					}
+					events <- event
 				case event, ok := <-stderrEvents:
 					if !ok {
 						stderrEvents = nil
@@ -98,11 +228,7 @@ func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.Te
 						}
 						continue
 					}
-					select {
-					case events <- event:
-					case <-ctx.Done():
-						return
-					}
+					events <- event
 				case err := <-decodeErrs:
 					if err != nil {
 						errs <- err
@@ -111,8 +237,6 @@ func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.Te
 					if err != nil {
 						errs <- err
 					}
-				case <-ctx.Done():
-					return
 				}
 			}
 		}()
@@ -120,9 +244,9 @@ func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.Te
 		<-done
 
 		if err := cmd.Wait(); err != nil {
-			// Non-zero exit code is expected for failing tests
-			if _, ok := err.(*exec.ExitError); !ok {
-				errs <- errors.Wrap(err, "go test command failed unexpectedly")
+			// Non-zero exit code is expected for failing and cancelled tests
+			if _, ok := err.(*exec.ExitError); !ok && ctx.Err() == nil {
+				errs <- errors.Wrap(err, "test command failed unexpectedly")
 			}
 		}
 	}()
@@ -132,12 +256,10 @@ func (r *TestRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.Te
 
 // buildArgs constructs the go test command arguments
 func (r *TestRunner) buildArgs(opts RunOptions) []string {
+	// -json implies verbose output, so Verbose needs no -v; passing it
+	// would stop newer toolchains from marking the output of t.Error
 	args := []string{"test", "-json"}
 
-	if opts.Verbose {
-		args = append(args, "-v")
-	}
-
 	if opts.Race {
 		args = append(args, "-race")
 	}
@@ -149,10 +271,35 @@ func (r *TestRunner) buildArgs(opts RunOptions) []string {
 		}
 	}
 
+	if opts.Short {
+		args = append(args, "-short")
+	}
+
 	if opts.RunRegex != "" {
 		args = append(args, "-run", opts.RunRegex)
 	}
 
+	if opts.Bench != "" {
+		args = append(args, "-bench", opts.Bench)
+		if opts.Benchtime != "" {
+			args = append(args, "-benchtime", opts.Benchtime)
+		}
+		if opts.Benchmem {
+			args = append(args, "-benchmem")
+		}
+	}
+
+	if opts.Fuzz != "" {
+		args = append(args, "-fuzz", opts.Fuzz)
+		if opts.Fuzztime != "" {
+			args = append(args, "-fuzztime", opts.Fuzztime)
+		}
+	}
+
+	if opts.Count > 0 {
+		args = append(args, "-count="+strconv.Itoa(opts.Count))
+	}
+
 	if opts.Tags != "" {
 		args = append(args, "-tags", opts.Tags)
 	}
@@ -161,6 +308,10 @@ func (r *TestRunner) buildArgs(opts RunOptions) []string {
 		args = append(args, "-parallel="+strconv.Itoa(opts.Parallel))
 	}
 
+	if opts.PackageParallel > 0 {
+		args = append(args, "-p="+strconv.Itoa(opts.PackageParallel))
+	}
+
 	if opts.Timeout != "" {
 		args = append(args, "-timeout", opts.Timeout)
 	}
@@ -175,22 +326,42 @@ func (r *TestRunner) buildArgs(opts RunOptions) []string {
 	return args
 }
 
-// ListTests runs go test -list to discover test names
-func (r *TestRunner) ListTests(ctx context.Context, pkg string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "go", "test", "-list", ".", pkg)
+// ListTests runs go test -list to discover the top-level Test, Benchmark,
+// Fuzz and Example functions of opts.Packages in a single invocation. The
+// packages that could not be listed, such as packages that do not build,
+// are reported by a *ListError returned with the tests of the others.
+func (r *TestRunner) ListTests(ctx context.Context, opts RunOptions) ([]domain.TestID, error) {
+	args := []string{"test", "-list", ".", "-json"}
+	if opts.Tags != "" {
+		args = append(args, "-tags", opts.Tags)
+	}
+	if len(opts.Packages) > 0 {
+		args = append(args, opts.Packages...)
+	} else {
+		args = append(args, "./...")
+	}
+
+	cmd := command(ctx, opts, "go", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to list tests")
+		// Packages that fail to build exit non-zero; keep what was listed
+		if _, ok := err.(*exec.ExitError); !ok || len(output) == 0 {
+			return nil, errors.Wrap(err, "failed to list tests")
+		}
+		logger.Warn("Listing tests failed for some packages", "error", err)
 	}
 
-	lines := strings.Split(string(output), "\n")
-	var tests []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "Test") || strings.HasPrefix(line, "Benchmark") {
-			tests = append(tests, line)
+	return parseTestList(ctx, bytes.NewReader(output), &stderr)
+}
+
+// isTestFuncName reports whether a go test -list output line names a test function
+func isTestFuncName(line string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if strings.HasPrefix(line, prefix) && !strings.ContainsAny(line, " \t") {
+			return true
 		}
 	}
-
-	return tests, nil
+	return false
 }
@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+	"github.com/YuminosukeSato/lazygotest/pkg/logger"
+)
+
+// ErrRerunUnavailable is returned when a stream has been consumed and no
+// rerun command is configured
+var ErrRerunUnavailable = errors.New("re-running is not available for streamed input; configure rerun_command to enable it")
+
+// StreamRunner serves test2json events read from a stream such as stdin.
+// The stream can only be consumed once; later runs go to the rerun runner
+// when one is configured.
+type StreamRunner struct {
+	mu       sync.Mutex
+	reader   io.Reader
+	consumed bool
+	events   []domain.TestEvent
+	rerun    *CommandRunner
+}
+
+// NewStreamRunner creates a runner for the given stream. rerun may be nil.
+func NewStreamRunner(r io.Reader, rerun *CommandRunner) *StreamRunner {
+	return &StreamRunner{reader: r, rerun: rerun}
+}
+
+// CanRerun reports whether runs after the first one are possible
+func (r *StreamRunner) CanRerun() bool {
+	return r.rerun != nil
+}
+
+// Run streams the input on the first call and delegates to the rerun
+// command afterwards
+func (r *StreamRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.TestEvent, <-chan error) {
+	r.mu.Lock()
+	consumed := r.consumed
+	r.consumed = true
+	r.mu.Unlock()
+
+	if consumed {
+		if r.rerun == nil {
+			events := make(chan domain.TestEvent)
+			errs := make(chan error, 1)
+			errs <- ErrRerunUnavailable
+			close(events)
+			close(errs)
+			return events, errs
+		}
+		return r.rerun.Run(ctx, opts)
+	}
+
+	events := make(chan domain.TestEvent, 100)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		decoded, decodeErrs := NewTest2JsonDecoder(r.reader).Decode(ctx)
+		for event := range decoded {
+			r.mu.Lock()
+			r.events = append(r.events, event)
+			r.mu.Unlock()
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := <-decodeErrs; err != nil {
+			errs <- err
+		}
+	}()
+
+	return events, errs
+}
+
+// ListTests returns the top-level tests seen in the stream so far
+func (r *StreamRunner) ListTests(ctx context.Context, opts RunOptions) ([]domain.TestID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return recordedTests(r.events, opts)
+}
+
+// CommandRunner runs a user-configured shell command that prints test2json
+// output, e.g. "make test-json PKGS={packages} RUN={run}". The {packages}
+// and {run} placeholders are replaced with shell-quoted values. The command
+// is POSIX shell syntax and runs through sh on every platform; on Windows sh
+// must be on the PATH, as with Git for Windows.
+type CommandRunner struct {
+	template string
+}
+
+// NewCommandRunner creates a runner for the given command template
+func NewCommandRunner(template string) *CommandRunner {
+	return &CommandRunner{template: template}
+}
+
+// Run executes the command for the given options
+func (r *CommandRunner) Run(ctx context.Context, opts RunOptions) (<-chan domain.TestEvent, <-chan error) {
+	expanded := r.expand(opts)
+	logger.Info("Running rerun command", "command", expanded)
+
+	return streamCommand(ctx, command(ctx, opts, "sh", "-c", expanded))
+}
+
+// ListTests runs go test -list like the default runner
+func (r *CommandRunner) ListTests(ctx context.Context, opts RunOptions) ([]domain.TestID, error) {
+	return NewTestRunner().ListTests(ctx, opts)
+}
+
+// expand substitutes the placeholders in the template
+func (r *CommandRunner) expand(opts RunOptions) string {
+	packages := opts.Packages
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+
+	quoted := make([]string, len(packages))
+	for i, pkg := range packages {
+		quoted[i] = shellQuote(pkg)
+	}
+
+	// "." selects every test, so "-run {run}" stays valid for full runs
+	run := "."
+	if opts.RunRegex != "" {
+		run = shellQuote(opts.RunRegex)
+	}
+
+	return strings.NewReplacer(
+		"{packages}", strings.Join(quoted, " "),
+		"{run}", run,
+	).Replace(r.template)
+}
+
+var shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_./:@=+,-]+$`)
+
+// shellQuote quotes s for a POSIX shell unless it is obviously safe
+func shellQuote(s string) string {
+	if shellSafeRegex.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+)
+
+func TestStreamRunnerRerunUnavailable(t *testing.T) {
+	r := NewStreamRunner(openFixture(t, "replay_b.json"), nil)
+	if r.CanRerun() {
+		t.Error("CanRerun without a rerun command")
+	}
+
+	events, errs := r.Run(context.Background(), RunOptions{})
+	n := 0
+	for range events {
+		n++
+	}
+	if err := <-errs; err != nil || n != 7 {
+		t.Fatalf("first run streamed %d events, %v; want 7", n, err)
+	}
+	tests, err := r.ListTests(context.Background(), RunOptions{})
+	want := []domain.TestID{{Pkg: "example.com/rp/b", Name: "TestB"}, {Pkg: "example.com/rp/b", Name: "TestAB"}}
+	if err != nil || !reflect.DeepEqual(tests, want) {
+		t.Errorf("ListTests = %v, %v; want %v", tests, err, want)
+	}
+
+	// The stream is consumed
+	events, errs = r.Run(context.Background(), RunOptions{})
+	for event := range events {
+		t.Errorf("rerun streamed %+v", event)
+	}
+	if err := <-errs; !errors.Is(err, ErrRerunUnavailable) {
+		t.Errorf("rerun error = %v, want ErrRerunUnavailable", err)
+	}
+}
+
+func TestCommandRunnerExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		opts     RunOptions
+		want     string
+	}{
+		{
+			// A full run selects every test
+			name:     "defaults",
+			template: "go test -json {packages} -run {run}",
+			want:     "go test -json ./... -run .",
+		},
+		{
+			name:     "packages",
+			template: "make test-json PKGS=\"{packages}\"",
+			opts:     RunOptions{Packages: []string{"example.com/a", "./b/..."}},
+			want:     "make test-json PKGS=\"example.com/a ./b/...\"",
+		},
+		{
+			name:     "run pattern",
+			template: "go test -json {packages} -run {run}",
+			opts:     RunOptions{Packages: []string{"./pkg"}, RunRegex: "^(TestA|TestB)$/^(x)$"},
+			want:     "go test -json ./pkg -run '^(TestA|TestB)$/^(x)$'",
+		},
+		{
+			name:     "quote in the pattern",
+			template: "run {run}",
+			opts:     RunOptions{RunRegex: "^(TestA)$/^(it's)$"},
+			want:     `run '^(TestA)$/^(it'\''s)$'`,
+		},
+		{
+			name:     "space in a package",
+			template: "run {packages}",
+			opts:     RunOptions{Packages: []string{"./my dir"}},
+			want:     "run './my dir'",
+		},
+		{
+			name:     "repeated placeholders",
+			template: "{run} {packages} {run}",
+			opts:     RunOptions{Packages: []string{"./a"}, RunRegex: "TestA"},
+			want:     "TestA ./a TestA",
+		},
+		{
+			name:     "no placeholders",
+			template: "make test-json",
+			opts:     RunOptions{Packages: []string{"./a"}, RunRegex: "TestA"},
+			want:     "make test-json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCommandRunner(tt.template).expand(tt.opts); got != tt.want {
+				t.Errorf("expand = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestShellQuote checks the shell reads the quoted values back unchanged
+func TestShellQuote(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	values := []string{
+		"TestA",
+		"",
+		"^(TestA)$",
+		"^(TestA|TestB)$/^(x|y)$",
+		"Test$HOME",
+		"Test${PATH}",
+		"it's",
+		"''",
+		`a\b"c`,
+		"`date`",
+		"$(date)",
+		"a;b&c>d<e",
+		"*",
+		"with space\tand tab",
+		"line\nbreak",
+		"~",
+		"#x",
+	}
+	var args []string
+	for _, value := range values {
+		args = append(args, shellQuote(value))
+	}
+	// printf repeats its format for every argument
+	out, err := exec.Command("sh", "-c", "printf '%s\\0' "+strings.Join(args, " ")).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("sh read\n%q\nwant\n%q", got, values)
+	}
+}
+
+func TestCommandRunnerRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	// The lines that are not test2json come out as build output
+	r := NewCommandRunner("printf '%s\\n' {packages} {run}")
+	events, errs := r.Run(context.Background(), RunOptions{
+		Packages: []string{"./a b"},
+		RunRegex: "^(TestA)$/^(it's $x|y)$",
+	})
+	var got []string
+	for event := range events {
+		got = append(got, event.Output)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"./a b\n", "^(TestA)$/^(it's $x|y)$\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -32,6 +32,7 @@ func (d *Test2JsonDecoder) Decode(ctx context.Context) (<-chan domain.TestEvent,
 		defer close(events)
 		defer close(errs)
 
+		var build buildOutput
 		lineNum := 0
 		for d.reader.Scan() {
 			select {
@@ -45,15 +46,23 @@ func (d *Test2JsonDecoder) Decode(ctx context.Context) (<-chan domain.TestEvent,
 
 			var event domain.TestEvent
 			if err := json.Unmarshal(line, &event); err != nil {
-				logger.Warn("Failed to decode JSON line", "line", lineNum, "content", string(line))
-				// Send raw output as a fallback
-				events <- domain.TestEvent{
-					Action: "output",
-					Output: string(line) + "\n",
-				}
+				logger.Debug("Decoded non-JSON line as build output", "line", lineNum, "content", string(line))
+				// Text in the stream is what go test wrote to stderr, such
+				// as compiler errors, when both streams were piped together
+				events <- build.event(string(line))
 				continue
 			}
 
+			// Build output is written a package at a time, so an event
+			// ends the package of the text before it
+			build.end()
+
+			// Newer toolchains attribute build output to the package build
+			// rather than a tested package
+			if event.Package == "" && event.ImportPath != "" {
+				event.Package = event.BuildPackage()
+			}
+
 			logger.Debug("Decoded event",
 				"action", event.Action, "package", event.Package, "test", event.Test)
 
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Units of the metrics go test reports for every benchmark
+const (
+	UnitNsPerOp     = "ns/op"
+	UnitBytesPerOp  = "B/op"
+	UnitAllocsPerOp = "allocs/op"
+	UnitMBPerSec    = "MB/s"
+)
+
+// BenchmarkResult is one result line of a benchmark run, such as
+// "BenchmarkAdd-8  1000000  2.88 ns/op  0 B/op  0 allocs/op"
+type BenchmarkResult struct {
+	// ID names the benchmark without the GOMAXPROCS suffix
+	ID TestID
+	// Procs is the GOMAXPROCS suffix of the name, 0 when there is none
+	Procs      int
+	Iterations int64
+	// Metrics are the reported values in output order, including custom
+	// b.ReportMetric units
+	Metrics []BenchmarkMetric
+}
+
+// BenchmarkMetric is a value with its unit, such as 2.88 ns/op
+type BenchmarkMetric struct {
+	Value float64
+	Unit  string
+}
+
+// Metric returns the value reported for unit
+func (r BenchmarkResult) Metric(unit string) (float64, bool) {
+	for _, metric := range r.Metrics {
+		if metric.Unit == unit {
+			return metric.Value, true
+		}
+	}
+	return 0, false
+}
+
+// BenchmarkUnits returns the metric units of the results in the order they
+// first appear, for laying out one column per unit
+func BenchmarkUnits(results []BenchmarkResult) []string {
+	var units []string
+	seen := make(map[string]bool)
+	for _, result := range results {
+		for _, metric := range result.Metrics {
+			if !seen[metric.Unit] {
+				seen[metric.Unit] = true
+				units = append(units, metric.Unit)
+			}
+		}
+	}
+	return units
+}
+
+// ParseBenchmarkLine parses a complete benchmark result line of package pkg.
+// Other output, including the bare benchmark name go test prints first,
+// is reported as not a result. The name is kept as printed, with any
+// GOMAXPROCS suffix; BenchmarkNames tells it apart.
+func ParseBenchmarkLine(pkg, line string) (BenchmarkResult, bool) {
+	fields := strings.Fields(line)
+	// A name, the iteration count and at least one value/unit pair
+	if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+		return BenchmarkResult{}, false
+	}
+
+	iterations, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return BenchmarkResult{}, false
+	}
+
+	result := BenchmarkResult{
+		ID:         TestID{Pkg: pkg, Name: fields[0]},
+		Iterations: iterations,
+	}
+	for i := 2; i < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return BenchmarkResult{}, false
+		}
+		result.Metrics = append(result.Metrics, BenchmarkMetric{Value: value, Unit: fields[i+1]})
+	}
+	return result, true
+}
+
+// BenchmarkNames separates the GOMAXPROCS suffix from the names of the
+// result lines of a run. go test appends "-N" to the name of a benchmark
+// run with GOMAXPROCS N above 1, but sub-benchmark names may end in "-N"
+// themselves, such as BenchmarkEncode/size-100 run with GOMAXPROCS 1. The
+// zero value is ready to use.
+type BenchmarkNames struct {
+	names map[TestID]bool
+	// procs are the GOMAXPROCS values of the lines of each package
+	procs map[string]map[int]bool
+}
+
+// Add records the name of a benchmark of the run, as test2json reports it
+// when the benchmark starts
+func (n *BenchmarkNames) Add(id TestID) {
+	if n.names == nil {
+		n.names = make(map[TestID]bool)
+	}
+	n.names[id] = true
+}
+
+// ParseLine parses a benchmark result line of package pkg like
+// ParseBenchmarkLine. Unless the whole name is a known benchmark's, a "-N"
+// suffix is the GOMAXPROCS when the name without it is a benchmark's, when
+// the name is a top-level benchmark's, which cannot contain a dash, or when
+// N is the GOMAXPROCS of an earlier line of the package. In doubt the name
+// is kept whole.
+func (n *BenchmarkNames) ParseLine(pkg, line string) (BenchmarkResult, bool) {
+	result, ok := ParseBenchmarkLine(pkg, line)
+	if !ok {
+		return result, false
+	}
+
+	name := result.ID.Name
+	if i := strings.LastIndexByte(name, '-'); i > 0 {
+		procs, err := strconv.Atoi(name[i+1:])
+		base := TestID{Pkg: pkg, Name: name[:i]}
+		if err == nil && procs > 0 && !n.names[result.ID] &&
+			(n.names[base] || !strings.Contains(name, "/") || n.procs[pkg][procs]) {
+			result.ID = base
+			result.Procs = procs
+			if n.procs == nil {
+				n.procs = make(map[string]map[int]bool)
+			}
+			if n.procs[pkg] == nil {
+				n.procs[pkg] = make(map[int]bool)
+			}
+			n.procs[pkg][procs] = true
+		}
+	}
+	// Later lines may be the same benchmark with another GOMAXPROCS
+	n.Add(result.ID)
+	return result, true
+}
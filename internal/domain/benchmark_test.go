@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBenchmarkLine(t *testing.T) {
+	const pkg = "example.com/fs/bn"
+	tests := []struct {
+		name string
+		line string
+		want BenchmarkResult
+		ok   bool
+	}{
+		{
+			// The GOMAXPROCS suffix is left to BenchmarkNames
+			name: "benchmem",
+			line: "BenchmarkJoin-4    \t    2000\t       127.7 ns/op\t      64 B/op\t       1 allocs/op\n",
+			want: BenchmarkResult{
+				ID:         TestID{Pkg: pkg, Name: "BenchmarkJoin-4"},
+				Iterations: 2000,
+				Metrics:    []BenchmarkMetric{{127.7, UnitNsPerOp}, {64, UnitBytesPerOp}, {1, UnitAllocsPerOp}},
+			},
+			ok: true,
+		},
+		{
+			// -cpu 1 leaves the name without a suffix
+			name: "without procs",
+			line: "BenchmarkJoin      \t    2000\t        76.24 ns/op\n",
+			want: BenchmarkResult{
+				ID:         TestID{Pkg: pkg, Name: "BenchmarkJoin"},
+				Iterations: 2000,
+				Metrics:    []BenchmarkMetric{{76.24, UnitNsPerOp}},
+			},
+			ok: true,
+		},
+		{
+			// b.ReportMetric(0, "ns/op") drops the time
+			name: "custom metric",
+			line: "BenchmarkHit-4     \t    2000\t         0.3335 hits/op\t       0 B/op\t       0 allocs/op\n",
+			want: BenchmarkResult{
+				ID:         TestID{Pkg: pkg, Name: "BenchmarkHit-4"},
+				Iterations: 2000,
+				Metrics:    []BenchmarkMetric{{0.3335, "hits/op"}, {0, UnitBytesPerOp}, {0, UnitAllocsPerOp}},
+			},
+			ok: true,
+		},
+		{
+			name: "throughput of a repeated subbenchmark",
+			line: "BenchmarkSizes/n=11#01-4      \t    2000\t       828.1 ns/op\t1236.62 MB/s\t    1024 B/op\t       1 allocs/op\n",
+			want: BenchmarkResult{
+				ID:         TestID{Pkg: pkg, Name: "BenchmarkSizes/n=11#01-4"},
+				Iterations: 2000,
+				Metrics: []BenchmarkMetric{
+					{828.1, UnitNsPerOp}, {1236.62, UnitMBPerSec}, {1024, UnitBytesPerOp}, {1, UnitAllocsPerOp},
+				},
+			},
+			ok: true,
+		},
+		{
+			// test2json reports the name before the benchmark runs
+			name: "bare name",
+			line: "BenchmarkJoin-4    \t",
+		},
+		{name: "pass", line: "PASS\n"},
+		{name: "header", line: "cpu: Intel(R) Xeon(R) Processor\n"},
+		{name: "missing unit", line: "BenchmarkJoin-4 2000 127.7 ns/op 64\n"},
+		{name: "bad iterations", line: "BenchmarkJoin-4 many 127.7 ns/op\n"},
+		{name: "bad value", line: "BenchmarkJoin-4 2000 fast ns/op\n"},
+		{name: "log line", line: "    bn_test.go:12: Benchmark took 2000 ns/op\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseBenchmarkLine(pkg, tt.line)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBenchmarkLine(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBenchmarkNames(t *testing.T) {
+	const pkg = "example.com/bs"
+	type line struct {
+		pkg, text string
+	}
+	type parsed struct {
+		name  string
+		procs int
+	}
+	tests := []struct {
+		name string
+		// started are the benchmarks test2json reported starting
+		started []string
+		lines   []line
+		want    []parsed
+	}{
+		{
+			// Top-level names cannot contain a dash
+			name:  "top-level benchmark",
+			lines: []line{{pkg, "BenchmarkJoin-8 2000 127.7 ns/op"}, {pkg, "BenchmarkJoin 2000 76.2 ns/op"}},
+			want:  []parsed{{"BenchmarkJoin", 8}, {"BenchmarkJoin", 0}},
+		},
+		{
+			name:    "subbenchmarks ending in -N with GOMAXPROCS 1",
+			started: []string{"BenchmarkEncode", "BenchmarkEncode/size-100", "BenchmarkEncode/size-1000"},
+			lines: []line{
+				{pkg, "BenchmarkEncode/size-100 100 5.0 ns/op"},
+				{pkg, "BenchmarkEncode/size-1000 100 5.1 ns/op"},
+			},
+			want: []parsed{{"BenchmarkEncode/size-100", 0}, {"BenchmarkEncode/size-1000", 0}},
+		},
+		{
+			// Without the names of a -json run, as read from a text file
+			name: "subbenchmarks ending in -N in doubt",
+			lines: []line{
+				{pkg, "BenchmarkEncode/size-100 100 5.0 ns/op"},
+				{pkg, "BenchmarkParse/go-1.21-8 100 5.5 ns/op"},
+				// -count repeats the line
+				{pkg, "BenchmarkEncode/size-100 100 5.2 ns/op"},
+			},
+			want: []parsed{{"BenchmarkEncode/size-100", 0}, {"BenchmarkParse/go-1.21-8", 0}, {"BenchmarkEncode/size-100", 0}},
+		},
+		{
+			name:    "subbenchmark with GOMAXPROCS",
+			started: []string{"BenchmarkEncode/size-100"},
+			lines:   []line{{pkg, "BenchmarkEncode/size-100-8 100 5.0 ns/op"}},
+			want:    []parsed{{"BenchmarkEncode/size-100", 8}},
+		},
+		{
+			name:    "-cpu 1,100",
+			started: []string{"BenchmarkEncode/size-100"},
+			lines: []line{
+				{pkg, "BenchmarkEncode/size-100 100 5.0 ns/op"},
+				{pkg, "BenchmarkEncode/size-100-100 100 9.0 ns/op"},
+			},
+			want: []parsed{{"BenchmarkEncode/size-100", 0}, {"BenchmarkEncode/size-100", 100}},
+		},
+		{
+			// The first line names the benchmark the second repeats
+			name: "-cpu 1,4 in a text file",
+			lines: []line{
+				{pkg, "BenchmarkEncode/size-100 100 5.0 ns/op"},
+				{pkg, "BenchmarkEncode/size-100-4 100 8.0 ns/op"},
+			},
+			want: []parsed{{"BenchmarkEncode/size-100", 0}, {"BenchmarkEncode/size-100", 4}},
+		},
+		{
+			name: "GOMAXPROCS of a top-level benchmark",
+			lines: []line{
+				{pkg, "BenchmarkDecode-8 100 5.0 ns/op"},
+				{pkg, "BenchmarkEncode/size-100-8 100 5.0 ns/op"},
+				{"example.com/other", "BenchmarkEncode/size-100-8 100 5.0 ns/op"},
+				// Another round of a benchmark of the package
+				{pkg, "BenchmarkEncode/size-100-4 100 5.0 ns/op"},
+			},
+			want: []parsed{
+				{"BenchmarkDecode", 8},
+				{"BenchmarkEncode/size-100", 8},
+				// Another package's lines tell nothing
+				{"BenchmarkEncode/size-100-8", 0},
+				{"BenchmarkEncode/size-100", 4},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names BenchmarkNames
+			for _, name := range tt.started {
+				names.Add(TestID{Pkg: pkg, Name: name})
+			}
+			var got []parsed
+			for _, line := range tt.lines {
+				result, ok := names.ParseLine(line.pkg, line.text)
+				if !ok {
+					t.Fatalf("ParseLine(%q) is not a result", line.text)
+				}
+				got = append(got, parsed{result.ID.Name, result.Procs})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBenchmarkRun parses the output of go test -bench . -benchmem -cpu 1,4
+func TestBenchmarkRun(t *testing.T) {
+	var names BenchmarkNames
+	var results []BenchmarkResult
+	for _, line := range readLines(t, "bench.txt") {
+		if result, ok := names.ParseLine("example.com/fs/bn", line); ok {
+			results = append(results, result)
+		}
+	}
+	if len(results) != 8 {
+		t.Fatalf("parsed %d results, want 8", len(results))
+	}
+	for i, result := range results {
+		want := []string{"BenchmarkJoin", "BenchmarkHit", "BenchmarkSizes/n=11", "BenchmarkSizes/n=11#01"}[i/2]
+		if result.ID.Name != want || result.Procs != i%2*4 {
+			t.Errorf("result %d is %s with GOMAXPROCS %d, want %s with %d", i, result.ID.Name, result.Procs, want, i%2*4)
+		}
+	}
+
+	wantUnits := []string{UnitNsPerOp, UnitBytesPerOp, UnitAllocsPerOp, "hits/op", UnitMBPerSec}
+	if got := BenchmarkUnits(results); !reflect.DeepEqual(got, wantUnits) {
+		t.Errorf("BenchmarkUnits = %v, want %v", got, wantUnits)
+	}
+
+	tests := []struct {
+		result int
+		unit   string
+		want   float64
+		ok     bool
+	}{
+		{0, UnitNsPerOp, 76.24, true},
+		{1, UnitBytesPerOp, 64, true},
+		{2, "hits/op", 0.3335, true},
+		{2, UnitNsPerOp, 0, false},
+		{3, UnitMBPerSec, 0, false},
+		{6, UnitMBPerSec, 1191.86, true},
+	}
+	for _, tt := range tests {
+		result := results[tt.result]
+		if got, ok := result.Metric(tt.unit); got != tt.want || ok != tt.ok {
+			t.Errorf("%s-%d Metric(%q) = %v, %v, want %v, %v",
+				result.ID.Name, result.Procs, tt.unit, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultAlpha is the significance level below which a benchmark change is
+// reported instead of "~"
+const DefaultAlpha = 0.05
+
+// BenchmarkRun is the set of benchmark results of one run. With -count, a
+// benchmark has one result per round.
+type BenchmarkRun struct {
+	Label     string
+	StartedAt time.Time
+	Results   []BenchmarkResult
+}
+
+// BenchmarkStats summarizes the samples of one benchmark metric in a run
+type BenchmarkStats struct {
+	N        int
+	Mean     float64
+	Variance float64
+}
+
+// String formats the mean with its relative standard deviation, such as
+// "2.88 ± 3%"
+func (s BenchmarkStats) String() string {
+	if s.N == 0 {
+		return "-"
+	}
+	out := formatValue(s.Mean)
+	if s.N > 1 && s.Mean != 0 {
+		out += " ± " + strconv.Itoa(int(math.Round(math.Sqrt(s.Variance)/math.Abs(s.Mean)*100))) + "%"
+	}
+	return out
+}
+
+// BenchmarkDelta compares a benchmark metric between a baseline and a
+// candidate run
+type BenchmarkDelta struct {
+	ID        TestID
+	Unit      string
+	Baseline  BenchmarkStats
+	Candidate BenchmarkStats
+	// Percent is the change of the mean relative to the baseline
+	Percent float64
+	// P is the two-sided p-value of the Mann-Whitney U test
+	P float64
+	// Significant reports whether P is below the significance level
+	Significant bool
+}
+
+// Change formats the delta like benchstat: "~" when the change is not
+// significant, otherwise a signed percentage
+func (d BenchmarkDelta) Change() string {
+	if !d.Significant {
+		return "~"
+	}
+	return strconv.FormatFloat(d.Percent, 'f', 2, 64) + "%"
+}
+
+// CompareBenchmarks compares every benchmark metric reported by both runs,
+// ordered by unit and then by first appearance in the candidate. Changes
+// with a p-value below alpha are significant.
+func CompareBenchmarks(baseline, candidate BenchmarkRun, alpha float64) []BenchmarkDelta {
+	base := sampleSets(baseline.Results)
+	cand := sampleSets(candidate.Results)
+
+	var deltas []BenchmarkDelta
+	for _, unit := range BenchmarkUnits(candidate.Results) {
+		for _, id := range benchmarkIDs(candidate.Results) {
+			key := sampleKey{id: id, unit: unit}
+			x, y := base[key], cand[key]
+			if len(x) == 0 || len(y) == 0 {
+				continue
+			}
+
+			delta := BenchmarkDelta{
+				ID:        id,
+				Unit:      unit,
+				Baseline:  summarize(x),
+				Candidate: summarize(y),
+				P:         mannWhitneyP(x, y),
+			}
+			if delta.Baseline.Mean != 0 {
+				delta.Percent = (delta.Candidate.Mean - delta.Baseline.Mean) / delta.Baseline.Mean * 100
+			}
+			delta.Significant = delta.P < alpha && delta.Baseline.Mean != delta.Candidate.Mean
+			deltas = append(deltas, delta)
+		}
+	}
+	return deltas
+}
+
+// sampleKey identifies the samples of a benchmark metric
+type sampleKey struct {
+	id   TestID
+	unit string
+}
+
+// sampleSets groups the metric values of the results by benchmark and unit
+func sampleSets(results []BenchmarkResult) map[sampleKey][]float64 {
+	sets := make(map[sampleKey][]float64)
+	for _, result := range results {
+		for _, metric := range result.Metrics {
+			key := sampleKey{id: result.ID, unit: metric.Unit}
+			sets[key] = append(sets[key], metric.Value)
+		}
+	}
+	return sets
+}
+
+// benchmarkIDs returns the benchmarks of the results in order of first appearance
+func benchmarkIDs(results []BenchmarkResult) []TestID {
+	var ids []TestID
+	seen := make(map[TestID]bool)
+	for _, result := range results {
+		if !seen[result.ID] {
+			seen[result.ID] = true
+			ids = append(ids, result.ID)
+		}
+	}
+	return ids
+}
+
+// summarize computes the mean and the sample variance
+func summarize(samples []float64) BenchmarkStats {
+	stats := BenchmarkStats{N: len(samples)}
+	for _, v := range samples {
+		stats.Mean += v
+	}
+	stats.Mean /= float64(len(samples))
+
+	if len(samples) > 1 {
+		for _, v := range samples {
+			stats.Variance += (v - stats.Mean) * (v - stats.Mean)
+		}
+		stats.Variance /= float64(len(samples) - 1)
+	}
+	return stats
+}
+
+// maxExactSamples bounds the sample sizes for which the exact U
+// distribution is computed; larger samples use the normal approximation
+const maxExactSamples = 20
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test for
+// the hypothesis that x and y come from the same distribution
+func mannWhitneyP(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+
+	type sample struct {
+		value float64
+		fromX bool
+	}
+	all := make([]sample, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, sample{value: v, fromX: true})
+	}
+	for _, v := range y {
+		all = append(all, sample{value: v})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Rank the pooled samples, giving ties their average rank
+	var rankSumX, tieSum float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				rankSumX += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			tieSum += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSumX - float64(n1*(n1+1))/2
+
+	if tieSum == 0 && n1 <= maxExactSamples && n2 <= maxExactSamples {
+		return exactUP(n1, n2, u)
+	}
+
+	// Normal approximation with tie and continuity corrections
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := math.Max(math.Abs(u-mean)-0.5, 0) / math.Sqrt(variance)
+	return math.Min(math.Erfc(z/math.Sqrt2), 1)
+}
+
+// exactUP returns the two-sided p-value of the statistic u from the exact
+// distribution of U for samples of sizes n1 and n2 without ties
+func exactUP(n1, n2 int, u float64) float64 {
+	// counts[i][j][k] is the number of orderings of i x and j y samples
+	// with U = k; only the previous i is kept
+	maxU := n1 * n2
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = make([]float64, maxU+1)
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= i*j; k++ {
+				// The largest sample is either an x, which is greater than
+				// all j y samples, or a y, which adds nothing to U
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				cur[j][k] += cur[j-1][k]
+			}
+		}
+		prev = cur
+	}
+	counts := prev[n2]
+
+	var total, lower, upper float64
+	for k, c := range counts {
+		total += c
+		if float64(k) <= u {
+			lower += c
+		}
+		if float64(k) >= u {
+			upper += c
+		}
+	}
+	return math.Min(2*math.Min(lower, upper)/total, 1)
+}
+
+// formatValue formats a metric value with about four significant digits
+func formatValue(v float64) string {
+	if math.Abs(v) >= 1000 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// The expected p-values are those of R's wilcox.test(x, y), two-sided with
+// the continuity correction: the exact distribution of U for small samples
+// without ties, the normal approximation with the tie correction otherwise
+func TestMannWhitneyP(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64
+	}{
+		{
+			// The example of R's ?wilcox.test, W = 35, p = 0.1272 one-sided
+			name: "R example",
+			x:    []float64{0.80, 0.83, 1.89, 1.04, 1.45, 1.38, 1.91, 1.64, 0.73, 1.46},
+			y:    []float64{1.15, 0.88, 0.90, 0.74, 1.21},
+			want: 0.2544122544122544,
+		},
+		{
+			name: "three samples each, separated",
+			x:    []float64{1, 2, 3},
+			y:    []float64{4, 5, 6},
+			want: 0.1,
+		},
+		{
+			name: "five samples each, separated",
+			x:    []float64{1, 2, 3, 4, 5},
+			y:    []float64{6, 7, 8, 9, 10},
+			want: 0.007936507936507936,
+		},
+		{
+			name: "reversed samples",
+			x:    []float64{6, 7, 8, 9, 10},
+			y:    []float64{1, 2, 3, 4, 5},
+			want: 0.007936507936507936,
+		},
+		{
+			name: "interleaved",
+			x:    []float64{1, 3, 5, 7},
+			y:    []float64{2, 4, 6, 8},
+			want: 0.6857142857142857,
+		},
+		{
+			name: "seven benchmark rounds each",
+			x:    []float64{10.1, 10.3, 10.2, 10.4, 10.0, 10.5, 10.7},
+			y:    []float64{9.1, 9.4, 9.0, 9.3, 9.2, 9.5, 9.35},
+			want: 0.0005827505827505828,
+		},
+		{
+			// A tie within one sample leaves the exact distribution too
+			name: "tie within a sample",
+			x:    []float64{10.1, 10.3, 10.2, 10.4, 10.0, 10.5, 10.2, 10.6},
+			y:    []float64{9.1, 9.4, 9.0, 9.3, 9.2, 9.5, 9.35, 9.25},
+			want: 0.0009309722715750016,
+		},
+		{
+			name: "ties across samples",
+			x:    []float64{1, 2, 2, 3, 3},
+			y:    []float64{2, 3, 4, 4, 5},
+			want: 0.08567343955231692,
+		},
+		{
+			name: "tied benchmark rounds",
+			x:    []float64{100, 101, 101, 102, 103, 103},
+			y:    []float64{103, 104, 104, 105, 106, 106},
+			want: 0.007471721114744328,
+		},
+		{
+			name: "all tied",
+			x:    []float64{5, 5, 5},
+			y:    []float64{5, 5, 5},
+			want: 1,
+		},
+		{
+			name: "25 samples each",
+			x:    seq(1, 25, 0),
+			y:    seq(1, 25, 12.5),
+			want: 5.618306190480165e-06,
+		},
+		{
+			name: "30 and 25 samples",
+			x: []float64{102.576, 102.899, 100.133, 98.471, 97.816, 100.063, 97.956, 97.126, 100.399, 100.267,
+				101.093, 98.172, 100.01, 99.871, 96.988, 101.076, 100.641, 104.778, 100.406, 99.711,
+				102.466, 100.398, 101.818, 99.269, 100.436, 102.049, 101.392, 100.257, 97.835, 100.89},
+			y: []float64{101.654, 102.941, 101.932, 103.676, 101.397, 101.904, 102.834, 99.326, 100.697, 100.5,
+				105.461, 101.314, 102.804, 102.739, 100.938, 98.398, 103.43, 100.686, 102.936, 98.889,
+				100.624, 104.014, 104.362, 98.895, 98.834},
+			want: 0.0046364035729045535,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mannWhitneyP(tt.x, tt.y)
+			if math.Abs(got-tt.want) > 1e-6*math.Max(tt.want, 1e-3) {
+				t.Errorf("mannWhitneyP = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// The expected p-values are twice the tail of R's pwilcox(u, n1, n2)
+func TestExactUP(t *testing.T) {
+	tests := []struct {
+		n1, n2 int
+		u      float64
+		want   float64
+	}{
+		{3, 3, 0, 0.1},
+		{3, 3, 9, 0.1},
+		{3, 3, 2, 0.4},
+		{3, 3, 4.5, 1},
+		{4, 4, 6, 0.6857142857142857},
+		{10, 5, 35, 0.2544122544122544},
+		{5, 10, 15, 0.2544122544122544},
+		{1, 1, 0, 1},
+		{8, 8, 0, 2 / 12870.0},
+	}
+	for _, tt := range tests {
+		got := exactUP(tt.n1, tt.n2, tt.u)
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("exactUP(%d, %d, %v) = %v, want %v", tt.n1, tt.n2, tt.u, got, tt.want)
+		}
+	}
+}
+
+// rounds returns one result of the benchmark per value of unit
+func rounds(name, unit string, values ...float64) []BenchmarkResult {
+	results := make([]BenchmarkResult, len(values))
+	for i, value := range values {
+		results[i] = BenchmarkResult{
+			ID:         TestID{Pkg: "example.com/bs", Name: name},
+			Iterations: 1000,
+			Metrics:    []BenchmarkMetric{{Value: value, Unit: unit}},
+		}
+	}
+	return results
+}
+
+// benchRun concatenates the results into a run
+func benchRun(results ...[]BenchmarkResult) BenchmarkRun {
+	var all []BenchmarkResult
+	for _, r := range results {
+		all = append(all, r...)
+	}
+	return BenchmarkRun{Results: all}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	baseline := benchRun(
+		rounds("BenchmarkFast", UnitNsPerOp, 100, 101, 102, 103, 104),
+		rounds("BenchmarkSlow", UnitNsPerOp, 100, 101, 102, 103, 104),
+		rounds("BenchmarkSame", UnitNsPerOp, 1, 3, 5, 7),
+		rounds("BenchmarkFew", UnitNsPerOp, 10, 11, 12),
+		rounds("BenchmarkGone", UnitNsPerOp, 10, 11, 12),
+		rounds("BenchmarkFast", UnitAllocsPerOp, 2, 2, 2, 2, 2),
+	)
+	candidate := benchRun(
+		rounds("BenchmarkSlow", UnitNsPerOp, 120, 121, 122, 123, 124),
+		rounds("BenchmarkFast", UnitNsPerOp, 80, 81, 82, 83, 84),
+		rounds("BenchmarkSame", UnitNsPerOp, 2, 4, 6, 8),
+		rounds("BenchmarkFew", UnitNsPerOp, 20, 21, 22),
+		rounds("BenchmarkNew", UnitNsPerOp, 10, 11, 12),
+		rounds("BenchmarkFast", UnitAllocsPerOp, 2, 2, 2, 2, 2),
+		rounds("BenchmarkFast", UnitBytesPerOp, 64, 64, 64, 64, 64),
+	)
+
+	type row struct {
+		name, unit, change string
+		significant        bool
+	}
+	rows := func(deltas []BenchmarkDelta) []row {
+		out := make([]row, len(deltas))
+		for i, d := range deltas {
+			out[i] = row{d.ID.Name, d.Unit, d.Change(), d.Significant}
+		}
+		return out
+	}
+
+	// Ordered by unit, then as the candidate lists them; BenchmarkGone,
+	// BenchmarkNew and B/op are on one side only
+	got := CompareBenchmarks(baseline, candidate, DefaultAlpha)
+	want := []row{
+		{"BenchmarkSlow", UnitNsPerOp, "19.61%", true},
+		{"BenchmarkFast", UnitNsPerOp, "-19.61%", true},
+		// Interleaved samples
+		{"BenchmarkSame", UnitNsPerOp, "~", false},
+		// Three rounds each cannot reach p < 0.05
+		{"BenchmarkFew", UnitNsPerOp, "~", false},
+		// Equal samples
+		{"BenchmarkFast", UnitAllocsPerOp, "~", false},
+	}
+	if !reflect.DeepEqual(rows(got), want) {
+		t.Errorf("CompareBenchmarks =\n%v\nwant\n%v", rows(got), want)
+	}
+
+	fast := got[1]
+	if fast.Baseline.N != 5 || fast.Baseline.Mean != 102 || fast.Candidate.Mean != 82 ||
+		math.Abs(fast.P-0.007936507936507936) > 1e-9 {
+		t.Errorf("BenchmarkFast delta = %+v", fast)
+	}
+
+	// The cut-off is strict: p = 0.1 is significant only above 0.1
+	for alpha, significant := range map[float64]bool{0.1: false, 0.11: true} {
+		deltas := CompareBenchmarks(benchRun(rounds("BenchmarkFew", UnitNsPerOp, 10, 11, 12)),
+			benchRun(rounds("BenchmarkFew", UnitNsPerOp, 20, 21, 22)), alpha)
+		if len(deltas) != 1 || deltas[0].Significant != significant {
+			t.Errorf("alpha %v: deltas = %+v, want significant %v", alpha, deltas, significant)
+		}
+	}
+
+	if deltas := CompareBenchmarks(benchRun(), candidate, DefaultAlpha); len(deltas) != 0 {
+		t.Errorf("against an empty baseline: %+v", deltas)
+	}
+}
+
+// seq returns n values counting up from from, shifted by offset
+func seq(from, n int, offset float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(from+i) + offset
+	}
+	return values
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BuildError is a compiler or vet error go test reported while building a
+// package's test binary
+type BuildError struct {
+	// Pkg is the package whose build printed the error, as named by the
+	// "# pkg" header above it. A package that does not build also fails
+	// the packages whose tests import it.
+	Pkg string
+	// File is the path as printed, relative to the directory go test ran
+	// in unless absolute
+	File string
+	Line int
+	// Col is zero for tools that report no column
+	Col int
+	// Message continues over the indented lines that follow the error,
+	// joined by newlines
+	Message string
+}
+
+// Location formats the error's position as file:line:col
+func (e BuildError) Location() string {
+	location := e.File + ":" + strconv.Itoa(e.Line)
+	if e.Col > 0 {
+		location += ":" + strconv.Itoa(e.Col)
+	}
+	return location
+}
+
+// buildHeaderRegex matches the headers go prints above the output of a
+// package build: "# example.com/pkg", "# example.com/pkg_test
+// [example.com/pkg.test]" for the test variant of a package, and
+// "# [example.com/pkg]" above vet's findings
+var buildHeaderRegex = regexp.MustCompile(`^# (\S+(?: \[\S+\])?|\[\S+\])$`)
+
+// buildErrorRegex matches "file.go:12:5: message" and "file.go:12: message"
+var buildErrorRegex = regexp.MustCompile(`^(\S+\.(?:go|s|c|h|cc|cpp|m)):(\d+)(?::(\d+))?: (.*)$`)
+
+// buildFailedRegex matches the result line of a package whose test binary
+// could not be built: "FAIL\texample.com/pkg [build failed]"
+var buildFailedRegex = regexp.MustCompile(`^FAIL\t(\S+) \[(?:build|setup) failed\]$`)
+
+// ParseBuildHeader returns the package a build output header names
+func ParseBuildHeader(line string) (string, bool) {
+	m := buildHeaderRegex.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return "", false
+	}
+	return TestedPackage(m[1]), true
+}
+
+// TestedPackage returns the package whose tests the build of a package ID
+// is for. IDs name the variant built for a test binary in brackets, as in
+// "example.com/pkg_test [example.com/pkg.test]"; the output of building a
+// package's tests, including its external _test package, is attributed to
+// the package.
+func TestedPackage(id string) string {
+	if open := strings.LastIndex(id, "["); open >= 0 && strings.HasSuffix(id, "]") {
+		id = id[open+1 : len(id)-1]
+	}
+	id = strings.TrimSuffix(id, ".test")
+	return strings.TrimSuffix(id, "_test")
+}
+
+// ParseBuildFailed returns the package a "[build failed]" or "[setup
+// failed]" result line reports
+func ParseBuildFailed(line string) (string, bool) {
+	m := buildFailedRegex.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// BuildOutput collects the errors of build output fed to it line by line
+type BuildOutput struct {
+	// Errors are in the order they were printed
+	Errors []BuildError
+	pkg    string
+}
+
+// Add parses a line of build output, without its newline. Lines belong to
+// the package of the last header until End; pkg attributes the others.
+func (b *BuildOutput) Add(pkg, line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if header, ok := ParseBuildHeader(line); ok {
+		b.pkg = header
+		return
+	}
+	if b.pkg != "" {
+		pkg = b.pkg
+	}
+
+	if m := buildErrorRegex.FindStringSubmatch(line); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		b.Errors = append(b.Errors, BuildError{Pkg: pkg, File: m[1], Line: n, Col: col, Message: m[4]})
+		return
+	}
+	// Notes such as the candidates of an ambiguous call are indented below
+	// their error
+	if strings.HasPrefix(line, "\t") && len(b.Errors) > 0 {
+		last := &b.Errors[len(b.Errors)-1]
+		last.Message += "\n" + strings.TrimPrefix(line, "\t")
+	}
+}
+
+// End ends the output of the last header's package, once a package's
+// result shows its build is over
+func (b *BuildOutput) End() {
+	b.pkg = ""
+}
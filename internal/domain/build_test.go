@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBuildHeader(t *testing.T) {
+	tests := []struct {
+		line string
+		pkg  string
+		ok   bool
+	}{
+		{"# example.com/bs/broken", "example.com/bs/broken", true},
+		{"# example.com/bs/bad [example.com/bs/bad.test]\n", "example.com/bs/bad", true},
+		{"# example.com/bs/ext_test [example.com/bs/ext.test]", "example.com/bs/ext", true},
+		{"# [example.com/bs/bad]", "example.com/bs/bad", true},
+		{"# ./none/...", "./none/...", true},
+		{"#example.com/bs/bad", "", false},
+		{"# two words", "", false},
+		{"bad/bad_test.go:7:11: undefined: missing", "", false},
+	}
+	for _, tt := range tests {
+		pkg, ok := ParseBuildHeader(tt.line)
+		if pkg != tt.pkg || ok != tt.ok {
+			t.Errorf("ParseBuildHeader(%q) = %q, %v, want %q, %v", tt.line, pkg, ok, tt.pkg, tt.ok)
+		}
+	}
+}
+
+// buildErrors are those of the build output of the packages of
+// example.com/bs, run from the module root
+var buildErrors = []BuildError{
+	{
+		Pkg: "example.com/bs/bad", File: "bad/bad_test.go", Line: 6, Col: 15,
+		Message: "too many arguments in call to Add\nhave (number, number, number)\nwant (int, int)",
+	},
+	{Pkg: "example.com/bs/bad", File: "bad/bad_test.go", Line: 7, Col: 11, Message: "undefined: missing"},
+	{
+		Pkg: "example.com/bs/broken", File: "broken/broken.go", Line: 3, Col: 27,
+		Message: `cannot use "value" (untyped string constant) as int value in return statement`,
+	},
+	{
+		Pkg: "example.com/bs/ext", File: "ext/ext_test.go", Line: 10, Col: 14,
+		Message: "cannot use ext.Name() (value of type string) as int value in variable declaration",
+	},
+}
+
+func TestBuildOutput(t *testing.T) {
+	t.Run("build events", func(t *testing.T) {
+		// Go 1.24 and later report build output as events of the build
+		var build BuildOutput
+		for _, event := range readEvents(t, "build.json") {
+			switch event.Action {
+			case "build-output":
+				build.Add(event.BuildPackage(), event.Output)
+			case "build-fail":
+				build.End()
+			}
+		}
+		if !reflect.DeepEqual(build.Errors, buildErrors) {
+			t.Errorf("Errors = %+v, want %+v", build.Errors, buildErrors)
+		}
+	})
+
+	t.Run("stderr", func(t *testing.T) {
+		// Older toolchains write it to stderr, where only headers name
+		// the packages
+		var build BuildOutput
+		for _, line := range readLines(t, "build_stderr.txt") {
+			build.Add("", line)
+		}
+		if !reflect.DeepEqual(build.Errors, buildErrors) {
+			t.Errorf("Errors = %+v, want %+v", build.Errors, buildErrors)
+		}
+	})
+
+	t.Run("relative paths", func(t *testing.T) {
+		// go test ./ ../ext run from example.com/bs/bad
+		var build BuildOutput
+		for _, line := range []string{
+			"# example.com/bs/bad [example.com/bs/bad.test]",
+			"./bad_test.go:7:11: undefined: missing",
+			"# example.com/bs/ext_test [example.com/bs/ext.test]",
+			"../ext/ext_test.go:10:14: cannot use ext.Name() (value of type string) as int value in variable declaration",
+			"/tmp/bs/asm_amd64.s:3: unexpected EOF",
+		} {
+			build.Add("", line)
+		}
+		want := []string{"./bad_test.go:7:11", "../ext/ext_test.go:10:14", "/tmp/bs/asm_amd64.s:3"}
+		if len(build.Errors) != len(want) {
+			t.Fatalf("%d errors, want %d", len(build.Errors), len(want))
+		}
+		for i, e := range build.Errors {
+			if e.Location() != want[i] {
+				t.Errorf("error %d at %q, want %q", i, e.Location(), want[i])
+			}
+		}
+	})
+
+	t.Run("package ends", func(t *testing.T) {
+		var build BuildOutput
+		build.Add("example.com/bs/a", "a/a.go:1:1: before any header")
+		build.Add("example.com/bs/b", "# example.com/bs/broken")
+		build.Add("example.com/bs/b", "broken/broken.go:3:27: under the header")
+		build.End()
+		build.Add("example.com/bs/c", "c/c.go:2:1: after the package ended")
+		build.Add("example.com/bs/c", "\tmore of it")
+
+		want := []BuildError{
+			{Pkg: "example.com/bs/a", File: "a/a.go", Line: 1, Col: 1, Message: "before any header"},
+			{Pkg: "example.com/bs/broken", File: "broken/broken.go", Line: 3, Col: 27, Message: "under the header"},
+			{Pkg: "example.com/bs/c", File: "c/c.go", Line: 2, Col: 1, Message: "after the package ended\nmore of it"},
+		}
+		if !reflect.DeepEqual(build.Errors, want) {
+			t.Errorf("Errors = %+v, want %+v", build.Errors, want)
+		}
+	})
+}
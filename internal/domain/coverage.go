@@ -0,0 +1,299 @@
+package domain
+
+import (
+	"path"
+	"sort"
+)
+
+// CoverBlock is a block of statements from a coverage profile, such as
+// "example.com/mod/pkg/file.go:10.2,12.16 2 1"
+type CoverBlock struct {
+	// File is the file in import path form
+	File      string
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	// Statements is the number of statements in the block
+	Statements int
+	// Count is how often the block ran; set mode only records 0 or 1
+	Count int
+}
+
+// CoverageCounts counts the covered statements out of all statements
+type CoverageCounts struct {
+	Statements int
+	Covered    int
+}
+
+// Percent returns the covered share of the statements, 0 when there are none
+func (c CoverageCounts) Percent() float64 {
+	if c.Statements == 0 {
+		return 0
+	}
+	return float64(c.Covered) / float64(c.Statements) * 100
+}
+
+func (c *CoverageCounts) add(other CoverageCounts) {
+	c.Statements += other.Statements
+	c.Covered += other.Covered
+}
+
+// FileCoverage is the statement coverage of a source file
+type FileCoverage struct {
+	// File is the file in import path form
+	File string
+	CoverageCounts
+	// Blocks are sorted by position
+	Blocks []CoverBlock
+}
+
+// CoverState is the coverage of a position in a source file
+type CoverState int
+
+const (
+	// CoverNone marks code that is not instrumented, such as declarations
+	// and comments
+	CoverNone CoverState = iota
+	CoverMissed
+	CoverHit
+)
+
+// LineCoverage returns the state of every byte of line n (1-based), a
+// line of size bytes. Columns in the profile are 1-based byte offsets with
+// exclusive ends.
+func (f FileCoverage) LineCoverage(n, size int) []CoverState {
+	states := make([]CoverState, size)
+	for _, block := range f.Blocks {
+		if block.StartLine > n || block.EndLine < n {
+			continue
+		}
+		start, end := 0, size
+		if block.StartLine == n {
+			start = block.StartCol - 1
+		}
+		if block.EndLine == n {
+			end = block.EndCol - 1
+		}
+		state := CoverMissed
+		if block.Count > 0 {
+			state = CoverHit
+		}
+		// Later blocks are nested in or follow earlier ones, so they win
+		for i := max(start, 0); i < min(end, size); i++ {
+			states[i] = state
+		}
+	}
+	return states
+}
+
+// Name returns the base name of the file
+func (f FileCoverage) Name() string {
+	return path.Base(f.File)
+}
+
+// PackageCoverage is the statement coverage of a package and its files
+type PackageCoverage struct {
+	Pkg string
+	CoverageCounts
+	// Files are sorted by name
+	Files []FileCoverage
+}
+
+// Coverage is the statement coverage of a run, per package
+type Coverage struct {
+	// Mode is the profile's -covermode: set, count or atomic
+	Mode string
+	// Packages are sorted by import path
+	Packages []PackageCoverage
+}
+
+// NewCoverage aggregates profile blocks into per file and per package
+// counts. A block reported several times, as when profiles of several runs
+// are combined, counts once and is covered if any report covered it.
+func NewCoverage(mode string, blocks []CoverBlock) *Coverage {
+	type blockKey struct {
+		file                                 string
+		startLine, startCol, endLine, endCol int
+	}
+	merged := make(map[blockKey]CoverBlock, len(blocks))
+	for _, block := range blocks {
+		key := blockKey{block.File, block.StartLine, block.StartCol, block.EndLine, block.EndCol}
+		if seen, ok := merged[key]; ok {
+			block.Count += seen.Count
+		}
+		merged[key] = block
+	}
+
+	files := make(map[string]*FileCoverage)
+	for _, block := range merged {
+		file, ok := files[block.File]
+		if !ok {
+			file = &FileCoverage{File: block.File}
+			files[block.File] = file
+		}
+		file.Blocks = append(file.Blocks, block)
+		file.Statements += block.Statements
+		if block.Count > 0 {
+			file.Covered += block.Statements
+		}
+	}
+
+	packages := make(map[string]*PackageCoverage)
+	for _, file := range files {
+		pkg := path.Dir(file.File)
+		cov, ok := packages[pkg]
+		if !ok {
+			cov = &PackageCoverage{Pkg: pkg}
+			packages[pkg] = cov
+		}
+		cov.add(file.CoverageCounts)
+		cov.Files = append(cov.Files, *file)
+	}
+
+	coverage := &Coverage{Mode: mode}
+	for _, cov := range packages {
+		for _, file := range cov.Files {
+			sort.Slice(file.Blocks, func(i, j int) bool {
+				a, b := file.Blocks[i], file.Blocks[j]
+				if a.StartLine != b.StartLine {
+					return a.StartLine < b.StartLine
+				}
+				return a.StartCol < b.StartCol
+			})
+		}
+		sort.Slice(cov.Files, func(i, j int) bool { return cov.Files[i].File < cov.Files[j].File })
+		coverage.Packages = append(coverage.Packages, *cov)
+	}
+	sort.Slice(coverage.Packages, func(i, j int) bool { return coverage.Packages[i].Pkg < coverage.Packages[j].Pkg })
+	return coverage
+}
+
+// Package returns the coverage of the package pkg
+func (c *Coverage) Package(pkg string) (PackageCoverage, bool) {
+	for _, cov := range c.Packages {
+		if cov.Pkg == pkg {
+			return cov, true
+		}
+	}
+	return PackageCoverage{}, false
+}
+
+// Total sums the counts of all packages
+func (c *Coverage) Total() CoverageCounts {
+	var total CoverageCounts
+	for _, cov := range c.Packages {
+		total.add(cov.CoverageCounts)
+	}
+	return total
+}
+
+// CoverageIndex maps source lines to the tests that execute them, built
+// from a coverage profile per test
+type CoverageIndex struct {
+	// Tests are the indexed tests
+	Tests []TestID
+	// lines maps a file in import path form and a line to its tests
+	lines map[string]map[int][]TestID
+}
+
+// NewCoverageIndex creates an empty index
+func NewCoverageIndex() *CoverageIndex {
+	return &CoverageIndex{lines: make(map[string]map[int][]TestID)}
+}
+
+// Add records the lines of the covered blocks of test's profile, replacing
+// what the index recorded for test before
+func (x *CoverageIndex) Add(test TestID, blocks []CoverBlock) {
+	if x.Indexed(test) {
+		x.remove(map[TestID]bool{test: true})
+	}
+	x.Tests = append(x.Tests, test)
+	for _, block := range blocks {
+		if block.Count == 0 {
+			continue
+		}
+		lines, ok := x.lines[block.File]
+		if !ok {
+			lines = make(map[int][]TestID)
+			x.lines[block.File] = lines
+		}
+		for n := block.StartLine; n <= block.EndLine; n++ {
+			// Blocks of the same test are added together, so a line already
+			// attributed to it ends with it
+			if tests := lines[n]; len(tests) == 0 || tests[len(tests)-1] != test {
+				lines[n] = append(tests, test)
+			}
+		}
+	}
+}
+
+// Merge adds the tests of other, replacing what the index recorded for
+// tests indexed again. The index copies what it adds, so other is left as
+// it was.
+func (x *CoverageIndex) Merge(other *CoverageIndex) {
+	again := make(map[TestID]bool, len(other.Tests))
+	for _, test := range other.Tests {
+		again[test] = true
+	}
+	x.remove(again)
+	x.Tests = append(x.Tests, other.Tests...)
+
+	for file, others := range other.lines {
+		lines, ok := x.lines[file]
+		if !ok {
+			lines = make(map[int][]TestID, len(others))
+			x.lines[file] = lines
+		}
+		for n, tests := range others {
+			lines[n] = append(lines[n], tests...)
+		}
+	}
+}
+
+// remove drops tests and the lines recorded for them. The index owns its
+// slices, so they are filtered in place.
+func (x *CoverageIndex) remove(tests map[TestID]bool) {
+	indexed := x.Tests[:0]
+	for _, test := range x.Tests {
+		if !tests[test] {
+			indexed = append(indexed, test)
+		}
+	}
+	x.Tests = indexed
+
+	for file, lines := range x.lines {
+		for n, at := range lines {
+			kept := at[:0]
+			for _, test := range at {
+				if !tests[test] {
+					kept = append(kept, test)
+				}
+			}
+			if len(kept) == 0 {
+				delete(lines, n)
+			} else {
+				lines[n] = kept
+			}
+		}
+		if len(lines) == 0 {
+			delete(x.lines, file)
+		}
+	}
+}
+
+// Indexed reports whether test is in the index
+func (x *CoverageIndex) Indexed(test TestID) bool {
+	for _, indexed := range x.Tests {
+		if indexed == test {
+			return true
+		}
+	}
+	return false
+}
+
+// TestsAt returns the tests that execute line n of file, a file in import
+// path form
+func (x *CoverageIndex) TestsAt(file string, n int) []TestID {
+	return x.lines[file][n]
+}
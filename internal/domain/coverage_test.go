@@ -0,0 +1,300 @@
+package domain
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const calcFile = "example.com/cs/calc/calc.go"
+
+// calcBlocks are the blocks of a profile of calc.go, of the tests run,
+// for "sign" or "sum"
+func calcBlocks(run string) []CoverBlock {
+	sign, sum := 0, 0
+	switch run {
+	case "sign":
+		sign = 1
+	case "sum":
+		sum = 1
+	}
+	return []CoverBlock{
+		{File: calcFile, StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 11, Statements: 1, Count: sign},
+		{File: calcFile, StartLine: 6, StartCol: 3, EndLine: 7, EndCol: 1, Statements: 1, Count: sign},
+		{File: calcFile, StartLine: 7, StartCol: 9, EndLine: 7, EndCol: 18, Statements: 1, Count: 0},
+		{File: calcFile, StartLine: 7, StartCol: 20, EndLine: 7, EndCol: 30, Statements: 1, Count: 0},
+		{File: calcFile, StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 10, Statements: 1, Count: 0},
+		{File: calcFile, StartLine: 13, StartCol: 2, EndLine: 14, EndCol: 23, Statements: 2, Count: sum},
+		{File: calcFile, StartLine: 15, StartCol: 3, EndLine: 16, EndCol: 1, Statements: 1, Count: 2 * sum},
+		{File: calcFile, StartLine: 17, StartCol: 2, EndLine: 17, EndCol: 14, Statements: 1, Count: sum},
+	}
+}
+
+// calcSource is calc.go, whose lines the blocks are of
+var calcSource = strings.Split(`package calc
+
+// Sign returns the sign of n
+func Sign(n int) int {
+	if n < 0 {
+		return -1
+	} else if n > 0 { return 1 }
+	return 0
+}
+
+// Sum adds the numbers
+func Sum(ns ...int) int {
+	total := 0
+	for _, n := range ns {
+		total += n
+	}
+	return total
+}`, "\n")
+
+func TestNewCoverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []CoverBlock
+		covered int
+		blocksN int
+	}{
+		{"one profile", calcBlocks("sign"), 2, 8},
+		// A block counts once, covered if either profile covered it
+		{"merged profiles", append(calcBlocks("sign"), calcBlocks("sum")...), 6, 8},
+		{"merged with an uncovered profile", append(calcBlocks("sum"), calcBlocks("")...), 4, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coverage := NewCoverage("count", tt.blocks)
+			if len(coverage.Packages) != 1 || len(coverage.Packages[0].Files) != 1 {
+				t.Fatalf("coverage of %d packages, want 1 with 1 file", len(coverage.Packages))
+			}
+			file := coverage.Packages[0].Files[0]
+			want := CoverageCounts{Statements: 9, Covered: tt.covered}
+			if file.CoverageCounts != want || coverage.Packages[0].CoverageCounts != want || coverage.Total() != want {
+				t.Errorf("file %+v, package %+v, total %+v; want %+v",
+					file.CoverageCounts, coverage.Packages[0].CoverageCounts, coverage.Total(), want)
+			}
+			if len(file.Blocks) != tt.blocksN {
+				t.Errorf("%d blocks, want %d", len(file.Blocks), tt.blocksN)
+			}
+			for i := 1; i < len(file.Blocks); i++ {
+				a, b := file.Blocks[i-1], file.Blocks[i]
+				if a.StartLine > b.StartLine || a.StartLine == b.StartLine && a.StartCol >= b.StartCol {
+					t.Errorf("block %d at %d.%d after %d.%d", i, b.StartLine, b.StartCol, a.StartLine, a.StartCol)
+				}
+			}
+		})
+	}
+
+	t.Run("counts of merged blocks add up", func(t *testing.T) {
+		coverage := NewCoverage("count", append(calcBlocks("sum"), calcBlocks("sum")...))
+		blocks := coverage.Packages[0].Files[0].Blocks
+		if got := blocks[6].Count; got != 4 {
+			t.Errorf("count of the loop body = %d, want 4", got)
+		}
+	})
+}
+
+func TestNewCoveragePackages(t *testing.T) {
+	blocks := []CoverBlock{
+		{File: "example.com/m/b/b.go", StartLine: 1, EndLine: 2, Statements: 2, Count: 1},
+		{File: "example.com/m/a/z.go", StartLine: 1, EndLine: 2, Statements: 3, Count: 0},
+		{File: "example.com/m/a/a.go", StartLine: 5, EndLine: 6, Statements: 1, Count: 1},
+		{File: "example.com/m/a/a.go", StartLine: 1, EndLine: 2, Statements: 4, Count: 1},
+		// Same start, another end: another block
+		{File: "example.com/m/a/a.go", StartLine: 1, EndLine: 3, Statements: 1, Count: 0},
+	}
+	coverage := NewCoverage("set", blocks)
+
+	var layout []string
+	for _, pkg := range coverage.Packages {
+		for _, file := range pkg.Files {
+			layout = append(layout, file.Name())
+		}
+	}
+	if want := []string{"a.go", "z.go", "b.go"}; !reflect.DeepEqual(layout, want) {
+		t.Errorf("files %v, want %v", layout, want)
+	}
+
+	a, ok := coverage.Package("example.com/m/a")
+	if !ok || a.CoverageCounts != (CoverageCounts{Statements: 9, Covered: 5}) {
+		t.Errorf("Package(a) = %+v, %v; want 5 of 9 statements", a.CoverageCounts, ok)
+	}
+	if _, ok := coverage.Package("example.com/m"); ok {
+		t.Error("Package found the coverage of a package without blocks")
+	}
+	if total := coverage.Total(); total != (CoverageCounts{Statements: 11, Covered: 7}) || coverage.Mode != "set" {
+		t.Errorf("Total() = %+v in mode %q, want 7 of 11 in set", total, coverage.Mode)
+	}
+	if got := a.Percent(); got < 55.55 || got > 55.56 {
+		t.Errorf("Percent() = %v, want 55.6", got)
+	}
+	if got := (CoverageCounts{}).Percent(); got != 0 {
+		t.Errorf("Percent() without statements = %v, want 0", got)
+	}
+}
+
+func TestLineCoverage(t *testing.T) {
+	const (
+		n = CoverNone
+		m = CoverMissed
+		h = CoverHit
+	)
+	// states expands runs such as "n1 h9 n1" into states
+	states := func(runs string) []CoverState {
+		var expanded []CoverState
+		for _, run := range strings.Fields(runs) {
+			state := map[byte]CoverState{'n': n, 'm': m, 'h': h}[run[0]]
+			count := 0
+			for _, c := range run[1:] {
+				count = count*10 + int(c-'0')
+			}
+			for range count {
+				expanded = append(expanded, state)
+			}
+		}
+		return expanded
+	}
+
+	file := NewCoverage("count", calcBlocks("sign")).Packages[0].Files[0]
+	tests := []struct {
+		name string
+		line int
+		want string
+	}{
+		// "\tif n < 0 {": the block ends before the brace
+		{"one-line block", 5, "n1 h9 n1"},
+		// "\t\treturn -1": the block starts in the line's indentation
+		{"first line of a block", 6, "n2 h9"},
+		// "\t} else if n > 0 { return 1 }": the previous block ends at
+		// column 1, before the line's first byte
+		{"blocks within a line", 7, "n8 m9 n2 m10"},
+		{"declaration", 4, "n22"},
+		{"comment", 3, "n29"},
+		// "\t\ttotal += n" of the block running from line 15 into 16
+		{"block of another test", 15, "n2 m10"},
+		// "\t}": blocks end at column 1 of the line of their closing brace
+		{"last line of a block", 16, "n2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := calcSource[tt.line-1]
+			got := file.LineCoverage(tt.line, len(line))
+			if want := states(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("LineCoverage(%d) of %q = %v, want %v", tt.line, line, got, want)
+			}
+		})
+	}
+
+	t.Run("line shorter than the block", func(t *testing.T) {
+		if got := file.LineCoverage(7, 10); !reflect.DeepEqual(got, states("n8 m2")) {
+			t.Errorf("LineCoverage = %v, want the line's states", got)
+		}
+	})
+}
+
+// indexLines lists the tests at lines 1 to 20 of calc.go, as "line:Test"
+func indexLines(x *CoverageIndex) []string {
+	var lines []string
+	for n := 1; n <= 20; n++ {
+		for _, test := range x.TestsAt(calcFile, n) {
+			lines = append(lines, strconv.Itoa(n)+":"+test.Name)
+		}
+	}
+	return lines
+}
+
+func TestCoverageIndexAdd(t *testing.T) {
+	sign := TestID{Pkg: "example.com/cs/calc", Name: "TestSign"}
+	sum := TestID{Pkg: "example.com/cs/calc", Name: "TestSum"}
+
+	x := NewCoverageIndex()
+	x.Add(sign, calcBlocks("sign"))
+	x.Add(sum, calcBlocks("sum"))
+	// Uncovered blocks are left out; blocks spanning lines cover each
+	want := []string{"5:TestSign", "6:TestSign", "7:TestSign", "13:TestSum", "14:TestSum", "15:TestSum", "16:TestSum", "17:TestSum"}
+	if got := indexLines(x); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+	if !x.Indexed(sign) || !x.Indexed(sum) || x.Indexed(TestID{Pkg: "example.com/cs/calc", Name: "TestOther"}) {
+		t.Errorf("Indexed reports %v indexed, want TestSign and TestSum", x.Tests)
+	}
+	if got := x.TestsAt("example.com/cs/other.go", 5); got != nil {
+		t.Errorf("TestsAt of a file not indexed = %v", got)
+	}
+
+	// Both blocks of line 7 are TestSign's; it is listed once
+	x.Add(sum, append(calcBlocks("sum"), CoverBlock{File: calcFile, StartLine: 7, StartCol: 1, EndLine: 7, EndCol: 3, Count: 1}))
+	want = []string{"5:TestSign", "6:TestSign", "7:TestSign", "7:TestSum", "13:TestSum", "14:TestSum", "15:TestSum", "16:TestSum", "17:TestSum"}
+	if got := indexLines(x); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+
+	// Indexing a test again replaces its lines
+	x.Add(sign, calcBlocks(""))
+	want = []string{"7:TestSum", "13:TestSum", "14:TestSum", "15:TestSum", "16:TestSum", "17:TestSum"}
+	if got := indexLines(x); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines after indexing TestSign again = %v, want %v", got, want)
+	}
+	if want := []TestID{sum, sign}; !reflect.DeepEqual(x.Tests, want) {
+		t.Errorf("Tests = %v, want %v", x.Tests, want)
+	}
+}
+
+func TestCoverageIndexMerge(t *testing.T) {
+	sign := TestID{Pkg: "example.com/cs/calc", Name: "TestSign"}
+	sum := TestID{Pkg: "example.com/cs/calc", Name: "TestSum"}
+	other := TestID{Pkg: "example.com/cs/calc", Name: "TestOther"}
+
+	index := func(test TestID, run string) *CoverageIndex {
+		x := NewCoverageIndex()
+		x.Add(test, calcBlocks(run))
+		return x
+	}
+	first := index(sign, "sign")
+	second := index(sum, "sum")
+	// TestSign indexed again, now executing what TestSum does
+	third := index(sign, "sum")
+
+	x := NewCoverageIndex()
+	x.Merge(first)
+	x.Merge(second)
+	want := []string{"5:TestSign", "6:TestSign", "7:TestSign", "13:TestSum", "14:TestSum", "15:TestSum", "16:TestSum", "17:TestSum"}
+	if got := indexLines(x); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+
+	x.Merge(third)
+	want = []string{"13:TestSum", "13:TestSign", "14:TestSum", "14:TestSign", "15:TestSum", "15:TestSign",
+		"16:TestSum", "16:TestSign", "17:TestSum", "17:TestSign"}
+	if got := indexLines(x); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines after merging TestSign again = %v, want %v", got, want)
+	}
+	if want := []TestID{sum, sign}; !reflect.DeepEqual(x.Tests, want) {
+		t.Errorf("Tests = %v, want %v", x.Tests, want)
+	}
+
+	// Merging filters the index's lines in place, never those of the
+	// indexes merged into it
+	x.Merge(index(sum, ""))
+	x.Merge(index(other, "sign"))
+	x.Merge(index(sign, ""))
+	for _, c := range []struct {
+		name string
+		x    *CoverageIndex
+		want []string
+	}{
+		{"first", first, []string{"5:TestSign", "6:TestSign", "7:TestSign"}},
+		{"second", second, []string{"13:TestSum", "14:TestSum", "15:TestSum", "16:TestSum", "17:TestSum"}},
+		{"third", third, []string{"13:TestSign", "14:TestSign", "15:TestSign", "16:TestSign", "17:TestSign"}},
+	} {
+		if got := indexLines(c.x); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s index changed by merging: lines = %v, want %v", c.name, got, c.want)
+		}
+	}
+	want = []string{"5:TestOther", "6:TestOther", "7:TestOther"}
+	if got := indexLines(x); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+}
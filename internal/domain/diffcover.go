@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangedFile is a file a diff adds or modifies lines of
+type ChangedFile struct {
+	// Path is slash-separated and relative to the directory tests run in
+	Path string
+	// File is the file in import path form; empty outside a module
+	File string
+	// Lines are the added or modified lines, ascending
+	Lines []int
+}
+
+// FileDiffCoverage is the coverage of the changed lines of a file
+type FileDiffCoverage struct {
+	// Path is the changed file's path
+	Path string
+	// File is the file in import path form, as in the coverage profile
+	File string
+	// Statements counts the changed lines with statements and Covered
+	// those a test executed
+	CoverageCounts
+	// Uncovered are the changed lines no test executed, ascending
+	Uncovered []int
+}
+
+// UncoveredRanges formats the uncovered lines as ranges, such as "8, 12-14"
+func (f FileDiffCoverage) UncoveredRanges() string {
+	var ranges []string
+	for i := 0; i < len(f.Uncovered); {
+		j := i
+		for j+1 < len(f.Uncovered) && f.Uncovered[j+1] == f.Uncovered[j]+1 {
+			j++
+		}
+		r := strconv.Itoa(f.Uncovered[i])
+		if j > i {
+			r += "-" + strconv.Itoa(f.Uncovered[j])
+		}
+		ranges = append(ranges, r)
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
+// DiffCoverage is the coverage of the lines changed since a git base
+type DiffCoverage struct {
+	Base string
+	// Files are the changed files with statements on changed lines,
+	// sorted by path
+	Files []FileDiffCoverage
+	// Unmeasured are changed files of packages the run did not cover
+	Unmeasured []string
+}
+
+// Total sums the counts of all files
+func (d *DiffCoverage) Total() CoverageCounts {
+	var total CoverageCounts
+	for _, file := range d.Files {
+		total.add(file.CoverageCounts)
+	}
+	return total
+}
+
+// NewDiffCoverage checks the changed lines of non-test Go files against the
+// coverage of a run. Changed lines without statements, such as comments and
+// declarations, are not counted.
+func NewDiffCoverage(base string, changed []ChangedFile, coverage *Coverage) *DiffCoverage {
+	diff := &DiffCoverage{Base: base}
+	for _, change := range changed {
+		if change.File == "" || !strings.HasSuffix(change.File, ".go") || strings.HasSuffix(change.File, "_test.go") {
+			continue
+		}
+
+		cov, ok := coverage.Package(path.Dir(change.File))
+		if !ok {
+			diff.Unmeasured = append(diff.Unmeasured, change.Path)
+			continue
+		}
+		// Profiles leave out files without statements
+		i := sort.Search(len(cov.Files), func(i int) bool { return cov.Files[i].File >= change.File })
+		if i == len(cov.Files) || cov.Files[i].File != change.File {
+			continue
+		}
+
+		states := cov.Files[i].LineStates()
+		file := FileDiffCoverage{Path: change.Path, File: change.File}
+		for _, n := range change.Lines {
+			switch states[n] {
+			case CoverHit:
+				file.Statements++
+				file.Covered++
+			case CoverMissed:
+				file.Statements++
+				file.Uncovered = append(file.Uncovered, n)
+			}
+		}
+		if file.Statements > 0 {
+			diff.Files = append(diff.Files, file)
+		}
+	}
+
+	sort.Slice(diff.Files, func(i, j int) bool { return diff.Files[i].Path < diff.Files[j].Path })
+	sort.Strings(diff.Unmeasured)
+	return diff
+}
+
+// LineStates returns the state of every line with statements. A line any
+// executed block touches counts as covered, so a line such as "if x {"
+// whose body never ran still counts as covered when its condition did.
+func (f FileCoverage) LineStates() map[int]CoverState {
+	states := make(map[int]CoverState)
+	for _, block := range f.Blocks {
+		// Block ends are exclusive; one ending at column 1 stops before the
+		// closing brace's line
+		last := block.EndLine
+		if block.EndCol <= 1 && last > block.StartLine {
+			last--
+		}
+		for n := block.StartLine; n <= last; n++ {
+			if block.Count > 0 {
+				states[n] = CoverHit
+			} else if states[n] != CoverHit {
+				states[n] = CoverMissed
+			}
+		}
+	}
+	return states
+}
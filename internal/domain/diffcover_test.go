@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUncoveredRanges(t *testing.T) {
+	tests := []struct {
+		lines []int
+		want  string
+	}{
+		{nil, ""},
+		{[]int{8}, "8"},
+		{[]int{3, 4}, "3-4"},
+		{[]int{8, 12, 13, 14}, "8, 12-14"},
+		{[]int{1, 3, 5, 6, 9}, "1, 3, 5-6, 9"},
+	}
+	for _, tt := range tests {
+		if got := (FileDiffCoverage{Uncovered: tt.lines}).UncoveredRanges(); got != tt.want {
+			t.Errorf("UncoveredRanges of %v = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestLineStates(t *testing.T) {
+	file := NewCoverage("count", calcBlocks("sign")).Packages[0].Files[0]
+	want := map[int]CoverState{
+		5: CoverHit,
+		// The block of the if's body ends at column 1 of line 7, where
+		// the else's blocks are missed
+		6:  CoverHit,
+		7:  CoverMissed,
+		8:  CoverMissed,
+		13: CoverMissed, 14: CoverMissed,
+		15: CoverMissed,
+		17: CoverMissed,
+	}
+	if got := file.LineStates(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LineStates() = %v, want %v", got, want)
+	}
+
+	// A line an executed block touches is covered, whatever else missed it
+	blocks := append(calcBlocks("sign"), CoverBlock{File: calcFile, StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 8, Statements: 1, Count: 1})
+	if got := NewCoverage("count", blocks).Packages[0].Files[0].LineStates()[7]; got != CoverHit {
+		t.Errorf("line 7 = %v, want covered", got)
+	}
+}
+
+func TestNewDiffCoverage(t *testing.T) {
+	coverage := NewCoverage("count", append(calcBlocks("sign"), CoverBlock{
+		File: "example.com/cs/calc/other.go", StartLine: 3, StartCol: 2, EndLine: 3, EndCol: 10, Statements: 1, Count: 1,
+	}))
+	changed := []ChangedFile{
+		{Path: "calc/other.go", File: "example.com/cs/calc/other.go", Lines: []int{1, 3}},
+		// The comment, covered lines, missed lines and the closing brace
+		{Path: "calc/calc.go", File: calcFile, Lines: []int{3, 5, 6, 7, 8, 13, 16}},
+		// Changed lines without statements
+		{Path: "calc/doc.go", File: "example.com/cs/calc/doc.go", Lines: []int{1, 2}},
+		{Path: "calc/calc_test.go", File: "example.com/cs/calc/calc_test.go", Lines: []int{5}},
+		{Path: "calc/testdata/gen.txt", File: "example.com/cs/calc/testdata/gen.txt", Lines: []int{1}},
+		// Outside any module
+		{Path: "../scratch/main.go", Lines: []int{1}},
+		// Packages the run did not cover
+		{Path: "util/util.go", File: "example.com/cs/util/util.go", Lines: []int{4}},
+		{Path: "cmd/main.go", File: "example.com/cs/cmd/main.go", Lines: []int{4}},
+	}
+
+	diff := NewDiffCoverage("main", changed, coverage)
+	want := &DiffCoverage{
+		Base: "main",
+		Files: []FileDiffCoverage{
+			{
+				Path: "calc/calc.go", File: calcFile,
+				CoverageCounts: CoverageCounts{Statements: 5, Covered: 2},
+				Uncovered:      []int{7, 8, 13},
+			},
+			{
+				Path: "calc/other.go", File: "example.com/cs/calc/other.go",
+				CoverageCounts: CoverageCounts{Statements: 1, Covered: 1},
+			},
+		},
+		Unmeasured: []string{"cmd/main.go", "util/util.go"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("NewDiffCoverage = %+v, want %+v", diff, want)
+	}
+	if got := diff.Total(); got != (CoverageCounts{Statements: 6, Covered: 3}) {
+		t.Errorf("Total() = %+v, want 3 of 6", got)
+	}
+	if got := diff.Files[0].UncoveredRanges(); got != "7-8, 13" {
+		t.Errorf("UncoveredRanges() = %q, want \"7-8, 13\"", got)
+	}
+
+	// Nothing changed
+	if diff := NewDiffCoverage("main", nil, coverage); len(diff.Files) != 0 || len(diff.Unmeasured) != 0 || diff.Total() != (CoverageCounts{}) {
+		t.Errorf("NewDiffCoverage without changes = %+v", diff)
+	}
+}
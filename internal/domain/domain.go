@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
@@ -26,6 +27,50 @@ type TestCase struct {
 	Output   string
 	Logs     []string
 	LastFail *FailInfo
+	Kind     TestKind
+
+	// runLogs is where the logs of the latest run start in Logs, and
+	// failures collects the failures the run reports
+	runLogs  int
+	failures failureLog
+}
+
+// TestKind distinguishes the kinds of functions go test runs
+type TestKind int
+
+const (
+	KindTest TestKind = iota
+	KindBenchmark
+	KindFuzz
+	KindExample
+)
+
+// KindOf classifies a top-level test function by its name prefix
+func KindOf(name string) TestKind {
+	switch {
+	case strings.HasPrefix(name, "Benchmark"):
+		return KindBenchmark
+	case strings.HasPrefix(name, "Fuzz"):
+		return KindFuzz
+	case strings.HasPrefix(name, "Example"):
+		return KindExample
+	default:
+		return KindTest
+	}
+}
+
+// String returns the lower-case kind name
+func (k TestKind) String() string {
+	switch k {
+	case KindBenchmark:
+		return "benchmark"
+	case KindFuzz:
+		return "fuzz"
+	case KindExample:
+		return "example"
+	default:
+		return "test"
+	}
 }
 
 // TestStatus represents the status of a test
@@ -37,20 +82,109 @@ const (
 	TestStatusPassed
 	TestStatusFailed
 	TestStatusSkipped
+	// TestStatusCancelled marks a test whose run was cancelled before it
+	// finished
+	TestStatusCancelled
+	// TestStatusStalled marks a running test that printed nothing for
+	// longer than the stall threshold
+	TestStatusStalled
+	// TestStatusPanicked marks a failed test whose run ended in a panic
+	TestStatusPanicked
 )
 
 // Legacy status constants for compatibility
 const (
-	StatusRunning  = TestStatusRunning
-	StatusPassed   = TestStatusPassed
-	StatusFailed   = TestStatusFailed
-	StatusSkipped  = TestStatusSkipped
+	StatusRunning   = TestStatusRunning
+	StatusPassed    = TestStatusPassed
+	StatusFailed    = TestStatusFailed
+	StatusSkipped   = TestStatusSkipped
+	StatusCancelled = TestStatusCancelled
+	StatusStalled   = TestStatusStalled
+	StatusPanicked  = TestStatusPanicked
 )
 
+// Failed reports whether the status is a failure, a panic included
+func (s TestStatus) Failed() bool {
+	return s == TestStatusFailed || s == TestStatusPanicked
+}
+
+// Apply updates the test case from a test2json event for the same test
+func (tc *TestCase) Apply(event TestEvent) {
+	switch event.Action {
+	case "run":
+		tc.Status = StatusRunning
+		tc.runLogs = len(tc.Logs)
+		tc.failures = failureLog{}
+	case "pass":
+		tc.Status = StatusPassed
+		tc.Duration = elapsed(event)
+	case "fail":
+		tc.Status = StatusFailed
+		tc.Duration = elapsed(event)
+		// Output lines keep their trailing newline
+		tc.LastFail = &FailInfo{
+			FullLog:  strings.Join(tc.Logs[tc.runLogs:], ""),
+			Failures: tc.failures.failures(),
+		}
+		if len(tc.LastFail.Failures) > 0 {
+			tc.LastFail.Error = tc.LastFail.Failures[0].Summary()
+		}
+		if panicked := ParsePanic(tc.LastFail.FullLog); panicked != nil {
+			tc.Status = StatusPanicked
+			tc.LastFail.Panic = panicked
+			tc.LastFail.Error = panicked.Summary()
+		}
+	case "skip":
+		tc.Status = StatusSkipped
+		tc.Duration = elapsed(event)
+	case "bench":
+		// A benchmark that logged output and did not fail
+		tc.Status = StatusPassed
+		tc.Duration = elapsed(event)
+	case "output":
+		tc.Logs = append(tc.Logs, event.Output)
+		tc.failures.add(event)
+		if tc.Status == StatusStalled {
+			tc.Status = StatusRunning
+		}
+	case "cont":
+		if tc.Status == StatusStalled {
+			tc.Status = StatusRunning
+		}
+	}
+}
+
+// Stall marks a running test stalled until its next output
+func (tc *TestCase) Stall() {
+	if tc.Status == StatusRunning {
+		tc.Status = StatusStalled
+	}
+}
+
+// Cancel marks the test cancelled if its run ended while it was running
+func (tc *TestCase) Cancel() {
+	if tc.Status == StatusRunning || tc.Status == StatusStalled {
+		tc.Status = StatusCancelled
+	}
+}
+
+// elapsed converts the event's elapsed seconds to a duration
+func elapsed(event TestEvent) time.Duration {
+	return time.Duration(event.Elapsed * float64(time.Second))
+}
+
 // FailInfo represents failure information for a test
 type FailInfo struct {
+	// FullLog is the output of the failed run
 	FullLog string
-	Error   string
+	// Error is the panic, or else the first failure, on one line; empty
+	// when the test failed without reporting one, such as a parent of
+	// failed subtests
+	Error string
+	// Failures are those the run reported, in order
+	Failures []Failure
+	// Panic is the panic the run ended in, nil if it did not panic
+	Panic *Panic
 }
 
 // Package represents a Go package
@@ -59,6 +193,59 @@ type Package struct {
 	Path  string
 	Name  string
 	Tests []TestCase
+	// Module is the path of the module the package belongs to, empty when
+	// unknown, as for streamed input
+	Module string
+	// Status is the outcome of the package's latest run
+	Status PackageStatus
+}
+
+// PackageStatus is the outcome of running a package's test binary
+type PackageStatus int
+
+const (
+	PackageStatusPending PackageStatus = iota
+	PackageStatusRunning
+	PackageStatusPassed
+	PackageStatusFailed
+	// PackageStatusSkipped marks a package without test files
+	PackageStatusSkipped
+	// PackageStatusBuildFailed marks a package whose test binary could not
+	// be built, so none of its tests ran
+	PackageStatusBuildFailed
+)
+
+// Apply updates the package from a test2json event of the package or one
+// of its tests
+func (p *Package) Apply(event TestEvent) {
+	if event.Test != "" {
+		// A test running means the test binary was built
+		if event.Action == "run" {
+			p.Status = PackageStatusRunning
+		}
+		return
+	}
+
+	switch event.Action {
+	case "start":
+		p.Status = PackageStatusRunning
+	case "output":
+		if pkg, ok := ParseBuildFailed(event.Output); ok && pkg == string(p.ID) {
+			p.Status = PackageStatusBuildFailed
+		}
+	case "build-fail":
+		p.Status = PackageStatusBuildFailed
+	case "pass":
+		p.Status = PackageStatusPassed
+	case "skip":
+		p.Status = PackageStatusSkipped
+	case "fail":
+		if event.FailedBuild != "" {
+			p.Status = PackageStatusBuildFailed
+		} else if p.Status != PackageStatusBuildFailed {
+			p.Status = PackageStatusFailed
+		}
+	}
 }
 
 // TestResult represents the result of running a test
@@ -77,6 +264,62 @@ type TestEvent struct {
 	Test    string
 	Output  string
 	Elapsed float64
+	// ImportPath identifies the package build a build-output or build-fail
+	// event belongs to, such as "example.com/pkg_test [example.com/pkg.test]";
+	// Go 1.24 and later report build output this way
+	ImportPath string
+	// OutputType marks output events as test framing or as written by
+	// t.Error and t.Fatal; older toolchains leave it empty
+	OutputType string
+	// FailedBuild is the ImportPath of the package build that failed a
+	// package, set on its fail event by Go 1.24 and later
+	FailedBuild string
+}
+
+// Output types of newer toolchains
+const (
+	// OutputFrame is test framing, such as "=== RUN" or "--- FAIL:"
+	OutputFrame = "frame"
+	// OutputError is a message of t.Error or t.Fatal, and
+	// OutputErrorContinue a further line of it
+	OutputError         = "error"
+	OutputErrorContinue = "error-continue"
+)
+
+// framePrefixes start the framing lines go test prints around test output
+var framePrefixes = []string{
+	"=== RUN", "=== PAUSE", "=== CONT", "=== NAME",
+	"--- PASS", "--- FAIL", "--- SKIP", "--- BENCH",
+}
+
+// IsFrame reports whether an output event is test framing. Without an
+// OutputType, from older toolchains, framing is recognized by its prefix.
+func (e TestEvent) IsFrame() bool {
+	if e.OutputType != "" {
+		return e.OutputType == OutputFrame
+	}
+	line := strings.TrimLeft(e.Output, " ")
+	for _, prefix := range framePrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsError reports whether an output event is part of a t.Error or t.Fatal
+// message; only newer toolchains mark these
+func (e TestEvent) IsError() bool {
+	return e.OutputType == OutputError || e.OutputType == OutputErrorContinue
+}
+
+// BuildPackage returns the package a build event is attributed to: the
+// package whose tests the build of ImportPath is for
+func (e TestEvent) BuildPackage() string {
+	if e.ImportPath == "" {
+		return e.Package
+	}
+	return TestedPackage(e.ImportPath)
 }
 
 // TestID represents a unique identifier for a test
@@ -85,15 +328,64 @@ type TestID struct {
 	Name string
 }
 
+// String returns the "pkg.TestName" form of the ID
+func (id TestID) String() string {
+	return id.Pkg + "." + id.Name
+}
+
+// ParseTestID parses the "pkg.TestName" form. The package part may itself
+// contain dots (gopkg.in/yaml.v3), so the split happens at the first dot
+// followed by a test function prefix.
+func ParseTestID(s string) (TestID, bool) {
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] != '.' {
+			continue
+		}
+		name := s[i+1:]
+		for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+			if strings.HasPrefix(name, prefix) {
+				return TestID{Pkg: s[:i], Name: name}, true
+			}
+		}
+	}
+	return TestID{}, false
+}
+
 // TestSummary represents a summary of test results
 type TestSummary struct {
-	Total         int
-	Passed        int
-	Failed        int
-	Skipped       int
-	TotalTests    int
-	TotalPackages int
-	StartedAt     time.Time
-	CompletedAt   time.Time
-	Duration      time.Duration
-}
\ No newline at end of file
+	Total          int
+	Passed         int
+	Failed         int
+	Skipped        int
+	TotalTests     int
+	TotalPackages  int
+	FailedPackages int
+	StartedAt      time.Time
+	CompletedAt    time.Time
+	Duration       time.Duration
+	// Env lists the environment overrides the run used, as NAME=VALUE for
+	// set variables and -NAME for unset ones
+	Env []string
+	// Benchmarks are the benchmark results in the order they were reported
+	Benchmarks []BenchmarkResult
+	// Crashers are the failing inputs fuzzing wrote to testdata/fuzz
+	Crashers []FuzzCrasher
+	// Coverage is the statement coverage of runs with -cover, nil otherwise
+	Coverage *Coverage
+	// Cancelled reports whether the run was cancelled before it finished;
+	// Unfinished are the tests that were still running then
+	Cancelled  bool
+	Unfinished []TestID
+	// BuildFailed are the packages whose test binaries could not be built,
+	// sorted; BuildErrors are the errors the builds printed
+	BuildFailed []string
+	BuildErrors []BuildError
+	// Races are the unique data races of runs with -race, in the order
+	// first reported
+	Races []DataRace
+}
+
+// HasFailures reports whether any test or package failed
+func (s *TestSummary) HasFailures() bool {
+	return s.Failed > 0 || s.FailedPackages > 0
+}
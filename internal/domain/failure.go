@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Failure is a message a test reported with t.Error or t.Fatal, at the
+// line of the call
+type Failure struct {
+	// File is the file's base name, as the testing package prints it
+	File string
+	Line int
+	// Message continues over the lines that follow it, joined by newlines
+	Message string
+}
+
+// Summary formats the failure on one line, as "file_test.go:12: message"
+// with the first line of the message
+func (f Failure) Summary() string {
+	message, _, _ := strings.Cut(f.Message, "\n")
+	return f.File + ":" + strconv.Itoa(f.Line) + ": " + message
+}
+
+// failureRegex matches "    file_test.go:12: message", which the testing
+// package indents below the test's framing
+var failureRegex = regexp.MustCompile(`^( +)([^\s:]+\.go):(\d+): (.*)$`)
+
+// framingRegex matches the lines go test frames tests with, such as
+// "=== RUN   TestX" and "    --- PASS: TestX/sub (0.00s)", capturing the
+// result of result lines
+var framingRegex = regexp.MustCompile(`^ *(?:=== (?:RUN|CONT|NAME|PAUSE) |--- (PASS|FAIL|SKIP): )`)
+
+// failureLog collects the failures of a test from its output. Newer
+// toolchains mark the lines of t.Error and t.Fatal, unless go test ran
+// with -v; without marks every message with a location counts, t.Log
+// included, as they look the same, except below the result of a subtest
+// that passed or was skipped: older toolchains report the output of
+// subtests in their parent's, below each subtest's result.
+type failureLog struct {
+	// marked are the failures of marked lines, located those of every
+	// line with a location
+	marked  []Failure
+	located []Failure
+	// indent is that of the last located failure's first line, whose
+	// message the following lines continue while open
+	indent int
+	open   bool
+	// passed is set below the result of a test that did not fail
+	passed bool
+	// typed is set once a line has an output type, which toolchains that
+	// mark failures give framing lines too
+	typed bool
+}
+
+// failures returns the marked failures, or the located ones without marks
+func (l *failureLog) failures() []Failure {
+	if l.typed {
+		return l.marked
+	}
+	return l.located
+}
+
+// add parses the lines of an output event
+func (l *failureLog) add(event TestEvent) {
+	for _, line := range strings.Split(strings.TrimSuffix(event.Output, "\n"), "\n") {
+		l.addLine(strings.TrimRight(line, "\r"), event.OutputType)
+	}
+}
+
+func (l *failureLog) addLine(line, outputType string) {
+	if outputType != "" {
+		l.typed = true
+	}
+	if m := framingRegex.FindStringSubmatch(line); m != nil {
+		l.passed = m[1] == "PASS" || m[1] == "SKIP"
+		l.open = false
+		return
+	}
+	if m := failureRegex.FindStringSubmatch(line); m != nil && !(l.passed && outputType == "") {
+		n, _ := strconv.Atoi(m[3])
+		failure := Failure{File: m[2], Line: n, Message: m[4]}
+		l.located = append(l.located, failure)
+		if outputType == OutputError {
+			l.marked = append(l.marked, failure)
+		}
+		l.indent = len(m[1])
+		l.open = true
+		return
+	}
+
+	// Further lines of a message are indented four more spaces
+	continuation := strings.Repeat(" ", l.indent+4)
+	if l.open && (outputType == OutputErrorContinue || strings.HasPrefix(line, continuation)) {
+		more := "\n" + strings.TrimPrefix(line, continuation)
+		l.located[len(l.located)-1].Message += more
+		if outputType == OutputErrorContinue && len(l.marked) > 0 {
+			l.marked[len(l.marked)-1].Message += more
+		}
+		return
+	}
+	l.open = false
+}
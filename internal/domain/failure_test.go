@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFailureRegex(t *testing.T) {
+	tests := []struct {
+		line   string
+		indent string
+		file   string
+		lineNo string
+		msg    string
+	}{
+		{line: "    fl_test.go:7: got 1, want 2", indent: "    ", file: "fl_test.go", lineNo: "7", msg: "got 1, want 2"},
+		{line: "        fl_test.go:17: broken", indent: "        ", file: "fl_test.go", lineNo: "17", msg: "broken"},
+		{line: "    helper.go:3: ", indent: "    ", file: "helper.go", lineNo: "3", msg: ""},
+		{line: "    fl_test.go:8: a: b.go:1: c", indent: "    ", file: "fl_test.go", lineNo: "8", msg: "a: b.go:1: c"},
+		// Lines the testing package did not write
+		{line: "fl_test.go:7: got 1, want 2"},
+		{line: "    fl_test.go: no line"},
+		{line: "    fl_test.go:7:3: column"},
+		{line: "        line"},
+	}
+	for _, tt := range tests {
+		m := failureRegex.FindStringSubmatch(tt.line)
+		var got []string
+		if m != nil {
+			got = m[1:]
+		}
+		var want []string
+		if tt.file != "" {
+			want = []string{tt.indent, tt.file, tt.lineNo, tt.msg}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("failureRegex on %q = %q, want %q", tt.line, got, want)
+		}
+	}
+}
+
+// testFailures returns the failures of each test in the events, parsed as
+// TestCase.Apply does
+func testFailures(events []TestEvent) map[string][]Failure {
+	logs := make(map[string]*failureLog)
+	for _, event := range events {
+		if event.Test == "" || event.Action != "output" {
+			continue
+		}
+		if logs[event.Test] == nil {
+			logs[event.Test] = &failureLog{}
+		}
+		logs[event.Test].add(event)
+	}
+	failures := make(map[string][]Failure, len(logs))
+	for name, log := range logs {
+		failures[name] = log.failures()
+	}
+	return failures
+}
+
+// withoutOutputType strips the events of their OutputType, which older
+// toolchains, and newer ones with go test -v, do not set
+func withoutOutputType(events []TestEvent) []TestEvent {
+	stripped := make([]TestEvent, len(events))
+	for i, event := range events {
+		event.OutputType = ""
+		stripped[i] = event
+	}
+	return stripped
+}
+
+func TestFailureLog(t *testing.T) {
+	events := readEvents(t, "failures.json")
+	tests := []struct {
+		name   string
+		events []TestEvent
+		want   map[string][]Failure
+	}{
+		{
+			// Only the lines of t.Error and t.Fatal are marked
+			name:   "marked",
+			events: events,
+			want: map[string][]Failure{
+				"TestLogAndError": {
+					{File: "fl_test.go", Line: 7, Message: "got 1, want 2"},
+					{File: "fl_test.go", Line: 8, Message: "multi\nline\nmessage"},
+				},
+				"TestParent/bad": {{File: "fl_test.go", Line: 17, Message: "broken"}},
+			},
+		},
+		{
+			// t.Log looks the same as t.Error without the marks
+			name:   "unmarked",
+			events: withoutOutputType(events),
+			want: map[string][]Failure{
+				"TestLogAndError": {
+					{File: "fl_test.go", Line: 6, Message: "starting"},
+					{File: "fl_test.go", Line: 7, Message: "got 1, want 2"},
+					{File: "fl_test.go", Line: 8, Message: "multi\nline\nmessage"},
+					{File: "fl_test.go", Line: 9, Message: "done"},
+				},
+				"TestParent/ok":  {{File: "fl_test.go", Line: 14, Message: "fine here"}},
+				"TestParent/bad": {{File: "fl_test.go", Line: 17, Message: "broken"}},
+				"TestPasses":     {{File: "fl_test.go", Line: 22, Message: "all good"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testFailures(tt.events)
+			for name, failures := range got {
+				if len(failures) == 0 {
+					delete(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("failures = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureLogNestedReports(t *testing.T) {
+	// Older toolchains report the output of subtests in their parent's,
+	// each below the subtest's result
+	output := []string{
+		"=== RUN   TestParent\n",
+		"=== RUN   TestParent/ok\n",
+		"=== RUN   TestParent/skipped\n",
+		"=== RUN   TestParent/bad\n",
+		"--- FAIL: TestParent (0.00s)\n",
+		"    --- PASS: TestParent/ok (0.00s)\n",
+		"        fl_test.go:14: fine here\n",
+		"            and more\n",
+		"    --- SKIP: TestParent/skipped (0.00s)\n",
+		"        fl_test.go:20: not today\n",
+		"    --- FAIL: TestParent/bad (0.00s)\n",
+		"        fl_test.go:17: broken\n",
+		"            badly\n",
+		"        fl_test.go:18: again\n",
+	}
+	var log failureLog
+	for _, line := range output {
+		log.add(TestEvent{Action: "output", Test: "TestParent", Output: line})
+	}
+	want := []Failure{
+		{File: "fl_test.go", Line: 17, Message: "broken\nbadly"},
+		{File: "fl_test.go", Line: 18, Message: "again"},
+	}
+	if got := log.failures(); !reflect.DeepEqual(got, want) {
+		t.Errorf("failures = %+v, want %+v", got, want)
+	}
+}
+
+func TestTestCaseFailures(t *testing.T) {
+	tc := &TestCase{}
+	for _, event := range readEvents(t, "failures.json") {
+		if event.Test == "TestLogAndError" {
+			tc.Apply(event)
+		}
+	}
+	if tc.LastFail == nil {
+		t.Fatal("LastFail not set")
+	}
+	if got, want := tc.LastFail.Error, "fl_test.go:7: got 1, want 2"; got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+	if len(tc.LastFail.Failures) != 2 {
+		t.Errorf("%d failures, want 2", len(tc.LastFail.Failures))
+	}
+	if !strings.HasPrefix(tc.LastFail.FullLog, "=== RUN   TestLogAndError\n") {
+		t.Errorf("FullLog = %q, want the run's output", tc.LastFail.FullLog)
+	}
+
+	// A second run starts over
+	tc.Apply(TestEvent{Action: "run", Test: "TestLogAndError"})
+	tc.Apply(TestEvent{Action: "output", Test: "TestLogAndError", Output: "    fl_test.go:9: other\n", OutputType: OutputError})
+	tc.Apply(TestEvent{Action: "fail", Test: "TestLogAndError"})
+	if got, want := tc.LastFail.Error, "fl_test.go:9: other"; got != want || len(tc.LastFail.Failures) != 1 {
+		t.Errorf("second run: Error = %q with %d failures, want %q with 1", got, len(tc.LastFail.Failures), want)
+	}
+}
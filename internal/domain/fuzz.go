@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FuzzPhase is the stage of a fuzzing run reported by its status lines
+type FuzzPhase string
+
+const (
+	FuzzPhaseBaseline   FuzzPhase = "baseline"
+	FuzzPhaseFuzzing    FuzzPhase = "fuzzing"
+	FuzzPhaseMinimizing FuzzPhase = "minimizing"
+)
+
+// FuzzProgress is a status line go test prints while fuzzing, such as
+// "fuzz: elapsed: 3s, execs: 135954 (45309/sec), new interesting: 0 (total: 1)"
+type FuzzProgress struct {
+	// ID is the fuzz target
+	ID      TestID
+	Phase   FuzzPhase
+	Elapsed time.Duration
+	// BaselineDone and BaselineTotal count the corpus entries run to
+	// gather the baseline coverage
+	BaselineDone  int
+	BaselineTotal int
+	Workers       int
+	Execs         int64
+	ExecsPerSec   int64
+	// NewInteresting counts the inputs this run added to the cached
+	// corpus; TotalInteresting includes the earlier ones
+	NewInteresting   int
+	TotalInteresting int
+}
+
+// String formats the progress in the order go test reports it
+func (p FuzzProgress) String() string {
+	parts := []string{"elapsed " + p.Elapsed.String()}
+	switch p.Phase {
+	case FuzzPhaseBaseline:
+		parts = append(parts, fmt.Sprintf("baseline coverage %d/%d", p.BaselineDone, p.BaselineTotal))
+	case FuzzPhaseFuzzing:
+		if p.Workers > 0 {
+			// The line announcing the end of the baseline
+			parts = append(parts, fmt.Sprintf("now fuzzing with %d workers", p.Workers))
+			break
+		}
+		parts = append(parts,
+			fmt.Sprintf("execs %d (%d/sec)", p.Execs, p.ExecsPerSec),
+			fmt.Sprintf("new interesting %d (total %d)", p.NewInteresting, p.TotalInteresting))
+	case FuzzPhaseMinimizing:
+		parts = append(parts, "minimizing failing input")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseFuzzProgress parses a "fuzz:" status line of the fuzz target id
+func ParseFuzzProgress(id TestID, line string) (FuzzProgress, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "fuzz: ")
+	if !ok {
+		return FuzzProgress{}, false
+	}
+
+	progress := FuzzProgress{ID: id}
+	for _, part := range strings.Split(rest, ", ") {
+		switch {
+		case strings.HasPrefix(part, "elapsed: "):
+			elapsed, err := time.ParseDuration(strings.TrimPrefix(part, "elapsed: "))
+			if err != nil {
+				return FuzzProgress{}, false
+			}
+			progress.Elapsed = elapsed
+		case strings.HasPrefix(part, "gathering baseline coverage: "):
+			progress.Phase = FuzzPhaseBaseline
+			if _, err := fmt.Sscanf(part, "gathering baseline coverage: %d/%d completed", &progress.BaselineDone, &progress.BaselineTotal); err != nil {
+				return FuzzProgress{}, false
+			}
+		case strings.HasPrefix(part, "now fuzzing with "):
+			progress.Phase = FuzzPhaseFuzzing
+			if _, err := fmt.Sscanf(part, "now fuzzing with %d workers", &progress.Workers); err != nil {
+				return FuzzProgress{}, false
+			}
+		case strings.HasPrefix(part, "execs: "):
+			progress.Phase = FuzzPhaseFuzzing
+			if _, err := fmt.Sscanf(part, "execs: %d (%d/sec)", &progress.Execs, &progress.ExecsPerSec); err != nil {
+				return FuzzProgress{}, false
+			}
+		case strings.HasPrefix(part, "new interesting: "):
+			if _, err := fmt.Sscanf(part, "new interesting: %d (total: %d)", &progress.NewInteresting, &progress.TotalInteresting); err != nil {
+				return FuzzProgress{}, false
+			}
+		case strings.HasPrefix(part, "minimizing"):
+			progress.Phase = FuzzPhaseMinimizing
+		}
+	}
+	if progress.Phase == "" {
+		return FuzzProgress{}, false
+	}
+	return progress, true
+}
+
+// FuzzCrasher is a failing input go test wrote to the package's
+// testdata/fuzz directory
+type FuzzCrasher struct {
+	// ID is the fuzz target
+	ID TestID
+	// Entry is the corpus file name
+	Entry string
+	// Path is the corpus file relative to the package directory
+	Path string
+}
+
+// TestID names the corpus entry as a subtest of the fuzz target, which
+// reruns just that input
+func (c FuzzCrasher) TestID() TestID {
+	return TestID{Pkg: c.ID.Pkg, Name: c.ID.Name + "/" + c.Entry}
+}
+
+// ParseFuzzCrasher parses the "Failing input written to" line of package pkg
+func ParseFuzzCrasher(pkg, line string) (FuzzCrasher, bool) {
+	file, ok := strings.CutPrefix(strings.TrimSpace(line), "Failing input written to ")
+	if !ok || file == "" {
+		return FuzzCrasher{}, false
+	}
+
+	// The file is testdata/fuzz/<target>/<entry>
+	target := filepath.Base(filepath.Dir(file))
+	if KindOf(target) != KindFuzz {
+		return FuzzCrasher{}, false
+	}
+	return FuzzCrasher{
+		ID:    TestID{Pkg: pkg, Name: target},
+		Entry: filepath.Base(file),
+		Path:  file,
+	}, true
+}
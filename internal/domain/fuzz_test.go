@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFuzzProgress(t *testing.T) {
+	id := TestID{Pkg: "example.com/fs/fz", Name: "FuzzFind"}
+	tests := []struct {
+		line string
+		want FuzzProgress
+		ok   bool
+		// str is the String of the progress
+		str string
+	}{
+		{
+			line: "fuzz: elapsed: 0s, gathering baseline coverage: 0/1 completed\n",
+			want: FuzzProgress{ID: id, Phase: FuzzPhaseBaseline, BaselineTotal: 1},
+			ok:   true,
+			str:  "elapsed 0s, baseline coverage 0/1",
+		},
+		{
+			line: "fuzz: elapsed: 0s, gathering baseline coverage: 1/1 completed, now fuzzing with 1 workers\n",
+			want: FuzzProgress{ID: id, Phase: FuzzPhaseFuzzing, BaselineDone: 1, BaselineTotal: 1, Workers: 1},
+			ok:   true,
+			str:  "elapsed 0s, now fuzzing with 1 workers",
+		},
+		{
+			line: "fuzz: elapsed: 3s, execs: 163773 (54573/sec), new interesting: 0 (total: 1)\n",
+			want: FuzzProgress{ID: id, Phase: FuzzPhaseFuzzing, Elapsed: 3 * time.Second,
+				Execs: 163773, ExecsPerSec: 54573, TotalInteresting: 1},
+			ok:  true,
+			str: "elapsed 3s, execs 163773 (54573/sec), new interesting 0 (total 1)",
+		},
+		{
+			line: "fuzz: elapsed: 1m0s, execs: 2724750 (0/sec), new interesting: 2 (total: 3)\n",
+			want: FuzzProgress{ID: id, Phase: FuzzPhaseFuzzing, Elapsed: time.Minute,
+				Execs: 2724750, NewInteresting: 2, TotalInteresting: 3},
+			ok:  true,
+			str: "elapsed 1m0s, execs 2724750 (0/sec), new interesting 2 (total 3)",
+		},
+		{
+			// The line announcing the minimization has no elapsed time
+			line: "fuzz: minimizing 49-byte failing input file\n",
+			want: FuzzProgress{ID: id, Phase: FuzzPhaseMinimizing},
+			ok:   true,
+			str:  "elapsed 0s, minimizing failing input",
+		},
+		{
+			line: "fuzz: elapsed: 2s, minimizing\n",
+			want: FuzzProgress{ID: id, Phase: FuzzPhaseMinimizing, Elapsed: 2 * time.Second},
+			ok:   true,
+			str:  "elapsed 2s, minimizing failing input",
+		},
+		{line: "=== RUN   FuzzFind\n"},
+		{line: "    fz_test.go:12: fuzz: elapsed: 3s, execs: 1 (1/sec)\n"},
+		{line: "fuzz: elapsed: 3s\n"},
+		{line: "fuzz: elapsed: soon, execs: 163773 (54573/sec)\n"},
+		{line: "fuzz: elapsed: 3s, execs: many\n"},
+		{line: "fuzz: elapsed: 0s, gathering baseline coverage: 0 of 1\n"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseFuzzProgress(id, tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseFuzzProgress(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			continue
+		}
+		if ok && got.String() != tt.str {
+			t.Errorf("ParseFuzzProgress(%q).String() = %q, want %q", tt.line, got.String(), tt.str)
+		}
+	}
+}
+
+func TestParseFuzzCrasher(t *testing.T) {
+	const pkg = "example.com/fs/fz"
+	tests := []struct {
+		line string
+		want FuzzCrasher
+		ok   bool
+	}{
+		{
+			line: "    Failing input written to testdata/fuzz/FuzzFind/c7260de965987544\n",
+			want: FuzzCrasher{
+				ID:    TestID{Pkg: pkg, Name: "FuzzFind"},
+				Entry: "c7260de965987544",
+				Path:  "testdata/fuzz/FuzzFind/c7260de965987544",
+			},
+			ok: true,
+		},
+		{line: "    Failing input written to \n"},
+		{line: "    Failing input written to testdata/fuzz/TestFind/c7260de965987544\n"},
+		{line: "    go test -run=FuzzFind/c7260de965987544\n"},
+		{line: "    fz_test.go:12: found \"000\\x7f000\"\n"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseFuzzCrasher(pkg, tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseFuzzCrasher(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestFuzzRun parses the output of two recorded fuzzing runs: one fuzzing
+// for 7s without finding anything, one finding a failing input at once
+func TestFuzzRun(t *testing.T) {
+	var phases []FuzzPhase
+	var crashers []TestID
+	for _, event := range readEvents(t, "fuzz.json") {
+		if event.Action != "output" || event.Test == "" {
+			continue
+		}
+		id := TestID{Pkg: event.Package, Name: event.Test}
+		if progress, ok := ParseFuzzProgress(id, event.Output); ok {
+			phases = append(phases, progress.Phase)
+		}
+		if crasher, ok := ParseFuzzCrasher(event.Package, event.Output); ok {
+			if crasher.ID != id {
+				t.Errorf("crasher of %v, want %v", crasher.ID, id)
+			}
+			crashers = append(crashers, crasher.TestID())
+		}
+	}
+
+	wantPhases := []FuzzPhase{
+		FuzzPhaseBaseline, FuzzPhaseFuzzing, FuzzPhaseFuzzing, FuzzPhaseFuzzing, FuzzPhaseFuzzing,
+		FuzzPhaseBaseline, FuzzPhaseFuzzing, FuzzPhaseMinimizing, FuzzPhaseMinimizing,
+	}
+	if !reflect.DeepEqual(phases, wantPhases) {
+		t.Errorf("phases = %v, want %v", phases, wantPhases)
+	}
+	wantCrashers := []TestID{{Pkg: "example.com/fs/fz", Name: "FuzzFind/c7260de965987544"}}
+	if !reflect.DeepEqual(crashers, wantCrashers) {
+		t.Errorf("crashers = %v, want %v", crashers, wantCrashers)
+	}
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Goroutine is a goroutine of the dump a Go program prints when it gets
+// SIGQUIT or panics, such as a test binary timing out
+type Goroutine struct {
+	ID int
+	// State is what the goroutine is doing, such as "chan receive"
+	State string
+	// Wait is how long it has been blocked; the runtime only reports whole
+	// minutes, so shorter waits are zero
+	Wait           time.Duration
+	LockedToThread bool
+	// Frames are the calls on its stack, innermost first
+	Frames []StackFrame
+	// CreatedBy is the go statement that started the goroutine, nil for
+	// the main goroutine
+	CreatedBy *StackFrame
+}
+
+// StackFrame is a call on a goroutine's stack
+type StackFrame struct {
+	// Func is the qualified function name, such as "testing.(*T).Run"
+	Func string
+	File string
+	Line int
+}
+
+// goroutineHeaderRegex matches "goroutine 7 [chan receive, 2 minutes]:";
+// tracebacks with GOTRACEBACK=system and above insert "gp=... m=..." before
+// the state
+var goroutineHeaderRegex = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)? \[([^\]]*)\]:$`)
+
+// ParseGoroutineDump parses the goroutines of the last dump in the output
+// of a program. Lines outside a dump are ignored.
+func ParseGoroutineDump(output string) []Goroutine {
+	var goroutines []Goroutine
+	var current *Goroutine
+	var fn string
+	creator := false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if m := goroutineHeaderRegex.FindStringSubmatch(line); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			goroutines = append(goroutines, parseGoroutineState(id, m[2]))
+			current = &goroutines[len(goroutines)-1]
+			fn = ""
+			creator = false
+			continue
+		}
+		// Each crash starts a new dump
+		if strings.HasPrefix(line, "SIGQUIT:") || strings.HasPrefix(line, "panic:") || strings.HasPrefix(line, "fatal error:") {
+			goroutines = nil
+			current = nil
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch {
+		case line == "":
+			current = nil
+		case strings.HasPrefix(line, "\t"):
+			// "\t/path/file.go:36 +0xe fp=0x... sp=0x... pc=0x..."
+			if fn == "" {
+				continue
+			}
+			frame := parseFrameLocation(fn, strings.TrimPrefix(line, "\t"))
+			if creator {
+				current.CreatedBy = &frame
+			} else {
+				current.Frames = append(current.Frames, frame)
+			}
+			fn = ""
+		case strings.HasPrefix(line, "created by "):
+			// "created by testing.(*T).Run in goroutine 1"
+			fn, _, _ = strings.Cut(strings.TrimPrefix(line, "created by "), " in goroutine ")
+			creator = true
+		default:
+			// A call such as "testing.(*T).Run(0x1876d9500008, ...)"; lines
+			// such as "...additional frames elided..." have no location
+			fn = line
+			if i := strings.LastIndex(line, "("); i > 0 && strings.HasSuffix(line, ")") {
+				fn = line[:i]
+			}
+			creator = false
+		}
+	}
+	return goroutines
+}
+
+// parseGoroutineState parses the bracketed part of a goroutine header, such
+// as "select, 5 minutes, locked to thread"
+func parseGoroutineState(id int, state string) Goroutine {
+	g := Goroutine{ID: id}
+	parts := strings.Split(state, ", ")
+	g.State = parts[0]
+	for _, part := range parts[1:] {
+		if minutes, ok := strings.CutSuffix(part, " minutes"); ok {
+			if n, err := strconv.Atoi(minutes); err == nil {
+				g.Wait = time.Duration(n) * time.Minute
+				continue
+			}
+		}
+		if part == "locked to thread" {
+			g.LockedToThread = true
+			continue
+		}
+		g.State += ", " + part
+	}
+	return g
+}
+
+// parseFrameLocation parses "file.go:36 +0xe ..." into the frame of fn
+func parseFrameLocation(fn, location string) StackFrame {
+	frame := StackFrame{Func: fn}
+	location, _, _ = strings.Cut(location, " ")
+	if i := strings.LastIndex(location, ":"); i > 0 {
+		frame.File = location[:i]
+		frame.Line, _ = strconv.Atoi(location[i+1:])
+	}
+	return frame
+}
+
+// Package returns the import path of the frame's function
+func (f StackFrame) Package() string {
+	slash := strings.LastIndex(f.Func, "/")
+	dot := strings.Index(f.Func[slash+1:], ".")
+	if dot < 0 {
+		return f.Func
+	}
+	return f.Func[:slash+1+dot]
+}
+
+// stdSrc is the src directory of the GOROOT set with SetGOROOT, with a
+// trailing slash; empty when unknown
+var stdSrc string
+
+// SetGOROOT sets the GOROOT of the go command that runs the tests, whose src
+// directory holds the standard library. Call it before parsing any output.
+func SetGOROOT(root string) {
+	stdSrc = ""
+	if root != "" {
+		// Stack traces print paths with forward slashes on every platform
+		stdSrc = strings.TrimSuffix(filepath.ToSlash(root), "/") + "/src/"
+	}
+}
+
+// Std reports whether the frame is in the standard library or the test
+// main go test generates: a file under the src directory of GOROOT. Some
+// functions, such as time.Sleep, are implemented in another package's
+// files. Without a GOROOT, a package whose path has no dot in its first
+// element in a file under any src directory is taken to be standard.
+func (f StackFrame) Std() bool {
+	if f.File == "_testmain.go" {
+		return true
+	}
+	if stdSrc != "" {
+		return strings.HasPrefix(f.File, stdSrc)
+	}
+	first, _, _ := strings.Cut(f.Package(), "/")
+	return !strings.Contains(first, ".") && strings.Contains(f.File, "/src/")
+}
+
+// User reports whether any frame of the goroutine is outside the standard
+// library
+func (g Goroutine) User() bool {
+	for _, frame := range g.Frames {
+		if !frame.Std() {
+			return true
+		}
+	}
+	return false
+}
+
+// TopFrame returns the innermost frame outside the standard library, or the
+// innermost frame when there is none
+func (g Goroutine) TopFrame() (StackFrame, bool) {
+	return topFrame(g.Frames)
+}
+
+// topFrame returns the innermost of frames outside the standard library,
+// or the innermost frame when there is none
+func topFrame(frames []StackFrame) (StackFrame, bool) {
+	for _, frame := range frames {
+		if !frame.Std() {
+			return frame, true
+		}
+	}
+	if len(frames) > 0 {
+		return frames[0], true
+	}
+	return StackFrame{}, false
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStackFrameStd(t *testing.T) {
+	tests := []struct {
+		name   string
+		goroot string
+		frame  StackFrame
+		want   bool
+	}{
+		{"standard library", "/usr/local/go", StackFrame{Func: "testing.tRunner", File: "/usr/local/go/src/testing/testing.go"}, true},
+		{"implemented in another package", "/usr/local/go", StackFrame{Func: "time.Sleep", File: "/usr/local/go/src/runtime/time.go"}, true},
+		{"test main", "/usr/local/go", StackFrame{Func: "main.main", File: "_testmain.go"}, true},
+		{"module without a dot under src", "/usr/local/go", StackFrame{Func: "myapp/store.Open", File: "/home/dev/src/myapp/store/store.go"}, false},
+		{"module with a dot", "/usr/local/go", StackFrame{Func: "example.com/p.F", File: "/home/dev/go/pkg/mod/example.com/p@v1.0.0/p.go"}, false},
+		{"GOROOT with a trailing slash", "/usr/local/go/", StackFrame{Func: "runtime.gopark", File: "/usr/local/go/src/runtime/proc.go"}, true},
+		{"other GOROOT", "/opt/go", StackFrame{Func: "testing.tRunner", File: "/usr/local/go/src/testing/testing.go"}, false},
+		{
+			"switched toolchain",
+			"/home/dev/go/pkg/mod/golang.org/toolchain@v0.0.1-go1.27.1.linux-amd64",
+			StackFrame{Func: "testing.tRunner", File: "/home/dev/go/pkg/mod/golang.org/toolchain@v0.0.1-go1.27.1.linux-amd64/src/testing/testing.go"},
+			true,
+		},
+		{"no GOROOT, standard library", "", StackFrame{Func: "testing.tRunner", File: "/usr/local/go/src/testing/testing.go"}, true},
+		{"no GOROOT, module with a dot", "", StackFrame{Func: "example.com/p.F", File: "/home/dev/src/p/p.go"}, false},
+	}
+	t.Cleanup(func() { SetGOROOT("") })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetGOROOT(tt.goroot)
+			if got := tt.frame.Std(); got != tt.want {
+				t.Errorf("Std() = %v with GOROOT %q, want %v", got, tt.goroot, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGoroutineDump(t *testing.T) {
+	SetGOROOT("/usr/local/go")
+	t.Cleanup(func() { SetGOROOT("") })
+
+	// A test binary blocked for five minutes, sent SIGQUIT
+	goroutines := ParseGoroutineDump(strings.Join(readLines(t, "sigquit.txt"), "\n"))
+
+	type goroutine struct {
+		id        int
+		state     string
+		wait      time.Duration
+		locked    bool
+		frames    int
+		top       string
+		user      bool
+		createdBy string
+	}
+	want := []goroutine{
+		{0, "idle", 0, false, 8, "runtime.futex sys_linux_amd64.s:575", false, ""},
+		{1, "chan receive", 5 * time.Minute, false, 11, "runtime.gopark proc.go:474", false, ""},
+		{2, "force gc (idle)", 5 * time.Minute, false, 4, "runtime.gopark proc.go:474", false, "runtime.init.7 proc.go:375"},
+		{3, "GC sweep wait", 0, false, 5, "runtime.gopark proc.go:474", false, "runtime.gcenable mgc.go:214"},
+		{4, "GC scavenge wait", 0, false, 6, "runtime.gopark proc.go:474", false, "runtime.gcenable mgc.go:215"},
+		{5, "finalizer wait", 5 * time.Minute, false, 3, "runtime.gopark proc.go:474", false, "runtime.createfing mfinal.go:172"},
+		{6, "chan receive", 5 * time.Minute, false, 7, "example.com/fs/dump.TestDeadlock dump_test.go:33", true, "testing.(*T).Run testing.go:2258"},
+		// worker is inlined into the go statement's wrapper
+		{7, "chan receive", 5 * time.Minute, true, 6, "example.com/fs/dump.worker dump_test.go:12", true, "example.com/fs/dump.TestDeadlock dump_test.go:18"},
+		{8, "select", 5 * time.Minute, false, 4, "example.com/fs/dump.TestDeadlock.func1 dump_test.go:22", true, "example.com/fs/dump.TestDeadlock dump_test.go:21"},
+		// The runtime only tracks waits of goroutines parked through a GC
+		{9, "sleep", 0, false, 4, "example.com/fs/dump.TestDeadlock.func2 dump_test.go:30", true, "example.com/fs/dump.TestDeadlock dump_test.go:27"},
+	}
+	if len(goroutines) < len(want) {
+		t.Fatalf("%d goroutines, want at least %d", len(goroutines), len(want))
+	}
+	for i, w := range want {
+		g := goroutines[i]
+		top, _ := g.TopFrame()
+		got := goroutine{g.ID, g.State, g.Wait, g.LockedToThread, len(g.Frames), frameString(top), g.User(), ""}
+		if g.CreatedBy != nil {
+			got.createdBy = frameString(*g.CreatedBy)
+		}
+		if got != w {
+			t.Errorf("goroutine %d = %+v, want %+v", w.id, got, w)
+		}
+	}
+}
+
+func TestParseGoroutineDumpLast(t *testing.T) {
+	dump := strings.Join(readLines(t, "sigquit.txt"), "\n")
+	tests := []struct {
+		name   string
+		output string
+		want   []int
+	}{
+		{"no dump", "=== RUN   TestA\n--- PASS: TestA (0.00s)\n", nil},
+		{
+			name: "header only",
+			output: "goroutine 7 [running]:\n" +
+				"goroutine 8 gp=0x1 m=nil [semacquire, 12 minutes, locked to thread, some state]:\n",
+			want: []int{7, 8},
+		},
+		{"dumped twice", dump + "\n" + dump, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"dump then panic", dump + "\npanic: boom\n\ngoroutine 12 [running]:\nmain.main()\n\t/tmp/main.go:5 +0x1d\n", []int{12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, g := range ParseGoroutineDump(tt.output) {
+				got = append(got, g.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("goroutines %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGoroutineState(t *testing.T) {
+	tests := []struct {
+		state  string
+		want   string
+		wait   time.Duration
+		locked bool
+	}{
+		{"running", "running", 0, false},
+		{"chan receive, 5 minutes", "chan receive", 5 * time.Minute, false},
+		{"chan receive, 5 minutes, locked to thread", "chan receive", 5 * time.Minute, true},
+		{"select (no cases), 1 minutes", "select (no cases)", time.Minute, false},
+		{"syscall, locked to thread", "syscall", 0, true},
+		{"sync.WaitGroup.Wait, 90 minutes", "sync.WaitGroup.Wait", 90 * time.Minute, false},
+		{"IO wait, many minutes", "IO wait, many minutes", 0, false},
+	}
+	for _, tt := range tests {
+		g := parseGoroutineState(1, tt.state)
+		if g.State != tt.want || g.Wait != tt.wait || g.LockedToThread != tt.locked {
+			t.Errorf("parseGoroutineState(%q) = %q %v locked %v, want %q %v locked %v",
+				tt.state, g.State, g.Wait, g.LockedToThread, tt.want, tt.wait, tt.locked)
+		}
+	}
+}
+
+// frameString formats a frame as "func file:line"
+func frameString(frame StackFrame) string {
+	if frame.Func == "" {
+		return ""
+	}
+	return frame.Func + " " + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+}
@@ -0,0 +1,196 @@
+package domain
+
+import "testing"
+
+func TestTestedPackage(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"example.com/pkg", "example.com/pkg"},
+		{"example.com/pkg [example.com/pkg.test]", "example.com/pkg"},
+		{"example.com/pkg_test [example.com/pkg.test]", "example.com/pkg"},
+		{"example.com/pkg.test", "example.com/pkg"},
+		{"[example.com/pkg]", "example.com/pkg"},
+		{"example.com/dep [example.com/pkg.test]", "example.com/pkg"},
+		{"example.com/my_test/pkg", "example.com/my_test/pkg"},
+		{"example.com/pkg_test", "example.com/pkg"},
+	}
+	for _, tt := range tests {
+		if got := TestedPackage(tt.id); got != tt.want {
+			t.Errorf("TestedPackage(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestBuildPackage(t *testing.T) {
+	tests := []struct {
+		event TestEvent
+		want  string
+	}{
+		{TestEvent{Action: "build-output", ImportPath: "example.com/bs/ext_test [example.com/bs/ext.test]"}, "example.com/bs/ext"},
+		{TestEvent{Action: "build-fail", ImportPath: "example.com/bs/broken"}, "example.com/bs/broken"},
+		// Without an ImportPath, as in output older toolchains write
+		{TestEvent{Action: "build-output", Package: "example.com/bs/bad"}, "example.com/bs/bad"},
+	}
+	for _, tt := range tests {
+		if got := tt.event.BuildPackage(); got != tt.want {
+			t.Errorf("BuildPackage() of %+v = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
+// withoutBuildFields strips events of what Go 1.24 added: build events,
+// FailedBuild and OutputType
+func withoutBuildFields(events []TestEvent) []TestEvent {
+	var stripped []TestEvent
+	for _, event := range withoutOutputType(events) {
+		if event.ImportPath != "" {
+			continue
+		}
+		event.FailedBuild = ""
+		stripped = append(stripped, event)
+	}
+	return stripped
+}
+
+func TestPackageApply(t *testing.T) {
+	want := map[string]PackageStatus{
+		"example.com/bs/bad":    PackageStatusBuildFailed,
+		"example.com/bs/broken": PackageStatusBuildFailed,
+		"example.com/bs/ext":    PackageStatusBuildFailed,
+		// Its tests import the package that failed to build
+		"example.com/bs/user":  PackageStatusBuildFailed,
+		"example.com/bs/fails": PackageStatusFailed,
+		"example.com/bs/ok":    PackageStatusPassed,
+		"example.com/bs/plain": PackageStatusSkipped,
+	}
+	tests := []struct {
+		name   string
+		events []TestEvent
+	}{
+		{
+			// Build events name the package build, as the decoder
+			// attributes them
+			name:   "Go 1.24",
+			events: readEvents(t, "build.json"),
+		},
+		{
+			// The result line is all that tells a build failed
+			name:   "before Go 1.24",
+			events: withoutBuildFields(readEvents(t, "build_text.json")),
+		},
+		{
+			name: "Go 1.24 without output",
+			events: func() []TestEvent {
+				var events []TestEvent
+				for _, event := range readEvents(t, "build.json") {
+					if event.Action != "output" && event.Action != "build-output" {
+						events = append(events, event)
+					}
+				}
+				return events
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages := make(map[string]*Package, len(want))
+			for id := range want {
+				packages[id] = &Package{ID: PkgID(id)}
+			}
+			for _, event := range tt.events {
+				if event.Package == "" {
+					event.Package = event.BuildPackage()
+				}
+				if p := packages[event.Package]; p != nil {
+					p.Apply(event)
+				}
+			}
+			for id, status := range want {
+				if got := packages[id].Status; got != status {
+					t.Errorf("%s status = %v, want %v", id, got, status)
+				}
+			}
+		})
+	}
+}
+
+func TestPackageApplySequence(t *testing.T) {
+	const pkg = "example.com/bs/bad"
+	tests := []struct {
+		name   string
+		events []TestEvent
+		want   []PackageStatus
+	}{
+		{
+			name: "test binary runs",
+			events: []TestEvent{
+				{Action: "start", Package: pkg},
+				{Action: "run", Package: pkg, Test: "TestA"},
+				{Action: "fail", Package: pkg, Test: "TestA"},
+				{Action: "fail", Package: pkg},
+			},
+			want: []PackageStatus{PackageStatusRunning, PackageStatusRunning, PackageStatusRunning, PackageStatusFailed},
+		},
+		{
+			// The build fails before the package starts
+			name: "build-fail",
+			events: []TestEvent{
+				{Action: "build-fail", Package: pkg, ImportPath: pkg + " [" + pkg + ".test]"},
+				{Action: "start", Package: pkg},
+				{Action: "fail", Package: pkg, FailedBuild: pkg + " [" + pkg + ".test]"},
+			},
+			want: []PackageStatus{PackageStatusBuildFailed, PackageStatusRunning, PackageStatusBuildFailed},
+		},
+		{
+			name: "build failed result line",
+			events: []TestEvent{
+				{Action: "start", Package: pkg},
+				{Action: "output", Package: pkg, Output: "FAIL\t" + pkg + " [build failed]\n"},
+				{Action: "fail", Package: pkg},
+			},
+			want: []PackageStatus{PackageStatusRunning, PackageStatusBuildFailed, PackageStatusBuildFailed},
+		},
+		{
+			name: "setup failed result line",
+			events: []TestEvent{
+				{Action: "start", Package: pkg},
+				{Action: "output", Package: pkg, Output: "FAIL\t" + pkg + " [setup failed]\n"},
+				{Action: "fail", Package: pkg},
+			},
+			want: []PackageStatus{PackageStatusRunning, PackageStatusBuildFailed, PackageStatusBuildFailed},
+		},
+		{
+			// The result line of another package, when a stream mixes them
+			name: "other package's result line",
+			events: []TestEvent{
+				{Action: "start", Package: pkg},
+				{Action: "output", Package: pkg, Output: "FAIL\texample.com/bs/other [build failed]\n"},
+				{Action: "fail", Package: pkg},
+			},
+			want: []PackageStatus{PackageStatusRunning, PackageStatusRunning, PackageStatusFailed},
+		},
+		{
+			// A later run starts over
+			name: "run again",
+			events: []TestEvent{
+				{Action: "fail", Package: pkg, FailedBuild: pkg},
+				{Action: "start", Package: pkg},
+				{Action: "pass", Package: pkg},
+			},
+			want: []PackageStatus{PackageStatusBuildFailed, PackageStatusRunning, PackageStatusPassed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Package{ID: pkg}
+			for i, event := range tt.events {
+				p.Apply(event)
+				if p.Status != tt.want[i] {
+					t.Errorf("after %s event %d: status = %v, want %v", event.Action, i, p.Status, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Panic is a panic, or a fatal runtime error such as concurrent map
+// writes, that ended a test binary while a test ran
+type Panic struct {
+	// Value is what the program panicked with, as printed after "panic: "
+	Value string
+	// Fatal marks a fatal error, which cannot be recovered
+	Fatal bool
+	// Goroutine is the goroutine that panicked, the first of the dump
+	Goroutine Goroutine
+}
+
+// panicRegex matches the line a panic starts with. The testing package
+// recovers a test's panic to report the test failed and panics again,
+// which newer toolchains mark "[recovered, repanicked]" and older ones
+// "[recovered]", with the panic repeated on an indented line below.
+var panicRegex = regexp.MustCompile(`^(panic|fatal error): (.*?)(?: \[recovered(?:, repanicked)?\])?$`)
+
+// ParsePanic parses the last panic in the output of a test, nil if it has
+// none. A panic is only recognized with the stack trace that follows it,
+// so a test printing "panic:" does not count.
+func ParsePanic(output string) *Panic {
+	if !strings.Contains(output, "panic: ") && !strings.Contains(output, "fatal error: ") {
+		return nil
+	}
+
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		m := panicRegex.FindStringSubmatch(strings.TrimRight(lines[i], "\r"))
+		if m == nil {
+			continue
+		}
+		goroutines := ParseGoroutineDump(strings.Join(lines[i:], "\n"))
+		if len(goroutines) == 0 {
+			return nil
+		}
+		return &Panic{Value: m[2], Fatal: m[1] == "fatal error", Goroutine: goroutines[0]}
+	}
+	return nil
+}
+
+// Summary formats the panic on one line, as go prints its first line
+func (p *Panic) Summary() string {
+	if p.Fatal {
+		return "fatal error: " + p.Value
+	}
+	return "panic: " + p.Value
+}
+
+// Origin returns the index of the frame the panic came from: the innermost
+// frame in module, the module under test. Without a module, or when no
+// frame is in it, it is the innermost frame outside the standard library,
+// and -1 when all frames are in the standard library, as for a test timing
+// out.
+func (p *Panic) Origin(module string) int {
+	frames := p.Goroutine.Frames
+	if module != "" {
+		for i, frame := range frames {
+			if pkg := frame.Package(); pkg == module || strings.HasPrefix(pkg, module+"/") {
+				return i
+			}
+		}
+	}
+	for i, frame := range frames {
+		if !frame.Std() {
+			return i
+		}
+	}
+	return -1
+}
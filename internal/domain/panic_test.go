@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// go122Panic rewrites the first line of a test's panic to the form Go 1.22
+// and earlier print, where the testing package's repanic repeats the value
+// on an indented line instead of marking it "repanicked"
+func go122Panic(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if value, ok := strings.CutSuffix(line, " [recovered, repanicked]"); ok {
+			repeated := "\tpanic: " + strings.TrimPrefix(value, "panic: ")
+			lines[i] = value + " [recovered]\n" + repeated
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestParsePanic(t *testing.T) {
+	const module = "example.com/fs"
+	tests := []struct {
+		name      string
+		fixture   string
+		rewrite   func(string) string
+		value     string
+		fatal     bool
+		goroutine int
+		// origin is the frame the panic came from in module, and
+		// originOutside the one without a module
+		origin, originOutside string
+	}{
+		{
+			name:          "recovered, repanicked",
+			fixture:       "panic_plain.txt",
+			value:         "assignment to entry in nil map",
+			goroutine:     6,
+			origin:        "example.com/fs/pn.explode pn_test.go:11",
+			originOutside: "example.com/fs/pn.explode pn_test.go:11",
+		},
+		{
+			name:          "recovered, Go 1.22",
+			fixture:       "panic_plain.txt",
+			rewrite:       go122Panic,
+			value:         "assignment to entry in nil map",
+			goroutine:     6,
+			origin:        "example.com/fs/pn.explode pn_test.go:11",
+			originOutside: "example.com/fs/pn.explode pn_test.go:11",
+		},
+		{
+			name:          "subtest",
+			fixture:       "panic_subtest.txt",
+			value:         "runtime error: index out of range [3] with length 0",
+			goroutine:     7,
+			origin:        "example.com/fs/pn.TestSubtest.func1 pn_test.go:20",
+			originOutside: "example.com/fs/pn.TestSubtest.func1 pn_test.go:20",
+		},
+		{
+			// A deferred function panicking while the test panics; the
+			// value is the first panic of the chain
+			name:          "nested",
+			fixture:       "panic_nested.txt",
+			value:         "first",
+			goroutine:     6,
+			origin:        "example.com/fs/pn.TestNested.func1 pn_test.go:26",
+			originOutside: "example.com/fs/pn.TestNested.func1 pn_test.go:26",
+		},
+		{
+			name:          "recovered and panicking again",
+			fixture:       "panic_wrapped.txt",
+			value:         "first",
+			goroutine:     6,
+			origin:        "example.com/fs/pn.TestWrapped.func1 pn_test.go:34",
+			originOutside: "example.com/fs/pn.TestWrapped.func1 pn_test.go:34",
+		},
+		{
+			// The panic is raised by a dependency, called from the module
+			name:          "in a dependency",
+			fixture:       "panic_dependency.txt",
+			value:         "no config",
+			goroutine:     6,
+			origin:        "example.com/fs/pn.TestDependency pn_test.go:42",
+			originOutside: "example.com/dep.Must dep.go:6",
+		},
+		{
+			name:          "fatal error",
+			fixture:       "panic_fatal.txt",
+			value:         "concurrent map writes",
+			fatal:         true,
+			goroutine:     7,
+			origin:        "example.com/fs/pn.TestFatal.func1 pn_test.go:51",
+			originOutside: "example.com/fs/pn.TestFatal.func1 pn_test.go:51",
+		},
+		{
+			// Every frame of the alarm goroutine is in the standard library
+			name:      "timeout",
+			fixture:   "panic_timeout.txt",
+			value:     "test timed out after 1s",
+			goroutine: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := strings.Join(readLines(t, tt.fixture), "\n")
+			if tt.rewrite != nil {
+				output = tt.rewrite(output)
+			}
+			p := ParsePanic(output)
+			if p == nil {
+				t.Fatal("ParsePanic found no panic")
+			}
+			if p.Value != tt.value || p.Fatal != tt.fatal || p.Goroutine.ID != tt.goroutine {
+				t.Errorf("ParsePanic = %q fatal %v in goroutine %d, want %q fatal %v in goroutine %d",
+					p.Value, p.Fatal, p.Goroutine.ID, tt.value, tt.fatal, tt.goroutine)
+			}
+			if got := frameAt(p, p.Origin(module)); got != tt.origin {
+				t.Errorf("Origin(%q) = %q, want %q", module, got, tt.origin)
+			}
+			if got := frameAt(p, p.Origin("")); got != tt.originOutside {
+				t.Errorf("Origin(\"\") = %q, want %q", got, tt.originOutside)
+			}
+		})
+	}
+}
+
+func TestParsePanicWithoutStack(t *testing.T) {
+	for _, output := range []string{
+		"",
+		"=== RUN   TestLog\n    log_test.go:9: panic: not really\n--- PASS: TestLog (0.00s)\n",
+		"panic: boom [recovered]\n\tpanic: boom\n",
+	} {
+		if p := ParsePanic(output); p != nil {
+			t.Errorf("ParsePanic(%q) = %+v, want nil", output, p)
+		}
+	}
+}
+
+// frameAt formats the frame of the panicking goroutine at index i as
+// "func file:line", empty for -1
+func frameAt(p *Panic, i int) string {
+	if i < 0 {
+		return ""
+	}
+	frame := p.Goroutine.Frames[i]
+	file := frame.File[strings.LastIndex(frame.File, "/")+1:]
+	return frame.Func + " " + file + ":" + strconv.Itoa(frame.Line)
+}
@@ -0,0 +1,247 @@
+package domain
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DataRace is a data race the race detector reported, in a binary built
+// with -race
+type DataRace struct {
+	// Access is the access that raced, Previous the earlier access of
+	// another goroutine it conflicts with
+	Access   RaceAccess
+	Previous RaceAccess
+	// Location describes the memory raced on, such as "global 'count' of
+	// size 8"; the detector only reports it for some memory
+	Location string
+	// Tests are the tests that reported the race, in the order reported. A
+	// race reported outside any test, such as in TestMain, has a test ID
+	// with an empty name.
+	Tests []TestID
+}
+
+// RaceAccess is one of the conflicting accesses of a data race
+type RaceAccess struct {
+	// Op is the kind of access in lower case: "read", "write", "atomic
+	// read" or "atomic write"
+	Op string
+	// Goroutine is the ID of the goroutine that accessed the memory, zero
+	// for the main goroutine
+	Goroutine int
+	// Frames are the calls of the access, innermost first
+	Frames []StackFrame
+	// State is whether the goroutine was "running" or "finished" at the
+	// time of the report, and Created the go statement that started it,
+	// innermost first; both are empty for the main goroutine
+	State   string
+	Created []StackFrame
+}
+
+// Site returns where the access happened: its innermost frame outside the
+// standard library, or its innermost frame when there is none
+func (a RaceAccess) Site() (StackFrame, bool) {
+	return topFrame(a.Frames)
+}
+
+// CreatedAt returns where the access's goroutine was started: the innermost
+// frame of its go statement outside the standard library
+func (a RaceAccess) CreatedAt() (StackFrame, bool) {
+	return topFrame(a.Created)
+}
+
+// Describe formats the access as the report heads it, such as "read at
+// counter.go:12 by goroutine 7", with the file's base name
+func (a RaceAccess) Describe() string {
+	text := a.Op
+	if site, ok := a.Site(); ok {
+		text += " at " + filepath.Base(site.File) + ":" + strconv.Itoa(site.Line)
+	}
+	if a.Goroutine == 0 {
+		return text + " by main goroutine"
+	}
+	return text + " by goroutine " + strconv.Itoa(a.Goroutine)
+}
+
+// site formats the access's kind and site to identify its race
+func (a RaceAccess) site() string {
+	site, _ := a.Site()
+	return a.Op + " " + site.File + ":" + strconv.Itoa(site.Line)
+}
+
+// Key identifies a race by the kinds and sites of its accesses. The same
+// race is reported again by other tests, through other callers, and with
+// either access first.
+func (r DataRace) Key() string {
+	sites := []string{r.Access.site(), r.Previous.site()}
+	sort.Strings(sites)
+	return strings.Join(sites, " | ")
+}
+
+// Summary formats the race on one line, as "read at a.go:12 races with
+// write at a.go:20"
+func (r DataRace) Summary() string {
+	site := func(a RaceAccess) string {
+		frame, _ := a.Site()
+		return a.Op + " at " + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+	}
+	return site(r.Access) + " races with " + site(r.Previous)
+}
+
+const (
+	// raceWarning is the first line of a race report
+	raceWarning = "WARNING: DATA RACE"
+	// raceSeparator is printed above and below a race report
+	raceSeparator = "=================="
+)
+
+// raceAccessRegex matches "Read at 0x00c0000182a8 by goroutine 9:" and
+// "Previous write at 0x00c0000182a8 by main goroutine:"
+var raceAccessRegex = regexp.MustCompile(`^(Previous )?((?:[Aa]tomic )?(?:[Rr]ead|[Ww]rite)) at 0x[0-9a-f]+ by (?:main goroutine|goroutine (\d+))(?: \(.*\))?:$`)
+
+// raceCreatedRegex matches "Goroutine 9 (running) created at:"
+var raceCreatedRegex = regexp.MustCompile(`^Goroutine (\d+) \((\w+)\) created at:$`)
+
+// ParseDataRace parses the lines of a race report between its "WARNING:
+// DATA RACE" and the separator below it. It reports false for reports
+// without both accesses.
+func ParseDataRace(lines []string) (DataRace, bool) {
+	var race DataRace
+	var frames *[]StackFrame
+	var fn string
+	created := make(map[int]*RaceAccess)
+	seen := 0
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r\n")
+
+		if m := raceAccessRegex.FindStringSubmatch(line); m != nil {
+			access := &race.Access
+			if m[1] != "" {
+				access = &race.Previous
+			}
+			access.Op = strings.ToLower(m[2])
+			access.Goroutine, _ = strconv.Atoi(m[3])
+			if access.Goroutine != 0 {
+				created[access.Goroutine] = access
+			}
+			frames = &access.Frames
+			seen++
+			fn = ""
+			continue
+		}
+		if m := raceCreatedRegex.FindStringSubmatch(line); m != nil {
+			frames = nil
+			id, _ := strconv.Atoi(m[1])
+			if access, ok := created[id]; ok {
+				access.State = m[2]
+				frames = &access.Created
+			}
+			fn = ""
+			continue
+		}
+		if location, ok := strings.CutPrefix(line, "Location is "); ok {
+			race.Location = location
+			frames = nil
+			continue
+		}
+
+		// Frames are a call indented by two spaces, such as
+		// "  example.com/pkg.(*T).inc()", above its location indented by six
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case frames == nil || trimmed == "":
+			fn = ""
+		case strings.HasPrefix(line, "      ") && fn != "":
+			*frames = append(*frames, parseFrameLocation(fn, trimmed))
+			fn = ""
+		case strings.HasPrefix(line, "  "):
+			fn = trimmed
+			if i := strings.LastIndex(fn, "("); i > 0 && strings.HasSuffix(fn, ")") {
+				fn = fn[:i]
+			}
+		}
+	}
+	return race, seen == 2 && race.Access.Op != "" && race.Previous.Op != ""
+}
+
+// RaceLog collects the data races in test output fed to it line by line,
+// de-duplicating races reported by several tests
+type RaceLog struct {
+	// Races are the unique races in the order first reported
+	Races []DataRace
+	// reports are the lines of the reports being read by package, as the
+	// output of test binaries may interleave, and reporting the test that
+	// printed each report's warning
+	reports map[string]*raceReport
+}
+
+// raceReport is a race report being read
+type raceReport struct {
+	test  TestID
+	lines []string
+}
+
+// Add parses a line of the output of test id, whose name is empty for
+// package output. When the line ends a race report it returns the race,
+// reported by id, which Merge has added to the log.
+func (l *RaceLog) Add(id TestID, line string) (DataRace, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	report := l.reports[id.Pkg]
+	if report == nil {
+		if line == raceWarning {
+			if l.reports == nil {
+				l.reports = make(map[string]*raceReport)
+			}
+			l.reports[id.Pkg] = &raceReport{test: id}
+		}
+		return DataRace{}, false
+	}
+	if line != raceSeparator {
+		report.lines = append(report.lines, line)
+		return DataRace{}, false
+	}
+
+	delete(l.reports, id.Pkg)
+	race, ok := ParseDataRace(report.lines)
+	if !ok {
+		return DataRace{}, false
+	}
+	race.Tests = []TestID{report.test}
+	l.Merge(race)
+	return race, true
+}
+
+// Merge adds a race to the log, or the tests that reported it to the same
+// race already in it. It reports whether the race is new.
+func (l *RaceLog) Merge(race DataRace) bool {
+	key := race.Key()
+	for i := range l.Races {
+		known := &l.Races[i]
+		if known.Key() != key {
+			continue
+		}
+		for _, id := range race.Tests {
+			if !containsTestID(known.Tests, id) {
+				known.Tests = append(known.Tests, id)
+			}
+		}
+		return false
+	}
+	race.Tests = append([]TestID(nil), race.Tests...)
+	l.Races = append(l.Races, race)
+	return true
+}
+
+// containsTestID reports whether ids contains id
+func containsTestID(ids []TestID, id TestID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
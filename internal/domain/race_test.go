@@ -0,0 +1,244 @@
+package domain
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// raceReports returns the lines of each race report in the output lines,
+// between the warning and the separator below it
+func raceReports(lines []string) [][]string {
+	var reports [][]string
+	var report []string
+	inReport := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == raceWarning:
+			inReport = true
+			report = nil
+		case inReport && line == raceSeparator:
+			inReport = false
+			reports = append(reports, report)
+		case inReport:
+			report = append(report, line)
+		}
+	}
+	return reports
+}
+
+// packageOutput returns the output lines of the package in the events
+func packageOutput(events []TestEvent, pkg string) []string {
+	var lines []string
+	for _, event := range events {
+		if event.Action == "output" && event.Package == pkg {
+			lines = append(lines, event.Output)
+		}
+	}
+	return lines
+}
+
+func TestParseDataRace(t *testing.T) {
+	events := readEvents(t, "race.json")
+	readThenWrite := raceReports(packageOutput(events, "example.com/fs/rw"))[0]
+	mainGoroutine := raceReports(readLines(t, "race_main.txt"))[0]
+
+	type access struct {
+		op        string
+		goroutine int
+		site      string
+		state     string
+		createdAt string
+	}
+	tests := []struct {
+		name             string
+		lines            []string
+		access, previous access
+		describe         string
+		summary          string
+	}{
+		{
+			name:     "previous read",
+			lines:    readThenWrite,
+			access:   access{"write", 7, "/tmp/fsample/rw/rw_test.go:16", "running", "testing.go:2258"},
+			previous: access{"read", 8, "/tmp/fsample/rw/rw_test.go:13", "running", "rw_test.go:12"},
+			describe: "write at rw_test.go:16 by goroutine 7",
+			summary:  "write at rw_test.go:16 races with read at rw_test.go:13",
+		},
+		{
+			name:     "previous write, main goroutine",
+			lines:    mainGoroutine,
+			access:   access{"read", 0, "/tmp/fsample/rm/rm_test.go:16", "", ""},
+			previous: access{"write", 7, "/tmp/fsample/rm/rm_test.go:13", "finished", "rm_test.go:12"},
+			describe: "read at rm_test.go:16 by main goroutine",
+			summary:  "read at rm_test.go:16 races with write at rm_test.go:13",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			race, ok := ParseDataRace(tt.lines)
+			if !ok {
+				t.Fatal("ParseDataRace reported no race")
+			}
+			for _, c := range []struct {
+				got  RaceAccess
+				want access
+			}{{race.Access, tt.access}, {race.Previous, tt.previous}} {
+				site, _ := c.got.Site()
+				got := access{op: c.got.Op, goroutine: c.got.Goroutine, site: site.File + ":" + strconv.Itoa(site.Line), state: c.got.State}
+				if created, ok := c.got.CreatedAt(); ok {
+					got.createdAt = filepath.Base(created.File) + ":" + strconv.Itoa(created.Line)
+				}
+				if got != c.want {
+					t.Errorf("access = %+v, want %+v", got, c.want)
+				}
+			}
+			if got := race.Access.Describe(); got != tt.describe {
+				t.Errorf("Describe() = %q, want %q", got, tt.describe)
+			}
+			if got := race.Summary(); got != tt.summary {
+				t.Errorf("Summary() = %q, want %q", got, tt.summary)
+			}
+		})
+	}
+
+	t.Run("one access", func(t *testing.T) {
+		// Cut the report before its previous access
+		for i, line := range readThenWrite {
+			if strings.HasPrefix(line, "Previous ") {
+				if _, ok := ParseDataRace(readThenWrite[:i]); ok {
+					t.Error("ParseDataRace reported a race without its previous access")
+				}
+				return
+			}
+		}
+		t.Fatal("fixture has no previous access")
+	})
+}
+
+func TestRaceLog(t *testing.T) {
+	var log RaceLog
+	var reported []TestID
+	for _, event := range readEvents(t, "race.json") {
+		if event.Action != "output" {
+			continue
+		}
+		race, ok := log.Add(TestID{Pkg: event.Package, Name: event.Test}, event.Output)
+		if !ok {
+			continue
+		}
+		if len(race.Tests) != 1 {
+			t.Errorf("race reported by %v, want the one test that printed it", race.Tests)
+		}
+		reported = append(reported, race.Tests...)
+	}
+
+	// Every report is returned, in the order printed
+	wantReported := []TestID{
+		{Pkg: "example.com/fs/r", Name: "TestRaceA"},
+		{Pkg: "example.com/fs/r", Name: "TestRaceB"},
+		{Pkg: "example.com/fs/r2", Name: "TestShared"},
+		{Pkg: "example.com/fs/racy", Name: "TestAdd"},
+		{Pkg: "example.com/fs/rw", Name: "TestReadThenWrite"},
+	}
+	if !reflect.DeepEqual(reported, wantReported) {
+		t.Errorf("reports by %v, want %v", reported, wantReported)
+	}
+
+	// A race two tests report, and a race two packages report, are logged
+	// once with the tests that reported them
+	want := []struct {
+		key   string
+		tests []TestID
+	}{
+		{
+			key:   "read /tmp/fsample/r/r_test.go:10 | write /tmp/fsample/r/r_test.go:10",
+			tests: wantReported[0:2],
+		},
+		{
+			key:   "read /tmp/fsample/racy/racy.go:13 | write /tmp/fsample/racy/racy.go:13",
+			tests: wantReported[2:4],
+		},
+		{
+			key:   "read /tmp/fsample/rw/rw_test.go:13 | write /tmp/fsample/rw/rw_test.go:16",
+			tests: wantReported[4:5],
+		},
+	}
+	if len(log.Races) != len(want) {
+		t.Fatalf("%d races logged, want %d", len(log.Races), len(want))
+	}
+	for i, race := range log.Races {
+		if race.Key() != want[i].key || !reflect.DeepEqual(race.Tests, want[i].tests) {
+			t.Errorf("race %d = %q reported by %v, want %q reported by %v",
+				i, race.Key(), race.Tests, want[i].key, want[i].tests)
+		}
+	}
+}
+
+func TestRaceLogInterleavedPackages(t *testing.T) {
+	// The reports of two packages whose output interleaves line by line
+	events := readEvents(t, "race.json")
+	r2 := packageOutput(events, "example.com/fs/r2")
+	racy := packageOutput(events, "example.com/fs/racy")
+
+	var log RaceLog
+	reports := 0
+	for i := 0; i < max(len(r2), len(racy)); i++ {
+		if i < len(r2) {
+			if _, ok := log.Add(TestID{Pkg: "example.com/fs/r2", Name: "TestShared"}, r2[i]); ok {
+				reports++
+			}
+		}
+		if i < len(racy) {
+			if _, ok := log.Add(TestID{Pkg: "example.com/fs/racy", Name: "TestAdd"}, racy[i]); ok {
+				reports++
+			}
+		}
+	}
+	if reports != 2 || len(log.Races) != 1 || len(log.Races[0].Tests) != 2 {
+		t.Errorf("%d reports of %d races, want 2 reports of 1 race by 2 tests", reports, len(log.Races))
+	}
+}
+
+func TestRaceLogMerge(t *testing.T) {
+	first, _ := ParseDataRace(raceReports(packageOutput(readEvents(t, "race.json"), "example.com/fs/rw"))[0])
+	a := TestID{Pkg: "example.com/fs/rw", Name: "TestA"}
+	b := TestID{Pkg: "example.com/fs/rw", Name: "TestB"}
+
+	var log RaceLog
+	first.Tests = []TestID{a}
+	if !log.Merge(first) {
+		t.Error("Merge did not report the first race new")
+	}
+
+	// The same race with its accesses the other way round
+	swapped := first
+	swapped.Access, swapped.Previous = first.Previous, first.Access
+	swapped.Tests = []TestID{b, a}
+	if swapped.Key() != first.Key() {
+		t.Errorf("Key() = %q with the accesses swapped, want %q", swapped.Key(), first.Key())
+	}
+	if log.Merge(swapped) {
+		t.Error("Merge reported a known race new")
+	}
+
+	// Another race at the same sites with other accesses
+	other := first
+	other.Previous.Op = "write"
+	other.Tests = []TestID{b}
+	if !log.Merge(other) {
+		t.Error("Merge did not report a race with other accesses new")
+	}
+
+	if len(log.Races) != 2 || !reflect.DeepEqual(log.Races[0].Tests, []TestID{a, b}) {
+		t.Errorf("races = %d, first reported by %v; want 2, first by [a b]", len(log.Races), log.Races[0].Tests)
+	}
+	// The log keeps its own copy of the tests of a new race
+	first.Tests[0] = TestID{}
+	if log.Races[0].Tests[0] != a {
+		t.Error("merged race shares its tests with the race merged")
+	}
+}
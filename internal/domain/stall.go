@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// TestStall reports a running test that printed nothing for longer than
+// the stall threshold
+type TestStall struct {
+	ID TestID
+	// Since is when the test last printed output, or started
+	Since time.Time
+}
+
+// Idle returns how long the test has been silent at now
+func (s TestStall) Idle(now time.Time) time.Duration {
+	return now.Sub(s.Since)
+}
+
+// StallWatch follows the output of running tests to find the ones that
+// stopped making progress, such as tests waiting on a deadlock
+type StallWatch struct {
+	threshold time.Duration
+	tests     map[TestID]*watchedTest
+}
+
+// watchedTest is the state of a running test
+type watchedTest struct {
+	last    time.Time
+	paused  bool
+	stalled bool
+}
+
+// NewStallWatch creates a watch flagging tests silent for longer than
+// threshold
+func NewStallWatch(threshold time.Duration) *StallWatch {
+	return &StallWatch{
+		threshold: threshold,
+		tests:     make(map[TestID]*watchedTest),
+	}
+}
+
+// Observe records a test event received at now
+func (w *StallWatch) Observe(event TestEvent, now time.Time) {
+	if event.Test == "" {
+		return
+	}
+	id := TestID{Pkg: event.Package, Name: event.Test}
+
+	if event.Action == "run" {
+		// Benchmarks print nothing until they finish, and test2json does
+		// not report them passing
+		if KindOf(event.Test) != KindBenchmark {
+			w.tests[id] = &watchedTest{last: now}
+		}
+		return
+	}
+	test, ok := w.tests[id]
+	if !ok {
+		return
+	}
+	switch event.Action {
+	case "pass", "fail", "skip", "bench":
+		delete(w.tests, id)
+	case "pause":
+		// Parallel tests wait for their siblings without output
+		test.paused = true
+	case "cont", "output":
+		test.paused = false
+		test.stalled = false
+		test.last = now
+	}
+}
+
+// Check returns the tests that stalled since the previous check, sorted.
+// Paused tests and tests waiting for their subtests are not stalled; a
+// stalled test is reported again only after it printed something.
+func (w *StallWatch) Check(now time.Time) []TestStall {
+	var stalls []TestStall
+	for id, test := range w.tests {
+		if test.paused || test.stalled || now.Sub(test.last) <= w.threshold || w.hasSubtests(id) {
+			continue
+		}
+		test.stalled = true
+		stalls = append(stalls, TestStall{ID: id, Since: test.last})
+	}
+
+	sort.Slice(stalls, func(i, j int) bool {
+		if stalls[i].ID.Pkg != stalls[j].ID.Pkg {
+			return stalls[i].ID.Pkg < stalls[j].ID.Pkg
+		}
+		return stalls[i].ID.Name < stalls[j].ID.Name
+	})
+	return stalls
+}
+
+// hasSubtests reports whether a subtest of id is running
+func (w *StallWatch) hasSubtests(id TestID) bool {
+	prefix := id.Name + "/"
+	for other := range w.tests {
+		if other.Pkg == id.Pkg && strings.HasPrefix(other.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
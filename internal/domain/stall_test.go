@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStallWatch(t *testing.T) {
+	const threshold = 10 * time.Second
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	// A step observes an event or, without one, checks at its time,
+	// expecting the stalls of the named tests since the times given
+	type stall struct {
+		test  string
+		since int
+	}
+	type step struct {
+		at     int
+		action string
+		test   string
+		stalls []stall
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "silent past the threshold",
+			steps: []step{
+				{at: 0, action: "run", test: "TestA"},
+				{at: 3, action: "output", test: "TestA"},
+				{at: 13},
+				{at: 14, stalls: []stall{{"TestA", 3}}},
+				// Reported once until the test prints again
+				{at: 60},
+				{at: 61, action: "output", test: "TestA"},
+				{at: 71},
+				{at: 72, stalls: []stall{{"TestA", 61}}},
+			},
+		},
+		{
+			name: "finished",
+			steps: []step{
+				{at: 0, action: "run", test: "TestA"},
+				{at: 0, action: "run", test: "TestB"},
+				{at: 0, action: "run", test: "TestC"},
+				{at: 0, action: "run", test: "TestD"},
+				{at: 5, action: "pass", test: "TestA"},
+				{at: 5, action: "fail", test: "TestB"},
+				{at: 5, action: "skip", test: "TestC"},
+				{at: 30, stalls: []stall{{"TestD", 0}}},
+			},
+		},
+		{
+			// Parallel tests wait for their siblings without output
+			name: "paused",
+			steps: []step{
+				{at: 0, action: "run", test: "TestA"},
+				{at: 1, action: "pause", test: "TestA"},
+				{at: 30},
+				{at: 31, action: "cont", test: "TestA"},
+				{at: 41},
+				{at: 42, stalls: []stall{{"TestA", 31}}},
+			},
+		},
+		{
+			name: "waiting for subtests",
+			steps: []step{
+				{at: 0, action: "run", test: "TestA"},
+				{at: 1, action: "run", test: "TestA/sub"},
+				{at: 2, action: "run", test: "TestAB"},
+				{at: 20, stalls: []stall{{"TestA/sub", 1}, {"TestAB", 2}}},
+				{at: 21, action: "pass", test: "TestA/sub"},
+				{at: 22, stalls: []stall{{"TestA", 0}}},
+			},
+		},
+		{
+			// Benchmarks print nothing until they finish
+			name: "benchmark",
+			steps: []step{
+				{at: 0, action: "run", test: "BenchmarkA"},
+				{at: 0, action: "run", test: "FuzzA"},
+				{at: 0, action: "run", test: "ExampleA"},
+				{at: 60, stalls: []stall{{"ExampleA", 0}, {"FuzzA", 0}}},
+			},
+		},
+		{
+			name: "events without a run",
+			steps: []step{
+				{at: 0, action: "output", test: "TestA"},
+				{at: 0, action: "output"},
+				{at: 60},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewStallWatch(threshold)
+			for _, s := range tt.steps {
+				if s.action != "" {
+					w.Observe(TestEvent{Action: s.action, Package: "example.com/a", Test: s.test}, at(s.at))
+					continue
+				}
+				var want []TestStall
+				for _, st := range s.stalls {
+					want = append(want, TestStall{ID: TestID{Pkg: "example.com/a", Name: st.test}, Since: at(st.since)})
+				}
+				if got := w.Check(at(s.at)); !reflect.DeepEqual(got, want) {
+					t.Errorf("Check at %ds = %v, want %v", s.at, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStallWatchOrder(t *testing.T) {
+	w := NewStallWatch(time.Second)
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, id := range []TestID{
+		{Pkg: "example.com/b", Name: "TestA"},
+		{Pkg: "example.com/a", Name: "TestB"},
+		{Pkg: "example.com/a", Name: "TestA"},
+	} {
+		w.Observe(TestEvent{Action: "run", Package: id.Pkg, Test: id.Name}, start)
+	}
+	stalls := w.Check(start.Add(time.Minute))
+	var got []TestID
+	for _, s := range stalls {
+		got = append(got, s.ID)
+		if idle := s.Idle(start.Add(2 * time.Minute)); idle != 2*time.Minute {
+			t.Errorf("%v idle for %v, want 2m", s.ID, idle)
+		}
+	}
+	want := []TestID{
+		{Pkg: "example.com/a", Name: "TestA"},
+		{Pkg: "example.com/a", Name: "TestB"},
+		{Pkg: "example.com/b", Name: "TestA"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stalls = %v, want %v", got, want)
+	}
+}
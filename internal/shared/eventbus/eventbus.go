@@ -93,12 +93,18 @@ func (b *EventBus) Close() {
 
 // Topics for the application
 const (
-	TopicTestEvent     = "test.event"
-	TopicTestStarted   = "test.started"
-	TopicTestCompleted = "test.completed"
-	TopicTestFailed    = "test.failed"
-	TopicPackageFound  = "package.found"
-	TopicFSChanged     = "fs.changed"
-	TopicCoverageReady = "coverage.ready"
-	TopicError         = "error"
+	TopicTestEvent       = "test.event"
+	TopicTestStarted     = "test.started"
+	TopicTestCompleted   = "test.completed"
+	TopicTestFailed      = "test.failed"
+	TopicTestStalled     = "test.stalled"
+	TopicBenchmarkResult = "benchmark.result"
+	TopicFuzzProgress    = "fuzz.progress"
+	TopicFuzzCrasher     = "fuzz.crasher"
+	TopicDataRace        = "race.detected"
+	TopicPackageFound    = "package.found"
+	TopicFSChanged       = "fs.changed"
+	TopicCoverageReady   = "coverage.ready"
+	TopicCoverageIndex   = "coverage.index"
+	TopicError           = "error"
 )
@@ -0,0 +1,91 @@
+// Package testutil holds the test doubles shared by the tests of several
+// packages
+package testutil
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// FakeRunner reports the tests of each package that a run's -run pattern
+// selects as passed. It records the options of every run and how many runs
+// are in progress at once.
+type FakeRunner struct {
+	// Tests are the test names of each package
+	Tests map[string][]string
+	// Delay is how long each run takes before reporting
+	Delay time.Duration
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	runs      []runner.RunOptions
+}
+
+// Run reports the selected tests of each package of the run
+func (r *FakeRunner) Run(ctx context.Context, opts runner.RunOptions) (<-chan domain.TestEvent, <-chan error) {
+	r.mu.Lock()
+	r.active++
+	r.maxActive = max(r.maxActive, r.active)
+	r.runs = append(r.runs, opts)
+	r.mu.Unlock()
+
+	events := make(chan domain.TestEvent)
+	errs := make(chan error)
+	go func() {
+		defer close(errs)
+		defer close(events)
+		defer func() {
+			r.mu.Lock()
+			r.active--
+			r.mu.Unlock()
+		}()
+
+		time.Sleep(r.Delay)
+		top, _, _ := strings.Cut(opts.RunRegex, "/")
+		selected := regexp.MustCompile(top)
+		for _, pkg := range opts.Packages {
+			events <- domain.TestEvent{Action: "start", Package: pkg}
+			for _, name := range r.Tests[pkg] {
+				if !selected.MatchString(name) {
+					continue
+				}
+				events <- domain.TestEvent{Action: "run", Package: pkg, Test: name}
+				events <- domain.TestEvent{Action: "pass", Package: pkg, Test: name}
+			}
+			events <- domain.TestEvent{Action: "pass", Package: pkg}
+		}
+	}()
+	return events, errs
+}
+
+// ListTests lists the tests of the packages of the run
+func (r *FakeRunner) ListTests(ctx context.Context, opts runner.RunOptions) ([]domain.TestID, error) {
+	var ids []domain.TestID
+	for _, pkg := range opts.Packages {
+		for _, name := range r.Tests[pkg] {
+			ids = append(ids, domain.TestID{Pkg: pkg, Name: name})
+		}
+	}
+	return ids, nil
+}
+
+// Runs returns the options of every run, in the order they started
+func (r *FakeRunner) Runs() []runner.RunOptions {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]runner.RunOptions(nil), r.runs...)
+}
+
+// MaxActive returns the most runs that were in progress at once
+func (r *FakeRunner) MaxActive() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.maxActive
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"os"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/coverprofile"
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+	"github.com/YuminosukeSato/lazygotest/pkg/logger"
+)
+
+// coverProfile is a coverage profile a run writes; temporary ones are
+// removed once read
+type coverProfile struct {
+	path string
+	temp bool
+	// test is set when the run executed just this test, for the coverage
+	// index
+	test domain.TestID
+}
+
+// withCoverProfiles points every run with coverage enabled at a profile to
+// write: the configured one, or a temporary file. Runs of a multi-package
+// selection always get their own file so they don't overwrite each other.
+func withCoverProfiles(runs []runner.RunOptions) []coverProfile {
+	var profiles []coverProfile
+	for i := range runs {
+		if !runs[i].Cover {
+			continue
+		}
+		if runs[i].CoverProfile != "" && len(runs) == 1 {
+			profiles = append(profiles, coverProfile{path: runs[i].CoverProfile})
+			continue
+		}
+
+		path, err := coverprofile.TempFile()
+		if err != nil {
+			logger.Error("Coverage profile unavailable", "error", err)
+			continue
+		}
+		runs[i].CoverProfile = path
+		profiles = append(profiles, coverProfile{path: path, temp: true})
+	}
+	return profiles
+}
+
+// testCoverProfiles points each run, which executes the test of the same
+// index, at a temporary profile of its own
+func testCoverProfiles(runs []runner.RunOptions, tests []domain.TestID) []coverProfile {
+	var profiles []coverProfile
+	for i := range runs {
+		path, err := coverprofile.TempFile()
+		if err != nil {
+			logger.Error("Coverage profile unavailable", "error", err)
+			continue
+		}
+		runs[i].Cover = true
+		runs[i].CoverProfile = path
+		profiles = append(profiles, coverProfile{path: path, temp: true, test: tests[i]})
+	}
+	return profiles
+}
+
+// loadCoverage reads the profiles of a run into one coverage model, and
+// the profiles of single tests into an index of the lines each executes.
+// Either is nil when no profile for it could be read, such as after a
+// build failure or for replayed output.
+func loadCoverage(profiles []coverProfile) (*domain.Coverage, *domain.CoverageIndex) {
+	var mode string
+	var blocks []domain.CoverBlock
+	var index *domain.CoverageIndex
+	loaded := false
+	for _, profile := range profiles {
+		m, b, err := coverprofile.Load(profile.path)
+		if profile.temp {
+			os.Remove(profile.path)
+		}
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				logger.Debug("No coverage profile written", "path", profile.path)
+			} else {
+				logger.Error("Failed to read coverage profile", "error", err)
+			}
+			continue
+		}
+		if m == "" {
+			// Created but never written, such as when the build failed
+			continue
+		}
+		mode = m
+		blocks = append(blocks, b...)
+		loaded = true
+
+		if profile.test.Name != "" {
+			if index == nil {
+				index = domain.NewCoverageIndex()
+			}
+			index.Add(profile.test, b)
+		}
+	}
+
+	if !loaded {
+		return nil, nil
+	}
+	return domain.NewCoverage(mode, blocks), index
+}
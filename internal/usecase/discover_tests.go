@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/pkg/errors"
+	"github.com/YuminosukeSato/lazygotest/pkg/logger"
+)
+
+// DiscoverTestsUseCase builds the test inventory of a set of packages
+type DiscoverTestsUseCase struct {
+	repo   PackageRepository
+	runner TestRunner
+}
+
+// NewDiscoverTestsUseCase creates a new DiscoverTestsUseCase
+func NewDiscoverTestsUseCase(repo PackageRepository, runner TestRunner) *DiscoverTestsUseCase {
+	return &DiscoverTestsUseCase{
+		repo:   repo,
+		runner: runner,
+	}
+}
+
+// Execute returns the packages matching opts.Packages with their Test,
+// Benchmark, Fuzz and Example functions filled in. The packages whose tests
+// could not be listed are returned with a *runner.ListError naming them.
+func (uc *DiscoverTestsUseCase) Execute(ctx context.Context, opts runner.RunOptions) ([]*domain.Package, error) {
+	logger.Debug("Executing DiscoverTestsUseCase", "patterns", opts.Packages)
+
+	packages, err := uc.repo.ListPackages(ctx, opts.Packages)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return packages, nil
+	}
+
+	byID := make(map[domain.PkgID]*domain.Package, len(packages))
+	listOpts := opts
+	listOpts.Packages = make([]string, len(packages))
+	for i, pkg := range packages {
+		pkg.Tests = nil
+		byID[pkg.ID] = pkg
+		listOpts.Packages[i] = string(pkg.ID)
+	}
+
+	testIDs, err := uc.runner.ListTests(ctx, listOpts)
+	var listErr *runner.ListError
+	if err != nil && !errors.As(err, &listErr) {
+		return nil, err
+	}
+
+	for _, id := range testIDs {
+		pkg, ok := byID[domain.PkgID(id.Pkg)]
+		if !ok {
+			continue
+		}
+		pkg.Tests = append(pkg.Tests, domain.TestCase{
+			ID:      id,
+			Package: id.Pkg,
+			Name:    id.Name,
+			Kind:    domain.KindOf(id.Name),
+		})
+	}
+
+	logger.Info("Discovered tests", "packages", len(packages), "tests", len(testIDs))
+	return packages, err
+}
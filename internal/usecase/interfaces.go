@@ -9,15 +9,21 @@ import (
 
 // PackageRepository defines package discovery operations
 type PackageRepository interface {
-	ListPackages(ctx context.Context) ([]*domain.Package, error)
+	ListPackages(ctx context.Context, patterns []string) ([]*domain.Package, error)
 	GetPackage(ctx context.Context, pkgPath string) (*domain.Package, error)
 	FilterPackages(packages []*domain.Package, pattern string) []*domain.Package
 }
 
+// DirScoped is implemented by package repositories that can discover the
+// packages of another directory (module root)
+type DirScoped interface {
+	SetDir(dir string)
+}
+
 // TestRunner defines test execution operations
 type TestRunner interface {
 	Run(ctx context.Context, opts runner.RunOptions) (<-chan domain.TestEvent, <-chan error)
-	ListTests(ctx context.Context, pkg string) ([]string, error)
+	ListTests(ctx context.Context, opts runner.RunOptions) ([]domain.TestID, error)
 }
 
 // EventPublisher defines event publishing operations
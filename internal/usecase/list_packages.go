@@ -22,11 +22,11 @@ func NewListPackagesUseCase(repo PackageRepository, publisher EventPublisher) *L
 	}
 }
 
-// Execute discovers and returns all packages with tests
-func (uc *ListPackagesUseCase) Execute(ctx context.Context) ([]*domain.Package, error) {
-	logger.Debug("Executing ListPackagesUseCase")
+// Execute discovers and returns all packages with tests matching patterns
+func (uc *ListPackagesUseCase) Execute(ctx context.Context, patterns []string) ([]*domain.Package, error) {
+	logger.Debug("Executing ListPackagesUseCase", "patterns", patterns)
 
-	packages, err := uc.repo.ListPackages(ctx)
+	packages, err := uc.repo.ListPackages(ctx, patterns)
 	if err != nil {
 		logger.Error("Failed to list packages", "error", err)
 		return nil, err
@@ -41,6 +41,16 @@ func (uc *ListPackagesUseCase) Execute(ctx context.Context) ([]*domain.Package,
 	return packages, nil
 }
 
+// SetDir points package discovery at another directory. It reports false
+// when the repository cannot change directories, e.g. for replayed input.
+func (uc *ListPackagesUseCase) SetDir(dir string) bool {
+	scoped, ok := uc.repo.(DirScoped)
+	if ok {
+		scoped.SetDir(dir)
+	}
+	return ok
+}
+
 // FilterPackages filters packages based on a search pattern
 func (uc *ListPackagesUseCase) FilterPackages(packages []*domain.Package, pattern string) []*domain.Package {
 	return uc.repo.FilterPackages(packages, pattern)
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+)
+
+// lineCollector reassembles complete output lines from output events for
+// parsing benchmark results and fuzzing status. go test writes the name and
+// the measurements of a benchmark result separately, so a line can span
+// several events, and results of later -count rounds are not attributed to
+// the benchmark; lines are therefore joined per package.
+type lineCollector struct {
+	partial map[string]string
+}
+
+func newLineCollector() *lineCollector {
+	return &lineCollector{partial: make(map[string]string)}
+}
+
+// add consumes an event and returns the lines its output completes
+func (c *lineCollector) add(event domain.TestEvent) []string {
+	if event.Action != "output" {
+		if event.Test == "" {
+			// The package finished; nothing can complete a partial line
+			delete(c.partial, event.Package)
+		}
+		return nil
+	}
+
+	text := c.partial[event.Package] + event.Output
+	lines := strings.SplitAfter(text, "\n")
+	// The last element is the unterminated rest ("" after a newline)
+	if rest := lines[len(lines)-1]; rest != "" {
+		c.partial[event.Package] = rest
+	} else {
+		delete(c.partial, event.Package)
+	}
+	return lines[:len(lines)-1]
+}
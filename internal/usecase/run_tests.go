@@ -3,7 +3,11 @@ package usecase
 import (
 	"context"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
@@ -16,6 +20,17 @@ import (
 type RunTestsUseCase struct {
 	runner    TestRunner
 	publisher EventPublisher
+
+	mu       sync.RWMutex
+	defaults runner.RunOptions
+	// stallThreshold is how long a running test may print nothing before
+	// it is reported stalled; zero disables the watch
+	stallThreshold time.Duration
+	// cancels cancel the runs in progress, by run number
+	cancels map[int]context.CancelFunc
+	nextRun int
+	// running counts the runs in progress, for Wait
+	running sync.WaitGroup
 }
 
 // NewRunTestsUseCase creates a new RunTestsUseCase
@@ -26,70 +41,217 @@ func NewRunTestsUseCase(runner TestRunner, publisher EventPublisher) *RunTestsUs
 	}
 }
 
+// SetDefaults sets the options every run starts from. Packages in the
+// defaults are the patterns used by ExecuteAll.
+func (uc *RunTestsUseCase) SetDefaults(opts runner.RunOptions) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	uc.defaults = opts
+}
+
+// SetStallThreshold sets how long a running test may print nothing before
+// a TopicTestStalled event reports it; zero disables the watch
+func (uc *RunTestsUseCase) SetStallThreshold(threshold time.Duration) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	uc.stallThreshold = threshold
+}
+
+// Defaults returns the options every run starts from
+func (uc *RunTestsUseCase) Defaults() runner.RunOptions {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+
+	return uc.defaults
+}
+
+// baseOptions returns a copy of the defaults with the package scope cleared.
+// The -bench regexp is kept so package runs include the benchmarks; -fuzz
+// is only set by ExecuteFuzz, as it needs a single package and target.
+func (uc *RunTestsUseCase) baseOptions() runner.RunOptions {
+	opts := uc.Defaults()
+	opts.Packages = nil
+	opts.RunRegex = ""
+	opts.Fuzz = ""
+	opts.Verbose = true
+	return opts
+}
+
 // ExecutePackage runs all tests in a package
 func (uc *RunTestsUseCase) ExecutePackage(ctx context.Context, pkgID domain.PkgID) error {
-	opts := runner.RunOptions{
-		Packages: []string{string(pkgID)},
-		Verbose:  true,
-	}
+	opts := uc.baseOptions()
+	opts.Packages = []string{string(pkgID)}
 
 	return uc.execute(ctx, opts)
 }
 
 // ExecuteTest runs a specific test
 func (uc *RunTestsUseCase) ExecuteTest(ctx context.Context, testID domain.TestID) error {
-	opts := runner.RunOptions{
-		Packages: []string{string(testID.Pkg)},
-		RunRegex: "^" + testID.Name + "$",
-		Verbose:  true,
+	opts := uc.baseOptions()
+	opts.Packages = []string{string(testID.Pkg)}
+
+	return uc.execute(ctx, selectTests(opts, []string{testID.Name}))
+}
+
+// ExecuteAll runs all tests matched by the default package patterns and
+// -run regex
+func (uc *RunTestsUseCase) ExecuteAll(ctx context.Context) error {
+	defaults := uc.Defaults()
+	opts := uc.baseOptions()
+	opts.Packages = defaults.Packages
+	opts.RunRegex = defaults.RunRegex
+	if len(opts.Packages) == 0 {
+		opts.Packages = []string{"./..."}
 	}
 
 	return uc.execute(ctx, opts)
 }
 
-// ExecuteAll runs all tests
-func (uc *RunTestsUseCase) ExecuteAll(ctx context.Context) error {
-	opts := runner.RunOptions{
-		Packages: []string{"./..."},
-		Verbose:  true,
+// ExecuteBenchmarks runs only the benchmarks of the default package
+// patterns: those matching the default -bench regexp, or all of them
+func (uc *RunTestsUseCase) ExecuteBenchmarks(ctx context.Context) error {
+	defaults := uc.Defaults()
+	opts := uc.baseOptions()
+	opts.Packages = defaults.Packages
+	if len(opts.Packages) == 0 {
+		opts.Packages = []string{"./..."}
 	}
+	// Skip the tests; -run does not apply to benchmarks
+	opts.RunRegex = "^$"
+	if opts.Bench == "" {
+		opts.Bench = "."
+	}
+
+	return uc.execute(ctx, opts)
+}
+
+// ExecuteFuzz fuzzes a single fuzz target for the default -fuzztime. The
+// other tests of the package are skipped; the target's seed corpus is run
+// while gathering the baseline coverage.
+func (uc *RunTestsUseCase) ExecuteFuzz(ctx context.Context, testID domain.TestID) error {
+	opts := uc.baseOptions()
+	opts.Packages = []string{string(testID.Pkg)}
+	opts.RunRegex = "^$"
+	opts.Bench = ""
+	opts.Count = 0
+	// go test refuses to fuzz with -coverprofile
+	opts.Cover = false
+	opts.CoverProfile = ""
+	opts.Fuzz = "^" + regexp.QuoteMeta(testID.Name) + "$"
 
 	return uc.execute(ctx, opts)
 }
 
-// ExecuteMultipleTests runs multiple specific tests
+// ExecuteMultipleTests runs the given tests as one logical run: a go test
+// invocation per package, at most the package parallelism at a time, with
+// their events merged into a single summary
 func (uc *RunTestsUseCase) ExecuteMultipleTests(ctx context.Context, testIDs []domain.TestID) error {
 	if len(testIDs) == 0 {
 		return nil
 	}
 
-	// Group tests by package
-	testsByPackage := make(map[domain.PkgID][]string)
+	// Group tests by package, keeping the order they were given in
+	var pkgOrder []string
+	testsByPackage := make(map[string][]string)
+	for _, testID := range testIDs {
+		if _, ok := testsByPackage[testID.Pkg]; !ok {
+			pkgOrder = append(pkgOrder, testID.Pkg)
+		}
+		testsByPackage[testID.Pkg] = append(testsByPackage[testID.Pkg], testID.Name)
+	}
+
+	runs := make([]runner.RunOptions, 0, len(pkgOrder))
+	for _, pkg := range pkgOrder {
+		opts := uc.baseOptions()
+		opts.Packages = []string{pkg}
+		runs = append(runs, selectTests(opts, testsByPackage[pkg]))
+	}
+
+	return uc.executeRuns(ctx, runs)
+}
+
+// ExecuteCoverageIndex runs each test on its own with a coverage profile
+// and publishes an index of the source lines each of them executes, along
+// with the combined coverage. Benchmarks are left out: their coverage
+// depends on the iterations the benchmark happens to run.
+func (uc *RunTestsUseCase) ExecuteCoverageIndex(ctx context.Context, testIDs []domain.TestID) error {
+	var tests []domain.TestID
+	var runs []runner.RunOptions
 	for _, testID := range testIDs {
-		// Escape special regex characters in test names
-		escapedName := regexp.QuoteMeta(testID.Name)
-		testsByPackage[domain.PkgID(testID.Pkg)] = append(testsByPackage[domain.PkgID(testID.Pkg)], escapedName)
+		if domain.KindOf(testID.Name) == domain.KindBenchmark {
+			continue
+		}
+		opts := uc.baseOptions()
+		opts.Packages = []string{testID.Pkg}
+		// Repeating a test does not change the lines it executes
+		opts.Count = 0
+		tests = append(tests, testID)
+		runs = append(runs, selectTests(opts, []string{testID.Name}))
+	}
+	if len(runs) == 0 {
+		return nil
 	}
 
-	// For now, if tests are from same package, run them together
-	// TODO: Support running tests from multiple packages
-	for pkgID, testNames := range testsByPackage {
-		// Create regex pattern with OR condition
-		runRegex := "^(" + strings.Join(testNames, "|") + ")$"
+	return uc.startRuns(ctx, runs, testCoverProfiles(runs, tests))
+}
 
-		opts := runner.RunOptions{
-			Packages: []string{string(pkgID)},
-			RunRegex: runRegex,
-			Verbose:  true,
+// selectTests narrows opts to the named functions of one package: tests,
+// fuzz targets and examples through -run, benchmarks through -bench
+func selectTests(opts runner.RunOptions, names []string) runner.RunOptions {
+	var tests, benchmarks []string
+	for _, name := range names {
+		if domain.KindOf(name) == domain.KindBenchmark {
+			benchmarks = append(benchmarks, name)
+		} else {
+			tests = append(tests, name)
 		}
+	}
+
+	// "^$" matches no test, so a selection of benchmarks only runs those
+	opts.RunRegex = "^$"
+	if len(tests) > 0 {
+		opts.RunRegex = namePattern(tests)
+	}
+	opts.Bench = ""
+	if len(benchmarks) > 0 {
+		opts.Bench = namePattern(benchmarks)
+	}
+	return opts
+}
 
-		// Execute the tests
-		if err := uc.execute(ctx, opts); err != nil {
-			return err
+// namePattern builds a -run or -bench pattern for the named tests. go test
+// splits patterns at slashes into one regexp per subtest level, so every
+// level gets its own alternation. Levels below the shallowest name are
+// left out, which runs all subtests of that name.
+func namePattern(names []string) string {
+	var levels [][]string
+	seen := make(map[string]bool)
+	depth := 0
+	for i, name := range names {
+		parts := strings.Split(name, "/")
+		if i == 0 || len(parts) < depth {
+			depth = len(parts)
+		}
+		for level, part := range parts {
+			if level == len(levels) {
+				levels = append(levels, nil)
+			}
+			key := strconv.Itoa(level) + "/" + part
+			if !seen[key] {
+				seen[key] = true
+				// Escape special regex characters in test names
+				levels[level] = append(levels[level], regexp.QuoteMeta(part))
+			}
 		}
 	}
 
-	return nil
+	patterns := make([]string, depth)
+	for level := range patterns {
+		patterns[level] = "^(" + strings.Join(levels[level], "|") + ")$"
+	}
+	return strings.Join(patterns, "/")
 }
 
 // ExecuteWithOptions runs tests with custom options
@@ -98,33 +260,237 @@ func (uc *RunTestsUseCase) ExecuteWithOptions(ctx context.Context, opts runner.R
 }
 
 func (uc *RunTestsUseCase) execute(ctx context.Context, opts runner.RunOptions) error {
-	logger.Info("Running tests", "options", opts)
+	return uc.executeRuns(ctx, []runner.RunOptions{opts})
+}
 
-	// Publish test started event
+// executeRuns starts the runner invocations as one logical run and
+// publishes their events and a single summary when all of them finish
+func (uc *RunTestsUseCase) executeRuns(ctx context.Context, runs []runner.RunOptions) error {
+	return uc.startRuns(ctx, runs, withCoverProfiles(runs))
+}
+
+// startRuns starts the runs, which write the given coverage profiles
+func (uc *RunTestsUseCase) startRuns(ctx context.Context, runs []runner.RunOptions, profiles []coverProfile) error {
+	runCtx, done := uc.track(ctx)
+	logger.Info("Running tests", "runs", len(runs), "options", runs)
+
+	// The started event describes the run as a whole
+	started := runs[0]
+	if len(runs) > 1 {
+		started.Packages = nil
+		started.RunRegex = ""
+		for _, run := range runs {
+			started.Packages = append(started.Packages, run.Packages...)
+		}
+	}
 	uc.publisher.Publish(ctx, eventbus.TopicTestStarted, &TestStartedEvent{
 		StartedAt: time.Now(),
-		Options:   opts,
+		Options:   started,
+		Runs:      runs,
 	})
 
-	// Run tests and stream events
-	events, errs := uc.runner.Run(ctx, opts)
+	events, errs := uc.mergeRuns(runCtx, runs)
 
 	// Process events
-	go uc.processEvents(ctx, events, errs)
+	// The events of a cancelled run, down to its summary, are still
+	// published
+	go func() {
+		defer done()
+		uc.processEvents(context.WithoutCancel(ctx), runCtx, started, profiles, events, errs)
+	}()
 
 	return nil
 }
 
-func (uc *RunTestsUseCase) processEvents(ctx context.Context, events <-chan domain.TestEvent, errs <-chan error) {
+// track derives the context of a run that Cancel cancels; done releases it
+// once the run has ended
+func (uc *RunTestsUseCase) track(ctx context.Context) (context.Context, func()) {
+	runCtx, cancel := context.WithCancel(ctx)
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.cancels == nil {
+		uc.cancels = make(map[int]context.CancelFunc)
+	}
+	id := uc.nextRun
+	uc.nextRun++
+	uc.cancels[id] = cancel
+	uc.running.Add(1)
+
+	return runCtx, func() {
+		cancel()
+		uc.mu.Lock()
+		defer uc.mu.Unlock()
+		delete(uc.cancels, id)
+		uc.running.Done()
+	}
+}
+
+// Cancel cancels the runs in progress: their test processes are sent
+// SIGQUIT, so test binaries dump their goroutines, and killed after
+// runner.QuitGrace. Each run still completes with a summary marked
+// cancelled. It reports whether any run was in progress.
+func (uc *RunTestsUseCase) Cancel() bool {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	for _, cancel := range uc.cancels {
+		cancel()
+	}
+	return len(uc.cancels) > 0
+}
+
+// Wait waits up to timeout for the runs in progress to end. A cancelled
+// run's processes are only killed while this process lives, so callers wait
+// runner.QuitGrace and a little more before exiting. It reports whether
+// every run ended.
+func (uc *RunTestsUseCase) Wait(timeout time.Duration) bool {
+	ended := make(chan struct{})
+	go func() {
+		uc.running.Wait()
+		close(ended)
+	}()
+
+	select {
+	case <-ended:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// mergeRuns starts the runs with bounded concurrency and merges their event
+// and error streams. Both channels are closed once every run has finished.
+func (uc *RunTestsUseCase) mergeRuns(ctx context.Context, runs []runner.RunOptions) (<-chan domain.TestEvent, <-chan error) {
+	if len(runs) == 1 {
+		return uc.runner.Run(ctx, runs[0])
+	}
+
+	limit := runs[0].PackageParallel
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+
+	events := make(chan domain.TestEvent, 100)
+	errs := make(chan error, len(runs))
+	slots := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		for _, opts := range runs {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			}
+
+			wg.Add(1)
+			go func(opts runner.RunOptions) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				uc.forward(ctx, opts, events, errs)
+			}(opts)
+		}
+		wg.Wait()
+	}()
+
+	return events, errs
+}
+
+// forward runs one invocation and copies its events and errors to the
+// merged streams
+func (uc *RunTestsUseCase) forward(ctx context.Context, opts runner.RunOptions, events chan<- domain.TestEvent, errs chan<- error) {
+	// Runs are drained even when cancelled, to keep the output of their
+	// processes quitting
+	runEvents, runErrs := uc.runner.Run(ctx, opts)
+	for runEvents != nil || runErrs != nil {
+		select {
+		case event, ok := <-runEvents:
+			if !ok {
+				runEvents = nil
+				continue
+			}
+			events <- event
+		case err, ok := <-runErrs:
+			if !ok {
+				runErrs = nil
+				continue
+			}
+			if err != nil {
+				// Buffered for one error per run; never block the run on it
+				select {
+				case errs <- err:
+				default:
+					logger.Error("Dropped test execution error", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// processEvents publishes the events of a run and its summary once the
+// event stream closes. Events keep being read after runCtx is cancelled,
+// until the run's processes have exited.
+func (uc *RunTestsUseCase) processEvents(ctx, runCtx context.Context, opts runner.RunOptions, profiles []coverProfile, events <-chan domain.TestEvent, errs <-chan error) {
 	summary := &domain.TestSummary{
 		StartedAt: time.Now(),
+		Env:       opts.EnvOverrides(),
+	}
+	lines := newLineCollector()
+	var build domain.BuildOutput
+	var races domain.RaceLog
+	running := make(map[domain.TestID]bool)
+	var benchmarks domain.BenchmarkNames
+	cancelled := runCtx.Done()
+
+	uc.mu.RLock()
+	threshold := uc.stallThreshold
+	uc.mu.RUnlock()
+	stalls := domain.NewStallWatch(threshold)
+	var checks <-chan time.Time
+	if threshold > 0 {
+		ticker := time.NewTicker(max(min(threshold/2, time.Second), 10*time.Millisecond))
+		defer ticker.Stop()
+		checks = ticker.C
 	}
 
 	for {
 		select {
 		case event, ok := <-events:
 			if !ok {
-				// Stream closed, tests completed
+				// Stream closed, tests completed. The run's last errors may
+				// still be buffered.
+				if errs != nil {
+					for err := range errs {
+						uc.publishError(ctx, err)
+					}
+				}
+				coverage, index := loadCoverage(profiles)
+				if coverage != nil {
+					summary.Coverage = coverage
+					uc.publisher.Publish(ctx, eventbus.TopicCoverageReady, coverage)
+				}
+				if index != nil {
+					uc.publisher.Publish(ctx, eventbus.TopicCoverageIndex, index)
+				}
+				if summary.Cancelled {
+					summary.Unfinished = unfinishedTests(running)
+				} else {
+					// A test binary that exits without reporting, such as
+					// one sent SIGQUIT, fails the tests it was running
+					for _, id := range unfinishedTests(running) {
+						failed := domain.TestEvent{Time: time.Now(), Action: "fail", Package: id.Pkg, Test: id.Name}
+						uc.publisher.Publish(ctx, eventbus.TopicTestEvent, failed)
+						uc.updateSummary(summary, failed)
+					}
+				}
+				summary.BuildErrors = build.Errors
+				sort.Strings(summary.BuildFailed)
+				summary.Races = races.Races
 				summary.CompletedAt = time.Now()
 				summary.Duration = summary.CompletedAt.Sub(summary.StartedAt)
 				uc.publisher.Publish(ctx, eventbus.TopicTestCompleted, summary)
@@ -134,25 +500,132 @@ func (uc *RunTestsUseCase) processEvents(ctx context.Context, events <-chan doma
 			// Publish each test event
 			uc.publisher.Publish(ctx, eventbus.TopicTestEvent, event)
 
+			stalls.Observe(event, time.Now())
+
 			// Update summary based on event
 			uc.updateSummary(summary, event)
+			if event.Test != "" {
+				id := domain.TestID{Pkg: event.Package, Name: event.Test}
+				switch event.Action {
+				case "run":
+					running[id] = true
+					if domain.KindOf(id.Name) == domain.KindBenchmark {
+						benchmarks.Add(id)
+					}
+				case "pass", "fail", "skip", "bench":
+					delete(running, id)
+				}
+			} else if event.Action == "fail" && event.FailedBuild != "" {
+				addBuildFailed(summary, event.Package)
+			}
+
+			switch {
+			case event.Action == "build-output":
+				build.Add(event.Package, event.Output)
+			case event.Action == "build-fail", event.Test == "" && (event.Action == "pass" || event.Action == "fail" || event.Action == "skip"):
+				build.End()
+			}
+			for _, line := range lines.add(event) {
+				uc.parseLine(ctx, summary, running, &benchmarks, event, line)
+				// Each report is published with the test that printed it;
+				// the summary has the unique races
+				id := domain.TestID{Pkg: event.Package, Name: event.Test}
+				if race, ok := races.Add(id, line); ok {
+					uc.publisher.Publish(ctx, eventbus.TopicDataRace, race)
+				}
+			}
 
 			// Publish failure events
 			if event.Action == "fail" {
 				uc.publisher.PublishAsync(ctx, eventbus.TopicTestFailed, event)
 			}
 
-		case err := <-errs:
-			if err != nil {
-				logger.Error("Test execution error", "error", err)
-				uc.publisher.Publish(ctx, eventbus.TopicError, err)
+		case err, ok := <-errs:
+			if !ok {
+				// Errors are done; keep draining events
+				errs = nil
+				continue
+			}
+			uc.publishError(ctx, err)
+
+		case now := <-checks:
+			for _, stall := range stalls.Check(now) {
+				logger.Warn("Test stalled", "test", stall.ID.String(), "idle", stall.Idle(now))
+				uc.publisher.Publish(ctx, eventbus.TopicTestStalled, stall)
 			}
 
-		case <-ctx.Done():
-			logger.Debug("Test execution cancelled")
+		case <-cancelled:
+			logger.Info("Test execution cancelled")
+			summary.Cancelled = true
+			cancelled = nil
+		}
+	}
+}
+
+// publishError publishes an error of a run
+func (uc *RunTestsUseCase) publishError(ctx context.Context, err error) {
+	if err != nil {
+		logger.Error("Test execution error", "error", err)
+		uc.publisher.Publish(ctx, eventbus.TopicError, err)
+	}
+}
+
+// unfinishedTests lists the tests still running, sorted
+func unfinishedTests(running map[domain.TestID]bool) []domain.TestID {
+	ids := make([]domain.TestID, 0, len(running))
+	for id := range running {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Pkg != ids[j].Pkg {
+			return ids[i].Pkg < ids[j].Pkg
+		}
+		return ids[i].Name < ids[j].Name
+	})
+	return ids
+}
+
+// parseLine publishes the benchmark result or fuzzing status an output
+// line reports, completed by event, and records packages that failed to
+// build. benchmarks are the benchmarks the run started.
+func (uc *RunTestsUseCase) parseLine(ctx context.Context, summary *domain.TestSummary, running map[domain.TestID]bool, benchmarks *domain.BenchmarkNames, event domain.TestEvent, line string) {
+	if pkg, ok := domain.ParseBuildFailed(line); ok && event.Test == "" {
+		addBuildFailed(summary, pkg)
+		return
+	}
+
+	if result, ok := benchmarks.ParseLine(event.Package, line); ok {
+		summary.Benchmarks = append(summary.Benchmarks, result)
+		uc.publisher.Publish(ctx, eventbus.TopicBenchmarkResult, result)
+		// test2json never reports benchmarks as passed; a result means the
+		// benchmark and its top-level parent completed
+		topLevel, _, _ := strings.Cut(result.ID.Name, "/")
+		delete(running, result.ID)
+		delete(running, domain.TestID{Pkg: result.ID.Pkg, Name: topLevel})
+		return
+	}
+
+	// Fuzzing status lines are attributed to the fuzz target
+	id := domain.TestID{Pkg: event.Package, Name: event.Test}
+	if progress, ok := domain.ParseFuzzProgress(id, line); ok {
+		uc.publisher.Publish(ctx, eventbus.TopicFuzzProgress, progress)
+		return
+	}
+	if crasher, ok := domain.ParseFuzzCrasher(event.Package, line); ok {
+		summary.Crashers = append(summary.Crashers, crasher)
+		uc.publisher.Publish(ctx, eventbus.TopicFuzzCrasher, crasher)
+	}
+}
+
+// addBuildFailed records a package that failed to build. Newer toolchains
+// report it both in the package's output and on its fail event.
+func addBuildFailed(summary *domain.TestSummary, pkg string) {
+	for _, failed := range summary.BuildFailed {
+		if failed == pkg {
 			return
 		}
 	}
+	summary.BuildFailed = append(summary.BuildFailed, pkg)
 }
 
 func (uc *RunTestsUseCase) updateSummary(summary *domain.TestSummary, event domain.TestEvent) {
@@ -168,6 +641,9 @@ func (uc *RunTestsUseCase) updateSummary(summary *domain.TestSummary, event doma
 		if event.Test != "" {
 			summary.Failed++
 			summary.TotalTests++
+		} else {
+			summary.FailedPackages++
+			summary.TotalPackages++
 		}
 	case "skip":
 		if event.Test != "" {
@@ -180,5 +656,9 @@ func (uc *RunTestsUseCase) updateSummary(summary *domain.TestSummary, event doma
 // TestStartedEvent is published when tests start
 type TestStartedEvent struct {
 	StartedAt time.Time
-	Options   runner.RunOptions
+	// Options describe the whole run; with several invocations Packages
+	// lists all of their packages
+	Options runner.RunOptions
+	// Runs are the go test invocations that make up the run
+	Runs []runner.RunOptions
 }
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YuminosukeSato/lazygotest/internal/adapter/secondary/runner"
+	"github.com/YuminosukeSato/lazygotest/internal/domain"
+	"github.com/YuminosukeSato/lazygotest/internal/shared/eventbus"
+	"github.com/YuminosukeSato/lazygotest/internal/testutil"
+)
+
+// recorder records the summaries published
+type recorder struct {
+	summaries chan *domain.TestSummary
+}
+
+func newRecorder() *recorder {
+	return &recorder{summaries: make(chan *domain.TestSummary, 10)}
+}
+
+func (r *recorder) Publish(ctx context.Context, topic string, event interface{}) {
+	if topic == eventbus.TopicTestCompleted {
+		r.summaries <- event.(*domain.TestSummary)
+	}
+}
+
+func (r *recorder) PublishAsync(ctx context.Context, topic string, event interface{}) {
+	r.Publish(ctx, topic, event)
+}
+
+// summary waits for the one summary of a run and checks no other follows
+func (r *recorder) summary(t *testing.T) *domain.TestSummary {
+	t.Helper()
+	var summary *domain.TestSummary
+	select {
+	case summary = <-r.summaries:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no summary published")
+	}
+	select {
+	case extra := <-r.summaries:
+		t.Fatalf("second summary published: %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+	return summary
+}
+
+func TestNamePattern(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"single test", []string{"TestA"}, "^(TestA)$"},
+		{"several tests", []string{"TestA", "TestB"}, "^(TestA|TestB)$"},
+		{"nested subtest", []string{"TestA/sub/deep"}, "^(TestA)$/^(sub)$/^(deep)$"},
+		{"subtests of one test", []string{"TestA/x", "TestA/y"}, "^(TestA)$/^(x|y)$"},
+		{"duplicates", []string{"TestA", "TestA"}, "^(TestA)$"},
+		{
+			"metacharacters",
+			[]string{"TestParse/a+b_(1.5)", "TestParse/[x]|y"},
+			`^(TestParse)$/^(a\+b_\(1\.5\)|\[x\]\|y)$`,
+		},
+		{
+			// A top-level test runs all its subtests, so the levels below
+			// it are left out for every name
+			"top-level and subtest",
+			[]string{"TestA/sub", "TestB"},
+			"^(TestA|TestB)$",
+		},
+		{
+			"subtests at different depths",
+			[]string{"TestA/x/deep", "TestB/y"},
+			"^(TestA|TestB)$/^(x|y)$",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := namePattern(tt.names)
+			if got != tt.want {
+				t.Errorf("namePattern(%q) = %q, want %q", tt.names, got, tt.want)
+			}
+			// Every level must compile, as go test compiles them
+			for _, level := range strings.Split(got, "/") {
+				if _, err := regexp.Compile(level); err != nil {
+					t.Errorf("level %q does not compile: %v", level, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectTests(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string
+		wantRun   string
+		wantBench string
+	}{
+		{"tests only", []string{"TestA", "ExampleB"}, "^(TestA|ExampleB)$", ""},
+		{"benchmarks only", []string{"BenchmarkA"}, "^$", "^(BenchmarkA)$"},
+		{"both", []string{"TestA", "BenchmarkA/size=10"}, "^(TestA)$", "^(BenchmarkA)$/^(size=10)$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := selectTests(runner.RunOptions{Bench: "."}, tt.names)
+			if opts.RunRegex != tt.wantRun || opts.Bench != tt.wantBench {
+				t.Errorf("selectTests(%q) = -run %q -bench %q, want -run %q -bench %q",
+					tt.names, opts.RunRegex, opts.Bench, tt.wantRun, tt.wantBench)
+			}
+		})
+	}
+}
+
+func TestMergeRunsLimitsPackageParallelism(t *testing.T) {
+	r := &testutil.FakeRunner{Delay: 20 * time.Millisecond}
+	uc := NewRunTestsUseCase(r, newRecorder())
+
+	var runs []runner.RunOptions
+	for _, pkg := range []string{"a", "b", "c", "d", "e", "f"} {
+		runs = append(runs, runner.RunOptions{Packages: []string{pkg}, PackageParallel: 2})
+	}
+	events, errs := uc.mergeRuns(context.Background(), runs)
+	for range events {
+	}
+	for range errs {
+	}
+
+	if active := r.MaxActive(); active > 2 {
+		t.Errorf("%d runs in progress at once, want at most 2", active)
+	}
+	if got := len(r.Runs()); got != len(runs) {
+		t.Errorf("ran %d packages, want %d", got, len(runs))
+	}
+}
+
+func TestExecuteMultipleTestsPublishesOneSummary(t *testing.T) {
+	r := &testutil.FakeRunner{
+		Tests: map[string][]string{
+			"example.com/a": {"TestA1", "TestA2", "TestA3"},
+			"example.com/b": {"TestB1"},
+		},
+		Delay: 10 * time.Millisecond,
+	}
+	rec := newRecorder()
+	uc := NewRunTestsUseCase(r, rec)
+	uc.SetDefaults(runner.RunOptions{PackageParallel: 1})
+
+	ids := []domain.TestID{
+		{Pkg: "example.com/a", Name: "TestA1"},
+		{Pkg: "example.com/b", Name: "TestB1"},
+		{Pkg: "example.com/a", Name: "TestA3"},
+	}
+	if err := uc.ExecuteMultipleTests(context.Background(), ids); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := rec.summary(t)
+	if summary.Passed != 3 || summary.TotalTests != 3 || summary.TotalPackages != 2 {
+		t.Errorf("summary has %d passed of %d tests in %d packages, want 3 of 3 in 2",
+			summary.Passed, summary.TotalTests, summary.TotalPackages)
+	}
+	if active := r.MaxActive(); active > 1 {
+		t.Errorf("%d runs in progress at once, want 1", active)
+	}
+}
+
+func TestWaitForRuns(t *testing.T) {
+	r := &testutil.FakeRunner{Delay: 200 * time.Millisecond}
+	uc := NewRunTestsUseCase(r, newRecorder())
+	if !uc.Wait(time.Millisecond) {
+		t.Error("Wait without runs timed out")
+	}
+
+	if err := uc.ExecutePackage(context.Background(), "example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+	if uc.Wait(10 * time.Millisecond) {
+		t.Error("Wait returned before the run ended")
+	}
+	if !uc.Wait(5 * time.Second) {
+		t.Error("Wait timed out on a run that ends")
+	}
+}
+
+func TestExecuteFuzzWithoutCoverProfile(t *testing.T) {
+	r := &testutil.FakeRunner{}
+	rec := newRecorder()
+	uc := NewRunTestsUseCase(r, rec)
+	// Coverage as set by -cover, -diff-base or the TUI toggle
+	uc.SetDefaults(runner.RunOptions{Cover: true, CoverProfile: "cover.out", Fuzztime: "10s"})
+
+	id := domain.TestID{Pkg: "example.com/fs/fz", Name: "FuzzFind"}
+	if err := uc.ExecuteFuzz(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	rec.summary(t)
+
+	// go test refuses to fuzz with -coverprofile
+	runs := r.Runs()
+	if len(runs) != 1 {
+		t.Fatalf("%d runs, want 1", len(runs))
+	}
+	opts := runs[0]
+	if opts.Fuzz != "^FuzzFind$" || opts.Cover || opts.CoverProfile != "" {
+		t.Errorf("run with -fuzz %q cover %v profile %q, want ^FuzzFind$ without coverage",
+			opts.Fuzz, opts.Cover, opts.CoverProfile)
+	}
+}